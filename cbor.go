@@ -0,0 +1,629 @@
+package did
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/pascaldekloe/did/internal/cbor"
+	"github.com/pascaldekloe/did/internal/sorted"
+)
+
+// The CBOR representation follows the JSON data model of each type, in the
+// deterministic encoding of “Concise Binary Object Representation (CBOR)” RFC
+// 8949, subsection 4.2.1. Input in any other than the deterministic encoding is
+// rejected. The core properties encode directly. Only the values which the
+// types hold as JSON, i.e., the Additional properties and the ServiceEndpoint
+// Maps, convert value for value.
+
+// MarshalCBOR returns the deterministic encoding of doc.
+func (doc *Document) MarshalCBOR() ([]byte, error) {
+	return doc.appendCBOR(make([]byte, 0, 256))
+}
+
+// UnmarshalCBOR decodes a single data item into doc.
+func (doc *Document) UnmarshalCBOR(data []byte) error {
+	dec := cbor.NewDecoder(data)
+	if err := doc.decodeCBOR(dec); err != nil {
+		return err
+	}
+	return dec.End()
+}
+
+// MarshalCBOR returns the deterministic encoding of m.
+func (m *VerificationMethod) MarshalCBOR() ([]byte, error) {
+	return m.appendCBOR(make([]byte, 0, 128))
+}
+
+// UnmarshalCBOR decodes a single data item into m.
+func (m *VerificationMethod) UnmarshalCBOR(data []byte) error {
+	dec := cbor.NewDecoder(data)
+	if err := m.decodeCBOR(dec); err != nil {
+		return err
+	}
+	return dec.End()
+}
+
+// MarshalCBOR returns the deterministic encoding of srv.
+func (srv *Service) MarshalCBOR() ([]byte, error) {
+	return srv.appendCBOR(make([]byte, 0, 128))
+}
+
+// UnmarshalCBOR decodes a single data item into srv.
+func (srv *Service) UnmarshalCBOR(data []byte) error {
+	dec := cbor.NewDecoder(data)
+	if err := srv.decodeCBOR(dec); err != nil {
+		return err
+	}
+	return dec.End()
+}
+
+// MarshalCBOR returns the deterministic encoding of e.
+func (e ServiceEndpoint) MarshalCBOR() ([]byte, error) {
+	return e.appendCBOR(make([]byte, 0, 64))
+}
+
+// UnmarshalCBOR decodes a single data item into e.
+func (e *ServiceEndpoint) UnmarshalCBOR(data []byte) error {
+	dec := cbor.NewDecoder(data)
+	if err := e.decodeCBOR(dec); err != nil {
+		return err
+	}
+	return dec.End()
+}
+
+// AppendCBOR appends the deterministic encoding of doc to buf.
+func (doc *Document) appendCBOR(buf []byte) ([]byte, error) {
+	var m cbor.Map
+	m.Set("id", cbor.AppendText(nil, doc.Subject.String()))
+
+	if len(doc.AlsoKnownAs) != 0 {
+		item := cbor.AppendArrayHead(nil, len(doc.AlsoKnownAs))
+		for _, s := range doc.AlsoKnownAs {
+			item = cbor.AppendText(item, s)
+		}
+		m.Set("alsoKnownAs", item)
+	}
+
+	if len(doc.Controllers) != 0 {
+		item := cbor.AppendArrayHead(nil, len(doc.Controllers))
+		for _, d := range doc.Controllers {
+			item = cbor.AppendText(item, d.String())
+		}
+		m.Set("controller", item)
+	}
+
+	if len(doc.VerificationMethods) != 0 {
+		item := cbor.AppendArrayHead(nil, len(doc.VerificationMethods))
+		for _, method := range doc.VerificationMethods {
+			if method == nil {
+				item = cbor.AppendNull(item)
+				continue
+			}
+			var err error
+			item, err = method.appendCBOR(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m.Set("verificationMethod", item)
+	}
+
+	relationships := [...]struct {
+		name string
+		r    *VerificationRelationship
+	}{
+		{Authentication, doc.Authentication},
+		{AssertionMethod, doc.AssertionMethod},
+		{KeyAgreement, doc.KeyAgreement},
+		{CapabilityInvocation, doc.CapabilityInvocation},
+		{CapabilityDelegation, doc.CapabilityDelegation},
+	}
+	for _, property := range relationships {
+		if property.r == nil {
+			continue
+		}
+		item, err := property.r.appendCBOR(nil)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(property.name, item)
+	}
+
+	if len(doc.Services) != 0 {
+		item := cbor.AppendArrayHead(nil, len(doc.Services))
+		for _, srv := range doc.Services {
+			if srv == nil {
+				item = cbor.AppendNull(item)
+				continue
+			}
+			var err error
+			item, err = srv.appendCBOR(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m.Set("service", item)
+	}
+
+	for _, property := range sorted.Keys(doc.Additional) {
+		if coreDocumentProperty(property) {
+			return nil, fmt.Errorf(`core DID document property %q in additional set`, property)
+		}
+		item, err := cbor.AppendJSON(nil, doc.Additional[property])
+		if err != nil {
+			return nil, fmt.Errorf("DID document property %q: %w", property, err)
+		}
+		m.Set(property, item)
+	}
+
+	return m.Append(buf), nil
+}
+
+// DecodeCBOR reads the next data item into doc. Any "@context" is ignored, as
+// it belongs to the JSON-LD representation.
+func (doc *Document) decodeCBOR(dec *cbor.Decoder) error {
+	// reset
+	*doc = Document{}
+
+	return dec.Map(func(property string) error {
+		var err error
+		switch property {
+		case "@context":
+			_, err = dec.JSON()
+		case "id":
+			doc.Subject, err = decodeDIDCBOR(dec)
+		case "alsoKnownAs":
+			doc.AlsoKnownAs, err = decodeStringsCBOR(dec)
+		case "controller":
+			doc.Controllers, err = decodeSetCBOR(dec)
+		case "verificationMethod":
+			doc.VerificationMethods, err = decodeMethodsCBOR(dec)
+		case Authentication:
+			doc.Authentication, err = decodeRelationshipCBOR(dec)
+		case AssertionMethod:
+			doc.AssertionMethod, err = decodeRelationshipCBOR(dec)
+		case KeyAgreement:
+			doc.KeyAgreement, err = decodeRelationshipCBOR(dec)
+		case CapabilityInvocation:
+			doc.CapabilityInvocation, err = decodeRelationshipCBOR(dec)
+		case CapabilityDelegation:
+			doc.CapabilityDelegation, err = decodeRelationshipCBOR(dec)
+		case "service":
+			doc.Services, err = decodeServicesCBOR(dec)
+		default:
+			doc.Additional, err = decodeAdditionalCBOR(dec, doc.Additional, property)
+		}
+		if err != nil {
+			return fmt.Errorf("DID document CBOR %q: %w", property, err)
+		}
+		return nil
+	})
+}
+
+// AppendCBOR appends the deterministic encoding of r to buf.
+func (r *VerificationRelationship) appendCBOR(buf []byte) ([]byte, error) {
+	if len(r.Methods) == 0 && len(r.URIRefs) == 0 {
+		return cbor.AppendNull(buf), nil
+	}
+
+	buf = cbor.AppendArrayHead(buf, len(r.Methods)+len(r.URIRefs))
+	for _, m := range r.Methods {
+		if m == nil {
+			buf = cbor.AppendNull(buf)
+			continue
+		}
+		var err error
+		buf, err = m.appendCBOR(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range r.URIRefs {
+		buf = cbor.AppendText(buf, u.String())
+	}
+	return buf, nil
+}
+
+// AppendCBOR appends the deterministic encoding of m to buf.
+func (m *VerificationMethod) appendCBOR(buf []byte) ([]byte, error) {
+	var entries cbor.Map
+	entries.Set("id", cbor.AppendText(nil, m.ID.String()))
+	entries.Set("type", cbor.AppendText(nil, m.Type))
+	entries.Set("controller", cbor.AppendText(nil, m.Controller.String()))
+
+	for _, property := range sorted.Keys(m.Additional) {
+		switch property {
+		case "id", "type", "controller":
+			return nil, fmt.Errorf(`core DID verification-method property %q in additional set`, property)
+		}
+		item, err := cbor.AppendJSON(nil, m.Additional[property])
+		if err != nil {
+			return nil, fmt.Errorf("DID verification-method property %q: %w", property, err)
+		}
+		entries.Set(property, item)
+	}
+
+	return entries.Append(buf), nil
+}
+
+// DecodeCBOR reads the next data item into m.
+func (m *VerificationMethod) decodeCBOR(dec *cbor.Decoder) error {
+	// reset
+	*m = VerificationMethod{}
+
+	var hasID, hasType, hasController bool
+	err := dec.Map(func(property string) error {
+		var err error
+		switch property {
+		case "id":
+			hasID = true
+			var u *URL
+			u, err = decodeURLCBOR(dec)
+			if err == nil {
+				m.ID = *u
+			}
+		case "type":
+			hasType = true
+			m.Type, err = dec.Text()
+		case "controller":
+			hasController = true
+			m.Controller, err = decodeDIDCBOR(dec)
+		default:
+			m.Additional, err = decodeAdditionalCBOR(dec, m.Additional, property)
+		}
+		if err != nil {
+			return fmt.Errorf("DID verification-method CBOR %q: %w", property, err)
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return err
+	case !hasID:
+		return errors.New(`DID verification-method CBOR has no "id"`)
+	case !hasType:
+		return errors.New(`DID verification-method CBOR has no "type"`)
+	case !hasController:
+		return errors.New(`DID verification-method CBOR has no "controller"`)
+	}
+	return nil
+}
+
+// AppendCBOR appends the deterministic encoding of srv to buf.
+func (srv *Service) appendCBOR(buf []byte) ([]byte, error) {
+	var entries cbor.Map
+	entries.Set("id", cbor.AppendText(nil, srv.ID.String()))
+
+	switch len(srv.Types) {
+	case 0:
+		return nil, errors.New("no DID service type")
+	case 1:
+		entries.Set("type", cbor.AppendText(nil, srv.Types[0]))
+	default:
+		item := cbor.AppendArrayHead(nil, len(srv.Types))
+		for _, s := range srv.Types {
+			item = cbor.AppendText(item, s)
+		}
+		entries.Set("type", item)
+	}
+
+	item, err := srv.Endpoint.appendCBOR(nil)
+	if err != nil {
+		return nil, err
+	}
+	entries.Set("serviceEndpoint", item)
+
+	for _, property := range sorted.Keys(srv.Additional) {
+		switch property {
+		case "id", "type", "serviceEndpoint":
+			return nil, fmt.Errorf(`core DID service property %q in additional set`, property)
+		}
+		item, err := cbor.AppendJSON(nil, srv.Additional[property])
+		if err != nil {
+			return nil, fmt.Errorf("DID service property %q: %w", property, err)
+		}
+		entries.Set(property, item)
+	}
+
+	return entries.Append(buf), nil
+}
+
+// DecodeCBOR reads the next data item into srv.
+func (srv *Service) decodeCBOR(dec *cbor.Decoder) error {
+	// reset
+	*srv = Service{}
+
+	var hasID, hasEndpoint bool
+	err := dec.Map(func(property string) error {
+		var err error
+		switch property {
+		case "id":
+			hasID = true
+			var s string
+			s, err = dec.Text()
+			if err != nil {
+				break
+			}
+			var p *url.URL
+			p, err = url.Parse(s)
+			if err != nil {
+				var wrap *url.Error // not useful
+				if errors.As(err, &wrap) {
+					err = wrap.Err // trim
+				}
+				return fmt.Errorf(`malformed DID service "id" URI: %w`, err)
+			}
+			srv.ID = *p
+		case "type":
+			if dec.IsText() {
+				var s string
+				s, err = dec.Text()
+				srv.Types = []string{s}
+				break
+			}
+			srv.Types, err = decodeStringsCBOR(dec)
+			if err == nil && len(srv.Types) == 0 {
+				return errors.New(`DID service CBOR "type" array empty`)
+			}
+		case "serviceEndpoint":
+			hasEndpoint = true
+			err = srv.Endpoint.decodeCBOR(dec)
+		default:
+			srv.Additional, err = decodeAdditionalCBOR(dec, srv.Additional, property)
+		}
+		if err != nil {
+			return fmt.Errorf("DID service CBOR %q: %w", property, err)
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return err
+	case !hasID:
+		return errors.New(`DID service CBOR has no "id"`)
+	case srv.Types == nil:
+		return errors.New(`DID service CBOR has no "type"`)
+	case !hasEndpoint:
+		return errors.New(`DID service CBOR has no "serviceEndpoint"`)
+	}
+	return nil
+}
+
+// AppendCBOR appends the deterministic encoding of e to buf.
+func (e *ServiceEndpoint) appendCBOR(buf []byte) ([]byte, error) {
+	switch {
+	case len(e.URIRefs) == 0 && len(e.Maps) == 0:
+		return nil, errors.New("DID service endpoint empty")
+	case len(e.URIRefs) == 1 && len(e.Maps) == 0:
+		return cbor.AppendText(buf, e.URIRefs[0].String()), nil
+	case len(e.URIRefs) == 0 && len(e.Maps) == 1:
+		return cbor.AppendJSON(buf, e.Maps[0])
+	}
+	// need array for two or more entries
+
+	buf = cbor.AppendArrayHead(buf, len(e.URIRefs)+len(e.Maps))
+	for _, u := range e.URIRefs {
+		buf = cbor.AppendText(buf, u.String())
+	}
+	for _, raw := range e.Maps {
+		var err error
+		buf, err = cbor.AppendJSON(buf, raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// DecodeCBOR reads the next data item into e.
+func (e *ServiceEndpoint) decodeCBOR(dec *cbor.Decoder) error {
+	// reset
+	e.URIRefs = e.URIRefs[:0]
+	e.Maps = e.Maps[:0]
+
+	n := 1 // single string or map
+	if dec.IsArray() {
+		var err error
+		n, err = dec.ArrayLen()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("DID serviceEndpoint CBOR array empty")
+		}
+	}
+
+	for ; n > 0; n-- {
+		switch {
+		case dec.IsText():
+			s, err := dec.Text()
+			if err != nil {
+				return err
+			}
+			u, err := url.Parse(s)
+			if err != nil {
+				var wrap *url.Error // not useful
+				if errors.As(err, &wrap) {
+					err = wrap.Err // trim
+				}
+				return fmt.Errorf("malformed DID service enpoint URI: %w", err)
+			}
+			e.URIRefs = append(e.URIRefs, u)
+
+		case dec.IsMap():
+			raw, err := dec.JSON()
+			if err != nil {
+				return err
+			}
+			e.Maps = append(e.Maps, raw)
+
+		default:
+			return errors.New("CBOR of DID serviceEndpoint is not a text string nor a map nor an array")
+		}
+	}
+	return nil
+}
+
+// DecodeDIDCBOR reads a text string with a DID.
+func decodeDIDCBOR(dec *cbor.Decoder) (DID, error) {
+	s, err := dec.Text()
+	if err != nil {
+		return DID{}, err
+	}
+	return Parse(s)
+}
+
+// DecodeURLCBOR reads a text string with a DID URL.
+func decodeURLCBOR(dec *cbor.Decoder) (*URL, error) {
+	s, err := dec.Text()
+	if err != nil {
+		return nil, err
+	}
+	return ParseURL(s)
+}
+
+// DecodeStringsCBOR reads an array of text strings, or null.
+func decodeStringsCBOR(dec *cbor.Decoder) ([]string, error) {
+	if dec.IsNull() {
+		return nil, dec.Null()
+	}
+	n, err := dec.ArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	strings := make([]string, n)
+	for i := range strings {
+		strings[i], err = dec.Text()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return strings, nil
+}
+
+// DecodeSetCBOR reads a text string, an array of text strings, or null.
+func decodeSetCBOR(dec *cbor.Decoder) (Set, error) {
+	switch {
+	case dec.IsNull():
+		return nil, dec.Null()
+	case dec.IsText():
+		d, err := decodeDIDCBOR(dec)
+		if err != nil {
+			return nil, err
+		}
+		return Set{d}, nil
+	}
+
+	n, err := dec.ArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	set := make(Set, n)
+	for i := range set {
+		set[i], err = decodeDIDCBOR(dec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// DecodeMethodsCBOR reads an array of verification methods, or null.
+func decodeMethodsCBOR(dec *cbor.Decoder) ([]*VerificationMethod, error) {
+	if dec.IsNull() {
+		return nil, dec.Null()
+	}
+	n, err := dec.ArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	methods := make([]*VerificationMethod, n)
+	for i := range methods {
+		if dec.IsNull() {
+			if err := dec.Null(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		methods[i] = new(VerificationMethod)
+		if err := methods[i].decodeCBOR(dec); err != nil {
+			return nil, err
+		}
+	}
+	return methods, nil
+}
+
+// DecodeRelationshipCBOR reads an array of verification methods and URL text
+// strings mixed, or null.
+func decodeRelationshipCBOR(dec *cbor.Decoder) (*VerificationRelationship, error) {
+	if dec.IsNull() {
+		return nil, dec.Null()
+	}
+	n, err := dec.ArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	r := new(VerificationRelationship)
+	for ; n > 0; n-- {
+		switch {
+		case dec.IsMap(): // embedded
+			m := new(VerificationMethod)
+			if err := m.decodeCBOR(dec); err != nil {
+				return nil, err
+			}
+			r.Methods = append(r.Methods, m)
+
+		case dec.IsText(): // reference
+			u, err := decodeURLCBOR(dec)
+			if err != nil {
+				return nil, err
+			}
+			r.URIRefs = append(r.URIRefs, u)
+
+		default:
+			return nil, errors.New("CBOR of DID set of verification methods entry is not a map nor a text string")
+		}
+	}
+	return r, nil
+}
+
+// DecodeServicesCBOR reads an array of services, or null.
+func decodeServicesCBOR(dec *cbor.Decoder) ([]*Service, error) {
+	if dec.IsNull() {
+		return nil, dec.Null()
+	}
+	n, err := dec.ArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*Service, n)
+	for i := range services {
+		if dec.IsNull() {
+			if err := dec.Null(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		services[i] = new(Service)
+		if err := services[i].decodeCBOR(dec); err != nil {
+			return nil, err
+		}
+	}
+	return services, nil
+}
+
+// DecodeAdditionalCBOR reads the next data item as JSON into the additional
+// properties, with lazy initiation.
+func decodeAdditionalCBOR(dec *cbor.Decoder, additional map[string]json.RawMessage, property string) (map[string]json.RawMessage, error) {
+	raw, err := dec.JSON()
+	if err != nil {
+		return additional, err
+	}
+	if additional == nil {
+		additional = make(map[string]json.RawMessage)
+	}
+	additional[property] = raw
+	return additional, nil
+}
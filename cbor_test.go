@@ -0,0 +1,192 @@
+package did_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/cbor"
+)
+
+func ExampleDocument_MarshalCBOR() {
+	doc := did.Document{Subject: did.DID{Method: "example", SpecID: "123"}}
+	data, err := doc.MarshalCBOR()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%x\n", data)
+	// Output:
+	// a16269646f6469643a6578616d706c653a313233
+}
+
+func TestDocumentCBOR(t *testing.T) {
+	for _, example := range []string{example9, example10, example11} {
+		var doc did.Document
+		if err := json.Unmarshal([]byte(example), &doc); err != nil {
+			t.Fatal(err)
+		}
+		data, err := doc.MarshalCBOR()
+		if err != nil {
+			t.Fatal("marshal error:", err)
+		}
+		// JSON data model in deterministic encoding
+		viaJSON, err := json.Marshal(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		viaJSON, err = cbor.FromJSON(viaJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, viaJSON) {
+			t.Errorf("got CBOR %x, want %x conform JSON", data, viaJSON)
+		}
+
+		var got did.Document
+		if err := got.UnmarshalCBOR(data); err != nil {
+			t.Fatalf("unmarshal error: %s; CBOR %x", err, data)
+		}
+		wantJSON, err := json.Marshal(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, err := json.Marshal(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("got JSON %s after CBOR round trip, want %s", gotJSON, wantJSON)
+		}
+
+		again, err := got.MarshalCBOR()
+		if err != nil {
+			t.Fatal("marshal again error:", err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("got CBOR %x after round trip, want %x", again, data)
+		}
+	}
+}
+
+func TestVerificationMethodCBOR(t *testing.T) {
+	var m did.VerificationMethod
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123#key-1",
+		"type": "JsonWebKey2020",
+		"controller": "did:example:123",
+		"publicKeyJwk": {"kty": "OKP", "crv": "Ed25519", "x": "VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ"},
+		"weight": 1.5,
+		"revoked": false
+	}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := m.MarshalCBOR()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+
+	var got did.VerificationMethod
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if got.ID != m.ID || got.Type != m.Type || got.Controller != m.Controller {
+		t.Errorf("got core %+v, want %+v", got, m)
+	}
+	want := map[string]string{
+		// map keys in deterministic order
+		"publicKeyJwk": `{"x":"VCpo2LMLhn6iWku8MKvSLg2ZAoC-nlOyPVQaO3FxVeQ","crv":"Ed25519","kty":"OKP"}`,
+		"weight":       `1.5`,
+		"revoked":      `false`,
+	}
+	if len(got.Additional) != len(want) {
+		t.Errorf("got additional %q, want %q", got.Additional, want)
+	}
+	for property, value := range want {
+		if s := string(got.Additional[property]); s != value {
+			t.Errorf("got additional %q %s, want %s", property, s, value)
+		}
+	}
+}
+
+func TestServiceCBOR(t *testing.T) {
+	var srv did.Service
+	err := json.Unmarshal([]byte(`{
+		"id": "#hub",
+		"type": ["Hub", "Relay"],
+		"serviceEndpoint": ["https://example.com/", {"uri": "https://example.com/", "accept": ["didcomm/v2"]}],
+		"priority": 2
+	}`), &srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := srv.MarshalCBOR()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+
+	var got did.Service
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	gotJSON, err := json.Marshal(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"id":"#hub","type":["Hub","Relay"],"serviceEndpoint":["https://example.com/",{"uri":"https://example.com/","accept":["didcomm/v2"]}],"priority":2}`
+	if string(gotJSON) != want {
+		t.Errorf("got JSON %s\nwant %s", gotJSON, want)
+	}
+
+	var e did.ServiceEndpoint
+	if err := e.UnmarshalCBOR([]byte{0x60 | 19, 'h', 't', 't', 'p', 's', ':', '/', '/', 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm'}); err != nil {
+		t.Fatal("endpoint unmarshal error:", err)
+	}
+	if len(e.URIRefs) != 1 || e.URIRefs[0].Host != "example.com" {
+		t.Errorf("got endpoint URIs %v, want https://example.com", e.URIRefs)
+	}
+}
+
+func TestUnmarshalCBORNonDeterministic(t *testing.T) {
+	// {"type": "Hub", "id": "#hub"} has the keys out of order
+	data := []byte{0xa2,
+		0x64, 't', 'y', 'p', 'e', 0x63, 'H', 'u', 'b',
+		0x62, 'i', 'd', 0x64, '#', 'h', 'u', 'b',
+	}
+	var srv did.Service
+	err := srv.UnmarshalCBOR(data)
+	if err == nil || !strings.Contains(err.Error(), "not sorted") {
+		t.Errorf("got error %v, want unsorted keys", err)
+	}
+}
+
+func TestUnmarshalCBORErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		into interface{ UnmarshalCBOR([]byte) error }
+		want string
+	}{
+		{`{"id":"did:example:123#key-1","type":"JsonWebKey2020"}`, new(did.VerificationMethod), `has no "controller"`},
+		{`{"id":"did:example:123#key-1","type":"JsonWebKey2020","controller":"123"}`, new(did.VerificationMethod), `"controller"`},
+		{`{"id":"#hub","type":[],"serviceEndpoint":"https://example.com/"}`, new(did.Service), `"type" array empty`},
+		{`{"id":"#hub","type":"Hub"}`, new(did.Service), `has no "serviceEndpoint"`},
+		{`[]`, new(did.ServiceEndpoint), "array empty"},
+		{`1`, new(did.ServiceEndpoint), "not a text string nor a map nor an array"},
+		{`{"id":"did:example:123","authentication":[1]}`, new(did.Document), `"authentication"`},
+		{`{"id":"did:example:123","controller":{}}`, new(did.Document), `"controller"`},
+	}
+	for _, test := range tests {
+		data, err := cbor.FromJSON([]byte(test.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = test.into.UnmarshalCBOR(data)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s got error %v, want %q", test.json, err, test.want)
+		}
+	}
+}
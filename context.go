@@ -0,0 +1,44 @@
+package did
+
+import "context"
+
+// Resolver is a resolution contract with cancellation and deadline support.
+// Resolve implements the interface, as does Registry.
+type Resolver interface {
+	// ResolveContext resolves d conform the options, which may be nil.
+	// Errors are encoded as a standardised code in the resolution metadata
+	// [ResolutionResult.Err], including the cancellation and expiry of ctx.
+	ResolveContext(ctx context.Context, d DID, opts *ResolutionOptions) *ResolutionResult
+}
+
+// ResolveContext adapts r to the Resolver interface. Resolve lacks support for
+// cancellation. The return is immediate once ctx is done, while r proceeds in
+// the background.
+func (r Resolve) ResolveContext(ctx context.Context, d DID, opts *ResolutionOptions) *ResolutionResult {
+	if err := ctx.Err(); err != nil {
+		return newErrorResult(err)
+	}
+	done := ctx.Done()
+	if done == nil {
+		return r.Resolution(d, opts) // never done
+	}
+
+	ch := make(chan *ResolutionResult, 1)
+	go func() {
+		ch <- r.Resolution(d, opts)
+	}()
+	select {
+	case res := <-ch:
+		return res
+	case <-done:
+		return newErrorResult(ctx.Err())
+	}
+}
+
+// NewErrorResult returns a failed resolution.
+func newErrorResult(err error) *ResolutionResult {
+	return &ResolutionResult{
+		Meta: ResolutionMeta{Error: ErrorCode(err)},
+		err:  err,
+	}
+}
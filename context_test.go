@@ -0,0 +1,53 @@
+package did_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+)
+
+func TestResolveContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := did.Resolve(func(d did.DID) (*did.Document, *did.Meta, error) {
+		<-release
+		return resolveExample21(d)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res := slow.ResolveContext(ctx, did.DID{Method: "example", SpecID: "123"}, nil)
+	if err := res.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if res.Meta.Error != "internalError" {
+		t.Errorf("got error code %q, want internalError", res.Meta.Error)
+	}
+}
+
+func TestRegistryResolveContext(t *testing.T) {
+	var reg did.Registry
+	reg.Register("example", resolveExample21)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res := reg.ResolveContext(ctx, did.DID{Method: "example", SpecID: "123"}, nil)
+	if err := res.Err(); err != nil {
+		t.Fatal("resolve error:", err)
+	}
+	if res.Document == nil {
+		t.Error("no document resolved")
+	}
+
+	cancel()
+	res = reg.ResolveContext(ctx, did.DID{Method: "example", SpecID: "123"}, nil)
+	if err := res.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v after cancel, want context.Canceled", err)
+	}
+	_, err := did.DereferenceContext(ctx, &reg, &did.URL{DID: did.DID{Method: "example", SpecID: "123"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got dereference error %v after cancel, want context.Canceled", err)
+	}
+}
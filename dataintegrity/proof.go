@@ -0,0 +1,440 @@
+// Package dataintegrity implements “Verifiable Credential Data Integrity” proofs
+// with the JSON Canonicalization Scheme cryptosuites, i.e., eddsa-jcs-2022 and
+// ecdsa-jcs-2019. Any JSON object can be secured, including DID documents and
+// verifiable credentials, without the need for JSON-LD processing. See
+// https://www.w3.org/TR/vc-data-integrity/ for the specification.
+//
+// The RDF cryptosuite eddsa-rdfc-2022, and the legacy Ed25519Signature2020 proof
+// type, are supported for interoperability with existing issuers. Those do need
+// JSON-LD processing, which is available once the rdfc package is imported, as
+// in import _ "github.com/pascaldekloe/did/rdfc". See RegisterSuite.
+package dataintegrity
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	_ "crypto/sha256" // link crypto.SHA256
+	_ "crypto/sha512" // link crypto.SHA384
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/multikey"
+	"github.com/pascaldekloe/did/jcs"
+)
+
+// ProofType is the "type" of each proof.
+const ProofType = "DataIntegrityProof"
+
+// Cryptosuite identifiers supported.
+const (
+	EdDSAJCS2022  = "eddsa-jcs-2022"
+	ECDSAJCS2019  = "ecdsa-jcs-2019"
+	EdDSARDFC2022 = "eddsa-rdfc-2022"
+)
+
+// Ed25519Signature2020 is the "type" of legacy proofs, which have no
+// cryptosuite. Its signatures are equivalent to the ones of EdDSARDFC2022.
+const Ed25519Signature2020 = "Ed25519Signature2020"
+
+// Canonicalization transforms a JSON document into the canonical form of a
+// cryptosuite. JSON-LD contexts, if any, are read from the documents per URL
+// exclusively, with nil for the defaults of the implementation.
+type Canonicalization func(doc []byte, contexts map[string][]byte) ([]byte, error)
+
+// Suites has the canonicalization per cryptosuite, with Ed25519Signature2020
+// for the legacy proof type.
+var suites = map[string]Canonicalization{
+	EdDSAJCS2022: jcsTransform,
+	ECDSAJCS2019: jcsTransform,
+}
+
+func jcsTransform(doc []byte, _ map[string][]byte) ([]byte, error) {
+	return jcs.Transform(doc)
+}
+
+// RegisterSuite installs the canonicalization of a cryptosuite, or of the
+// legacy Ed25519Signature2020 proof type. The rdfc package registers both
+// EdDSARDFC2022 and Ed25519Signature2020 on import, which keeps JSON-LD out of
+// any builds that do without. RegisterSuite is meant for package initialization,
+// as it is not safe for concurrent use.
+func RegisterSuite(suite string, c Canonicalization) {
+	suites[suite] = c
+}
+
+// ErrCryptosuite signals a cryptosuite not supported, or one which does not
+// match the key type.
+var ErrCryptosuite = errors.New("data-integrity cryptosuite not supported")
+
+// Proof is a data-integrity proof, as embedded in the "proof" property of a
+// secured document.
+type Proof struct {
+	ID                 string  // optional URL
+	Type               string  // required
+	Cryptosuite        string  // required
+	VerificationMethod did.URL // required
+	ProofPurpose       string  // required
+	ProofValue         string  // required, in multibase
+
+	// Zero values are omitted.
+	Created time.Time
+	Expires time.Time
+
+	Domain    string // optional
+	Challenge string // optional
+	Nonce     string // optional
+
+	// A proof MAY include additional properties, such as "@context" or
+	// "previousProof".
+	Additional map[string]json.RawMessage
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	m := make(map[string]json.RawMessage, len(p.Additional)+12)
+	for property, value := range p.Additional {
+		switch property {
+		case "id", "type", "cryptosuite", "verificationMethod", "proofPurpose", "proofValue", "created", "expires", "domain", "challenge", "nonce":
+			return nil, fmt.Errorf("core data-integrity proof property %q in additional set", property)
+		}
+		m[property] = value
+	}
+
+	for _, s := range [...]struct{ name, value string }{
+		{"id", p.ID},
+		{"type", p.Type},
+		{"cryptosuite", p.Cryptosuite},
+		{"proofPurpose", p.ProofPurpose},
+		{"proofValue", p.ProofValue},
+		{"domain", p.Domain},
+		{"challenge", p.Challenge},
+		{"nonce", p.Nonce},
+	} {
+		if s.value != "" {
+			m[s.name] = json.RawMessage(strconv.Quote(s.value))
+		}
+	}
+	if p.VerificationMethod != (did.URL{}) {
+		m["verificationMethod"] = json.RawMessage(strconv.Quote(p.VerificationMethod.String()))
+	}
+	if !p.Created.IsZero() {
+		m["created"] = json.RawMessage(strconv.Quote(formatDateTime(p.Created)))
+	}
+	if !p.Expires.IsZero() {
+		m["expires"] = json.RawMessage(strconv.Quote(formatDateTime(p.Expires)))
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Proof) UnmarshalJSON(bytes []byte) error {
+	// Read all properties as Additional first.
+	*p = Proof{}
+	err := json.Unmarshal(bytes, &p.Additional)
+	if err != nil {
+		return fmt.Errorf("data-integrity proof: %w", err)
+	}
+
+	// Second, extract the core from Additional.
+	for _, s := range [...]struct {
+		name    string
+		pointer *string
+	}{
+		{"id", &p.ID},
+		{"type", &p.Type},
+		{"cryptosuite", &p.Cryptosuite},
+		{"proofPurpose", &p.ProofPurpose},
+		{"proofValue", &p.ProofValue},
+		{"domain", &p.Domain},
+		{"challenge", &p.Challenge},
+		{"nonce", &p.Nonce},
+	} {
+		if err := p.popPropertyInto(s.name, s.pointer); err != nil {
+			return err
+		}
+	}
+	if _, ok := p.Additional["verificationMethod"]; ok {
+		if err := p.popPropertyInto("verificationMethod", &p.VerificationMethod); err != nil {
+			return err
+		}
+	}
+	for _, t := range [...]struct {
+		name    string
+		pointer *time.Time
+	}{
+		{"created", &p.Created},
+		{"expires", &p.Expires},
+	} {
+		var s string
+		if err := p.popPropertyInto(t.name, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			continue
+		}
+		*t.pointer, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("data-integrity proof JSON %q: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// PopPropertyInto unmarshals a core property, if present.
+func (p *Proof) popPropertyInto(name string, pointer any) error {
+	raw, ok := p.Additional[name]
+	if !ok {
+		return nil
+	}
+	delete(p.Additional, name)
+
+	err := json.Unmarshal([]byte(raw), pointer)
+	if err != nil {
+		return fmt.Errorf("data-integrity proof JSON %q: %w", name, err)
+	}
+	return nil
+}
+
+// FormatDateTime returns t in the XML Schema dateTimeStamp notation.
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Sign returns the JSON object doc with a proof added. The options must have
+// the VerificationMethod and the ProofPurpose set. The Type defaults to
+// ProofType, and the Cryptosuite defaults to the one of the key type, i.e.,
+// EdDSAJCS2022 for Ed25519, and ECDSAJCS2019 for P-256 and P-384. Any proofs
+// already present in doc are kept as a proof set. The proof gets the "@context"
+// of doc, if any. Object members of the return are in lexical order. RDF
+// canonicalization, as with EdDSARDFC2022 or Ed25519Signature2020, applies the
+// defaults of the rdfc package. See SignWithContexts for others.
+func Sign(doc []byte, options *Proof, key crypto.Signer) ([]byte, error) {
+	return SignWithContexts(doc, options, key, nil)
+}
+
+// SignWithContexts is like Sign, yet RDF canonicalization reads the JSON-LD
+// documents per context URL exclusively, as with an rdfc.Loader. Nil defaults
+// to rdfc.Bundled.
+func SignWithContexts(doc []byte, options *Proof, key crypto.Signer, contexts map[string][]byte) ([]byte, error) {
+	if options.VerificationMethod.IsRelative() {
+		return nil, errors.New("data-integrity proof has no DID in verification method")
+	}
+	if options.ProofPurpose == "" {
+		return nil, errors.New("data-integrity proof has no purpose")
+	}
+	proof := *options // copy
+	proof.ProofValue = ""
+	if proof.Type == "" {
+		proof.Type = ProofType
+	}
+	if proof.Cryptosuite == "" && proof.Type == ProofType {
+		switch pub := key.Public().(type) {
+		case ed25519.PublicKey:
+			proof.Cryptosuite = EdDSAJCS2022
+		case *ecdsa.PublicKey:
+			proof.Cryptosuite = ECDSAJCS2019
+		default:
+			return nil, fmt.Errorf("%w: key type %T", ErrCryptosuite, pub)
+		}
+	}
+
+	members, err := decodeObject(doc)
+	if err != nil {
+		return nil, err
+	}
+	proofs, err := popProofs(members)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := proofSuite(&proof)
+	if err != nil {
+		return nil, err
+	}
+	// “If unsecuredDocument.@context is present, set proof.@context to
+	// unsecuredDocument.@context.” Legacy proofs go without.
+	if ctx, ok := members["@context"]; ok && suite != Ed25519Signature2020 {
+		additional := make(map[string]json.RawMessage, len(proof.Additional)+1)
+		for k, v := range proof.Additional {
+			additional[k] = v
+		}
+		additional["@context"] = ctx
+		proof.Additional = additional
+	}
+
+	hash, err := suiteHash(suite, key.Public())
+	if err != nil {
+		return nil, err
+	}
+	configJSON, err := json.Marshal(&proof)
+	if err != nil {
+		return nil, err
+	}
+	config, err := decodeObject(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	hashData, err := hashData(hash, suite, members, config, contexts)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(hash, key, hashData)
+	if err != nil {
+		return nil, err
+	}
+	proof.ProofValue = string(multikey.Base58BTC) + multikey.EncodeBase58(sig)
+
+	proofJSON, err := json.Marshal(&proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(proofs) == 0 {
+		members["proof"] = proofJSON
+	} else {
+		members["proof"], err = json.Marshal(append(proofs, proofJSON))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(members)
+}
+
+func sign(hash crypto.Hash, key crypto.Signer, hashData []byte) ([]byte, error) {
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return key.Sign(rand.Reader, hashData, crypto.Hash(0))
+	}
+
+	digest := hash.New()
+	digest.Write(hashData)
+	der, err := key.Sign(rand.Reader, digest.Sum(nil), hash)
+	if err != nil {
+		return nil, fmt.Errorf("data-integrity signature: %w", err)
+	}
+	// ASN.1 DER to R‖S
+	var rs struct{ R, S *big.Int }
+	rest, err := asn1.Unmarshal(der, &rs)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("data-integrity ECDSA signature not in ASN.1 DER")
+	}
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	rs.R.FillBytes(sig[:size])
+	rs.S.FillBytes(sig[size:])
+	return sig, nil
+}
+
+// HashData returns the hash of the canonical proof configuration, followed by
+// the hash of the canonical document. The proof configuration is modified to
+// have the "@context" of the document, if any, and no "proofValue". Contexts
+// apply to RDF canonicalization only.
+func hashData(hash crypto.Hash, suite string, members, config map[string]json.RawMessage, contexts map[string][]byte) ([]byte, error) {
+	delete(config, "proofValue")
+	if ctx, ok := members["@context"]; ok {
+		config["@context"] = ctx
+	}
+
+	c, ok := suites[suite]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no canonicalization registered; import the rdfc package for RDF", ErrCryptosuite, suite)
+	}
+	canonicalize := func(v map[string]json.RawMessage) ([]byte, error) {
+		doc, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return c(doc, contexts)
+	}
+	canonicalConfig, err := canonicalize(config)
+	if err != nil {
+		return nil, fmt.Errorf("data-integrity proof configuration: %w", err)
+	}
+	canonicalDoc, err := canonicalize(members)
+	if err != nil {
+		return nil, fmt.Errorf("data-integrity document: %w", err)
+	}
+
+	h := hash.New()
+	h.Write(canonicalConfig)
+	sum := h.Sum(nil)
+	h.Reset()
+	h.Write(canonicalDoc)
+	return h.Sum(sum), nil
+}
+
+// ProofSuite returns the cryptosuite of a proof, with Ed25519Signature2020 for
+// the legacy proof type.
+func proofSuite(p *Proof) (string, error) {
+	switch p.Type {
+	case ProofType:
+		return p.Cryptosuite, nil
+	case Ed25519Signature2020:
+		if p.Cryptosuite != "" {
+			return "", fmt.Errorf("%w: %q with proof type %s", ErrCryptosuite, p.Cryptosuite, p.Type)
+		}
+		return Ed25519Signature2020, nil
+	}
+	return "", fmt.Errorf("data-integrity proof type %q not supported", p.Type)
+}
+
+// SuiteHash returns the hash function of a cryptosuite for key.
+func suiteHash(suite string, key crypto.PublicKey) (crypto.Hash, error) {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		switch suite {
+		case EdDSAJCS2022, EdDSARDFC2022, Ed25519Signature2020:
+			return crypto.SHA256, nil
+		}
+	case *ecdsa.PublicKey:
+		if suite == ECDSAJCS2019 {
+			switch key.Curve.Params().BitSize {
+			case 256:
+				return crypto.SHA256, nil
+			case 384:
+				return crypto.SHA384, nil
+			}
+			return 0, fmt.Errorf("%w: %s with curve %s", ErrCryptosuite, suite, key.Curve.Params().Name)
+		}
+	}
+	return 0, fmt.Errorf("%w: %q with %T", ErrCryptosuite, suite, key)
+}
+
+// DecodeObject parses a JSON object.
+func decodeObject(doc []byte) (map[string]json.RawMessage, error) {
+	var members map[string]json.RawMessage
+	err := json.Unmarshal(doc, &members)
+	if err != nil {
+		return nil, fmt.Errorf("data-integrity document: %w", err)
+	}
+	if members == nil {
+		return nil, errors.New("data-integrity document is not a JSON object")
+	}
+	return members, nil
+}
+
+// PopProofs removes the "proof" property, and it returns each entry.
+func popProofs(members map[string]json.RawMessage) ([]json.RawMessage, error) {
+	raw, ok := members["proof"]
+	if !ok {
+		return nil, nil
+	}
+	delete(members, "proof")
+
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	if len(raw) != 0 && raw[0] == '[' {
+		var proofs []json.RawMessage
+		err := json.Unmarshal(raw, &proofs)
+		if err != nil {
+			return nil, fmt.Errorf("data-integrity proof set: %w", err)
+		}
+		return proofs, nil
+	}
+	return []json.RawMessage{raw}, nil
+}
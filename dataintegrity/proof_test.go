@@ -0,0 +1,442 @@
+package dataintegrity_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/dataintegrity"
+	"github.com/pascaldekloe/did/didkey"
+	"github.com/pascaldekloe/did/rdfc"
+)
+
+const credential = `{
+	"@context": [
+		"https://www.w3.org/ns/credentials/v2",
+		"https://www.w3.org/ns/credentials/examples/v2"
+	],
+	"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+	"type": ["VerifiableCredential", "AlumniCredential"],
+	"name": "Alumni Credential",
+	"issuer": "https://vc.example/issuers/5678",
+	"validFrom": "2023-01-01T00:00:00Z",
+	"credentialSubject": {
+		"id": "did:example:abcdefgh",
+		"alumniOf": "The School of Examples"
+	}
+}`
+
+// KeyMethod returns the did:key verification method of key.
+func keyMethod(t testing.TB, key crypto.PublicKey) did.URL {
+	d, err := didkey.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return did.URL{DID: d, RawFragment: "#" + d.SpecID}
+}
+
+func Example() {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	secured, err := dataintegrity.Sign([]byte(credential), &dataintegrity.Proof{
+		VerificationMethod: keyMethod(nil, public),
+		ProofPurpose:       did.AssertionMethod,
+		Created:            time.Date(2023, 2, 24, 23, 36, 38, 0, time.UTC),
+	}, private)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	v := dataintegrity.Verifier{Resolve: didkey.Resolve, Purpose: did.AssertionMethod}
+	proof, err := v.Verify(secured)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("cryptosuite:", proof.Cryptosuite)
+	fmt.Println("created:", proof.Created)
+	// Output:
+	// cryptosuite: eddsa-jcs-2022
+	// created: 2023-02-24 23:36:38 +0000 UTC
+}
+
+func TestSignVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []crypto.Signer{edKey}
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, key)
+	}
+
+	for _, signer := range signers {
+		secured, err := dataintegrity.Sign([]byte(credential), &dataintegrity.Proof{
+			VerificationMethod: keyMethod(t, signer.Public()),
+			ProofPurpose:       did.AssertionMethod,
+			Domain:             "example.com",
+			Challenge:          "abc",
+		}, signer)
+		if err != nil {
+			t.Errorf("%T: %s", signer, err)
+			continue
+		}
+
+		v := dataintegrity.Verifier{
+			Resolve:   didkey.Resolve,
+			Purpose:   did.AssertionMethod,
+			Domain:    "example.com",
+			Challenge: "abc",
+		}
+		if _, err := v.Verify(secured); err != nil {
+			t.Errorf("%T: %s", signer, err)
+		}
+
+		tampered := strings.Replace(string(secured), "The School of Examples", "The School of Exemptions", 1)
+		if _, err := v.Verify([]byte(tampered)); !errors.Is(err, did.ErrSignature) {
+			t.Errorf("%T: tampered document got error %v, want did.ErrSignature", signer, err)
+		}
+
+		v.Challenge = "xyz"
+		if _, err := v.Verify(secured); err == nil {
+			t.Errorf("%T: wrong challenge got no error", signer)
+		}
+
+		v.Challenge = "abc"
+		v.Purpose = did.Authentication
+		if _, err := v.Verify(secured); !errors.Is(err, did.ErrNotAuthorized) {
+			t.Errorf("%T: authentication purpose got error %v, want did.ErrNotAuthorized", signer, err)
+		}
+	}
+}
+
+func TestProofPurposeRelationship(t *testing.T) {
+	// X25519 keys are for key agreement only
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := didkey.New(edKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _, err := didkey.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sign with the Ed25519 key, yet claim the X25519 method
+	secured, err := dataintegrity.Sign([]byte(`{"claim":true}`), &dataintegrity.Proof{
+		VerificationMethod: *doc.KeyAgreement.URIRefs[0],
+		ProofPurpose:       did.KeyAgreement,
+	}, edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := dataintegrity.Verifier{Resolve: didkey.Resolve, Purpose: did.KeyAgreement}
+	if _, err := v.Verify(secured); !errors.Is(err, dataintegrity.ErrCryptosuite) {
+		t.Errorf("X25519 method got error %v, want ErrCryptosuite", err)
+	}
+
+	// sign for assertion, yet with an authentication purpose
+	secured, err = dataintegrity.Sign([]byte(`{"claim":true}`), &dataintegrity.Proof{
+		VerificationMethod: *doc.Authentication.URIRefs[0],
+		ProofPurpose:       did.Authentication,
+	}, edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Purpose = did.Authentication
+	if _, err := v.Verify(secured); err != nil {
+		t.Error("authentication proof:", err)
+	}
+}
+
+func TestProofSet(t *testing.T) {
+	_, key1, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secured, err := dataintegrity.Sign([]byte(credential), &dataintegrity.Proof{
+		VerificationMethod: keyMethod(t, key1.Public()),
+		ProofPurpose:       did.Authentication,
+	}, key1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secured, err = dataintegrity.Sign(secured, &dataintegrity.Proof{
+		VerificationMethod: keyMethod(t, key2.Public()),
+		ProofPurpose:       did.AssertionMethod,
+	}, key2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields struct {
+		Proofs []*dataintegrity.Proof `json:"proof"`
+	}
+	if err := json.Unmarshal(secured, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if len(fields.Proofs) != 2 {
+		t.Fatalf("got %d proofs, want 2", len(fields.Proofs))
+	}
+
+	for _, purpose := range []string{did.Authentication, did.AssertionMethod} {
+		v := dataintegrity.Verifier{Resolve: didkey.Resolve, Purpose: purpose}
+		proof, err := v.Verify(secured)
+		if err != nil {
+			t.Errorf("%s: %s", purpose, err)
+			continue
+		}
+		if proof.ProofPurpose != purpose {
+			t.Errorf("got proof purpose %q, want %q", proof.ProofPurpose, purpose)
+		}
+	}
+}
+
+func TestExpires(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	secured, err := dataintegrity.Sign([]byte(credential), &dataintegrity.Proof{
+		VerificationMethod: keyMethod(t, public),
+		ProofPurpose:       did.AssertionMethod,
+		Expires:            expires,
+	}, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := dataintegrity.Verifier{
+		Resolve: didkey.Resolve,
+		Purpose: did.AssertionMethod,
+		Now:     func() time.Time { return expires },
+	}
+	if _, err := v.Verify(secured); !errors.Is(err, dataintegrity.ErrExpired) {
+		t.Errorf("got error %v, want ErrExpired", err)
+	}
+	v.Now = func() time.Time { return expires.Add(-time.Second) }
+	if _, err := v.Verify(secured); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProofJSON(t *testing.T) {
+	const sample = `{"created":"2023-02-24T23:36:38Z","cryptosuite":"eddsa-jcs-2022","previousProof":"urn:uuid:1","proofPurpose":"assertionMethod","proofValue":"z2","type":"DataIntegrityProof","verificationMethod":"did:example:123#key-1"}`
+
+	var p dataintegrity.Proof
+	if err := json.Unmarshal([]byte(sample), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.VerificationMethod.Fragment() != "key-1" || p.Created.Year() != 2023 || len(p.Additional) != 1 {
+		t.Errorf("got %+v", p)
+	}
+	bytes, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != sample {
+		t.Errorf("got JSON %s\nwant %s", bytes, sample)
+	}
+}
+
+func TestRDFCSignVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		options dataintegrity.Proof
+	}{
+		{
+			name: dataintegrity.EdDSARDFC2022,
+			doc: `{
+				"@context": "https://www.w3.org/ns/credentials/v2",
+				"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+				"type": ["VerifiableCredential", "AlumniCredential"],
+				"issuer": "https://vc.example/issuers/5678",
+				"validFrom": "2023-01-01T00:00:00Z",
+				"credentialSubject": {
+					"id": "did:example:abcdefgh",
+					"alumniOf": "The School of Examples"
+				}
+			}`,
+			options: dataintegrity.Proof{Cryptosuite: dataintegrity.EdDSARDFC2022},
+		}, {
+			name: dataintegrity.Ed25519Signature2020,
+			doc: `{
+				"@context": [
+					"https://www.w3.org/2018/credentials/v1",
+					"https://w3id.org/security/suites/ed25519-2020/v1",
+					{"alumniOf": "https://schema.org/alumniOf"}
+				],
+				"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+				"type": ["VerifiableCredential"],
+				"issuer": "https://vc.example/issuers/5678",
+				"issuanceDate": "2023-01-01T00:00:00Z",
+				"credentialSubject": {
+					"id": "did:example:abcdefgh",
+					"alumniOf": "The School of Examples"
+				}
+			}`,
+			options: dataintegrity.Proof{Type: dataintegrity.Ed25519Signature2020},
+		},
+	}
+	for _, test := range tests {
+		test.options.VerificationMethod = keyMethod(t, public)
+		test.options.ProofPurpose = did.AssertionMethod
+		test.options.Created = time.Date(2023, 2, 24, 23, 36, 38, 0, time.UTC)
+		secured, err := dataintegrity.Sign([]byte(test.doc), &test.options, private)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		v := dataintegrity.Verifier{Resolve: didkey.Resolve, Purpose: did.AssertionMethod}
+		if _, err := v.Verify(secured); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+
+		// same RDF in another JSON-LD notation
+		equivalent := strings.Replace(string(secured), `"type":["VerifiableCredential"`, `"@type":["VerifiableCredential"`, 1)
+		if equivalent == string(secured) {
+			t.Fatalf("%s: no type in %s", test.name, secured)
+		}
+		if _, err := v.Verify([]byte(equivalent)); err != nil {
+			t.Errorf("%s: equivalent document: %s", test.name, err)
+		}
+
+		tampered := strings.Replace(string(secured), "The School of Examples", "The School of Exemptions", 1)
+		if _, err := v.Verify([]byte(tampered)); !errors.Is(err, did.ErrSignature) {
+			t.Errorf("%s: tampered document got error %v, want did.ErrSignature", test.name, err)
+		}
+
+		v.Contexts = rdfc.Loader{}
+		if _, err := v.Verify(secured); !errors.Is(err, rdfc.ErrContext) {
+			t.Errorf("%s: without contexts got error %v, want rdfc.ErrContext", test.name, err)
+		}
+	}
+}
+
+// Test vectors from “Data Integrity EdDSA Cryptosuites v1.0”, appendix B.
+func TestRDFCGolden(t *testing.T) {
+	// secretKeyMultibase z3u2en7t5LR2WtQH5PfFqMqwVHBeXouLzo6haApm8XHqvjxq
+	seed, err := hex.DecodeString("c96ef9ea10c5e414c471723aff9de72c35fa5b70fae97e8832ecac7d2e2b8ed6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	const keyMethod = "did:key:z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2#z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2"
+	method, err := did.ParseURL(keyMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const examplesV2 = "https://www.w3.org/ns/credentials/examples/v2"
+	contexts := rdfc.Bundled()
+	contexts[examplesV2] = []byte(`{"@context": {"@vocab": "https://www.w3.org/ns/credentials/examples#"}}`)
+
+	tests := []struct {
+		name       string
+		contexts   string
+		options    dataintegrity.Proof
+		proofJSON  string
+		proofValue string
+	}{
+		{
+			name:     dataintegrity.EdDSARDFC2022,
+			contexts: `"https://www.w3.org/ns/credentials/v2", "https://www.w3.org/ns/credentials/examples/v2"`,
+			options:  dataintegrity.Proof{Cryptosuite: dataintegrity.EdDSARDFC2022},
+			proofJSON: `{
+				"type": "DataIntegrityProof",
+				"cryptosuite": "eddsa-rdfc-2022",
+				"created": "2023-02-24T23:36:38Z",
+				"verificationMethod": "` + keyMethod + `",
+				"proofPurpose": "assertionMethod",
+				"proofValue": "z2YwC8z3ap7yx1nZYCg4L3j3ApHsF8kgPdSb5xoS1VR7vPG3F561B52hYnQF9iseabecm3ijx4K1FBTQsCZahKZme"
+			}`,
+			proofValue: "z2YwC8z3ap7yx1nZYCg4L3j3ApHsF8kgPdSb5xoS1VR7vPG3F561B52hYnQF9iseabecm3ijx4K1FBTQsCZahKZme",
+		}, {
+			name:     dataintegrity.Ed25519Signature2020,
+			contexts: `"https://www.w3.org/ns/credentials/v2", "https://www.w3.org/ns/credentials/examples/v2", "https://w3id.org/security/suites/ed25519-2020/v1"`,
+			options:  dataintegrity.Proof{Type: dataintegrity.Ed25519Signature2020},
+			proofJSON: `{
+				"type": "Ed25519Signature2020",
+				"created": "2023-02-24T23:36:38Z",
+				"verificationMethod": "` + keyMethod + `",
+				"proofPurpose": "assertionMethod",
+				"proofValue": "z57Mm1vboMtZiCyJ4aReZsv8co4Re64Y8GEjL1ZARzMbXZgkARFLqFs1P345NpPGG2hgCrS4nNdvJhpwnrNyG3kEF"
+			}`,
+			proofValue: "z57Mm1vboMtZiCyJ4aReZsv8co4Re64Y8GEjL1ZARzMbXZgkARFLqFs1P345NpPGG2hgCrS4nNdvJhpwnrNyG3kEF",
+		},
+	}
+	for _, test := range tests {
+		unsecured := `{
+			"@context": [` + test.contexts + `],
+			"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+			"type": ["VerifiableCredential", "AlumniCredential"],
+			"name": "Alumni Credential",
+			"description": "A minimum viable example of an Alumni Credential.",
+			"issuer": "https://vc.example/issuers/5678",
+			"validFrom": "2023-01-01T00:00:00Z",
+			"credentialSubject": {
+				"id": "did:example:abcdefgh",
+				"alumniOf": "The School of Examples"
+			}`
+		v := dataintegrity.Verifier{Resolve: didkey.Resolve, Purpose: did.AssertionMethod, Contexts: contexts}
+
+		// verify the specification's secured document as is
+		if _, err := v.Verify([]byte(unsecured + `, "proof": ` + test.proofJSON + "}")); err != nil {
+			t.Errorf("%s: golden document got error: %s", test.name, err)
+		}
+
+		test.options.VerificationMethod = *method
+		test.options.ProofPurpose = did.AssertionMethod
+		test.options.Created = time.Date(2023, 2, 24, 23, 36, 38, 0, time.UTC)
+		secured, err := dataintegrity.SignWithContexts([]byte(unsecured+"}"), &test.options, private, contexts)
+		if err != nil {
+			t.Errorf("%s: sign error: %s", test.name, err)
+			continue
+		}
+		var got struct{ Proof dataintegrity.Proof }
+		if err := json.Unmarshal(secured, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Proof.ProofValue != test.proofValue {
+			t.Errorf("%s: got proof value %q, want %q", test.name, got.Proof.ProofValue, test.proofValue)
+		}
+		if _, err := v.Verify(secured); err != nil {
+			t.Errorf("%s: signed document got error: %s", test.name, err)
+		}
+	}
+}
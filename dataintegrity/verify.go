@@ -0,0 +1,227 @@
+package dataintegrity
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/multikey"
+	"github.com/pascaldekloe/did/jcs"
+)
+
+// ErrExpired signals a proof past its expiry.
+var ErrExpired = errors.New("data-integrity proof expired")
+
+// Verifier checks proofs with verification methods from DID documents.
+type Verifier struct {
+	// Resolve retrieves the DID document of each verification method.
+	Resolve did.Resolve
+
+	// Purpose is the required "proofPurpose", which is also the
+	// verification relationship that the verification method must be in,
+	// e.g., did.AssertionMethod or did.Authentication.
+	Purpose string
+
+	// Domain and Challenge are required to match when set.
+	Domain    string
+	Challenge string
+
+	// Now is the time of validation. The zero value defaults to time.Now.
+	Now func() time.Time
+
+	// Contexts are the JSON-LD documents per URL for RDF canonicalization,
+	// as with EdDSARDFC2022 and Ed25519Signature2020, e.g., an rdfc.Loader.
+	// Remote contexts are never fetched. The zero value defaults to
+	// rdfc.Bundled.
+	Contexts map[string][]byte
+}
+
+// Verify checks the proof of a secured document. In case of a proof set, the
+// first proof with a matching purpose that verifies is returned. Errors include
+// did.ErrSignature for mismatches, did.ErrNotAuthorized for methods not in the
+// verification relationship of the purpose, ErrCryptosuite and ErrExpired, as
+// well as any of the errors from v.Resolve.
+func (v *Verifier) Verify(secured []byte) (*Proof, error) {
+	members, err := decodeObject(secured)
+	if err != nil {
+		return nil, err
+	}
+	proofs, err := popProofs(members)
+	if err != nil {
+		return nil, err
+	}
+	if len(proofs) == 0 {
+		return nil, errors.New("data-integrity document has no proof")
+	}
+
+	var errs []error
+	for _, raw := range proofs {
+		proof := new(Proof)
+		err := json.Unmarshal(raw, proof)
+		if err == nil {
+			err = v.verify(members, proof, raw)
+		}
+		if err == nil {
+			return proof, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (v *Verifier) verify(members map[string]json.RawMessage, proof *Proof, raw json.RawMessage) error {
+	suite, err := proofSuite(proof)
+	if err != nil {
+		return err
+	}
+	if proof.ProofPurpose != v.Purpose {
+		return fmt.Errorf("%w: proof purpose %q, want %q", did.ErrNotAuthorized, proof.ProofPurpose, v.Purpose)
+	}
+	if v.Domain != "" && proof.Domain != v.Domain {
+		return fmt.Errorf("data-integrity proof domain %q, want %q", proof.Domain, v.Domain)
+	}
+	if v.Challenge != "" && proof.Challenge != v.Challenge {
+		return fmt.Errorf("data-integrity proof challenge %q, want %q", proof.Challenge, v.Challenge)
+	}
+	if !proof.Expires.IsZero() {
+		now := time.Now()
+		if v.Now != nil {
+			now = v.Now()
+		}
+		if !now.Before(proof.Expires) {
+			return fmt.Errorf("%w: at %s", ErrExpired, formatDateTime(proof.Expires))
+		}
+	}
+
+	// “If proofOptions.@context exists: Check that the
+	// securedDocument.@context starts with all values contained in the
+	// proofOptions.@context in the same order.”
+	if ctx, ok := proof.Additional["@context"]; ok {
+		if err := checkContextPrefix(members["@context"], ctx); err != nil {
+			return err
+		}
+		unsecured := make(map[string]json.RawMessage, len(members))
+		for k, v := range members {
+			unsecured[k] = v
+		}
+		unsecured["@context"] = ctx
+		members = unsecured
+	}
+
+	if proof.VerificationMethod.IsRelative() {
+		return errors.New("data-integrity proof has no DID in verification method")
+	}
+	doc, _, err := v.Resolve(proof.VerificationMethod.DID)
+	if err != nil {
+		return fmt.Errorf("data-integrity verification method %s: %w", proof.VerificationMethod.String(), err)
+	}
+	m, err := doc.MethodFor(proof.ProofPurpose, &proof.VerificationMethod)
+	if err != nil {
+		return fmt.Errorf("data-integrity verification method %s: %w", proof.VerificationMethod.String(), err)
+	}
+	key, err := m.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	hash, err := suiteHash(suite, key)
+	if err != nil {
+		return err
+	}
+	if proof.ProofValue == "" || proof.ProofValue[0] != multikey.Base58BTC {
+		return errors.New("data-integrity proof value not in base58-btc multibase")
+	}
+	sig, err := multikey.DecodeBase58(proof.ProofValue[1:])
+	if err != nil {
+		return fmt.Errorf("data-integrity proof value: %w", err)
+	}
+	// proof configuration as is, i.e., not re-encoded
+	config, err := decodeObject(raw)
+	if err != nil {
+		return err
+	}
+	hashData, err := hashData(hash, suite, members, config, v.Contexts)
+	if err != nil {
+		return err
+	}
+	return verifySignature(hash, key, hashData, sig)
+}
+
+func verifySignature(hash crypto.Hash, key crypto.PublicKey, hashData, sig []byte) error {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, hashData, sig) {
+			return did.ErrSignature
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return did.ErrSignature
+		}
+		digest := hash.New()
+		digest.Write(hashData)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest.Sum(nil), r, s) {
+			return did.ErrSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: key type %T", ErrCryptosuite, key)
+	}
+}
+
+// CheckContextPrefix verifies that the document context starts with each of
+// the proof contexts, in the same order.
+func checkContextPrefix(docContext, proofContext json.RawMessage) error {
+	docEntries, err := contextEntries(docContext)
+	if err != nil {
+		return err
+	}
+	proofEntries, err := contextEntries(proofContext)
+	if err != nil {
+		return err
+	}
+	if len(proofEntries) > len(docEntries) {
+		return errors.New("data-integrity proof @context not in document")
+	}
+	for i, entry := range proofEntries {
+		if !bytes.Equal(entry, docEntries[i]) {
+			return errors.New("data-integrity proof @context not in document")
+		}
+	}
+	return nil
+}
+
+// ContextEntries returns each "@context" value in canonical form.
+func contextEntries(raw json.RawMessage) ([][]byte, error) {
+	var entries []json.RawMessage
+	if trimmed := bytes.TrimLeft(raw, " \t\r\n"); len(trimmed) != 0 && trimmed[0] == '[' {
+		err := json.Unmarshal(trimmed, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("data-integrity @context: %w", err)
+		}
+	} else if len(trimmed) != 0 {
+		entries = []json.RawMessage{trimmed}
+	}
+
+	canonical := make([][]byte, len(entries))
+	for i, entry := range entries {
+		var err error
+		canonical[i], err = jcs.Transform(entry)
+		if err != nil {
+			return nil, fmt.Errorf("data-integrity @context: %w", err)
+		}
+	}
+	return canonical, nil
+}
@@ -0,0 +1,201 @@
+package did
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DerefMeta is the “DID URL dereferencing metadata”.
+type DerefMeta struct {
+	// The (MIME) media type of the content, if any.
+	ContentType string `json:"contentType,omitempty"`
+
+	// Error has the standardised code [ErrorCode] in case of failure.
+	Error string `json:"error,omitempty"`
+}
+
+// Dereferenced is the outcome of DID URL dereferencing.
+type Dereferenced struct {
+	Meta DerefMeta
+
+	// Content is either a *Document, a *VerificationMethod, a *Service, or
+	// a service endpoint as *url.URL.
+	Content any
+
+	// The resolved document, regardless of the selection in Content.
+	Document *Document
+
+	// ContentMeta describes the resolved document, if any.
+	ContentMeta *Meta
+}
+
+// Dereference resolves the DID of u with r, and it selects the resource which
+// u points to. A DID URL without path, query and fragment dereferences to the
+// Document as a whole. Fragments select a verification method or a service.
+// The "service" parameter selects a service endpoint, with the "relativeRef"
+// parameter resolved against it as per “URI: Generic Syntax” RFC 3986, section
+// 5.
+//
+// The "versionId" and "versionTime" parameters apply as ResolutionOptions.
+// Errors include ErrInvalidURL for syntax violations, and any of the errors
+// from r. The error code [ErrorCode] is also set in the dereferencing metadata.
+// Paths are method specific, and therefore not found.
+func (r Resolve) Dereference(u *URL) (*Dereferenced, error) {
+	return DereferenceContext(context.Background(), r, u)
+}
+
+// DereferenceContext is like Dereference, yet with context support.
+func DereferenceContext(ctx context.Context, r Resolver, u *URL) (*Dereferenced, error) {
+	var deref Dereferenced
+	fail := func(err error) (*Dereferenced, error) {
+		deref.Meta.Error = ErrorCode(err)
+		return &deref, err
+	}
+
+	if u == nil || u.IsRelative() {
+		return fail(fmt.Errorf("%w: no DID to resolve", ErrInvalidURL))
+	}
+
+	var params url.Values
+	var opts ResolutionOptions
+	if u.RawQuery != "" {
+		var err error
+		params, err = url.ParseQuery(u.RawQuery[1:])
+		if err != nil {
+			return fail(fmt.Errorf("%w: query: %s", ErrInvalidURL, err))
+		}
+		if len(params["service"]) > 1 {
+			return fail(fmt.Errorf("%w: duplicate service parameter", ErrInvalidURL))
+		}
+		if len(params["relativeRef"]) > 1 {
+			return fail(fmt.Errorf("%w: duplicate relativeRef parameter", ErrInvalidURL))
+		}
+		opts.VersionID, opts.VersionTime, err = VersionParams(params)
+		if err != nil {
+			return fail(fmt.Errorf("%w: %s", ErrInvalidURL, err))
+		}
+	}
+
+	res := r.ResolveContext(ctx, u.DID, &opts)
+	if err := res.Err(); err != nil {
+		return fail(err)
+	}
+	doc := res.Document
+	deref.Document = doc
+	deref.ContentMeta = res.DocumentMeta
+
+	if u.RawPath != "" {
+		return fail(fmt.Errorf("%w: path %q of DID URL is method specific", ErrNotFound, u.RawPath))
+	}
+
+	if name := params.Get("service"); name != "" {
+		srv := doc.service(&URL{DID: doc.Subject, RawFragment: encodeWithLead(name, '#')})
+		if srv == nil {
+			return fail(fmt.Errorf("%w: no service %q in DID document", ErrNotFound, name))
+		}
+		if len(srv.Endpoint.URIRefs) == 0 {
+			return fail(fmt.Errorf("%w: no URI in endpoint of service %q", ErrNotFound, name))
+		}
+		endpoint := *srv.Endpoint.URIRefs[0] // copy
+
+		if ref, ok := params["relativeRef"]; ok {
+			p, err := url.Parse(ref[0])
+			if err != nil {
+				return fail(fmt.Errorf("%w: relativeRef: %s", ErrInvalidURL, err))
+			}
+			endpoint = *endpoint.ResolveReference(p)
+		}
+		if u.RawFragment != "" {
+			// “If the input DID URL contains a DID fragment, then
+			// append the DID fragment to the output service endpoint
+			// URL.”
+			endpoint.Fragment = u.Fragment()
+			endpoint.RawFragment = u.RawFragment[1:]
+		}
+
+		deref.Meta.ContentType = "text/uri-list"
+		deref.Content = &endpoint
+		return &deref, nil
+	}
+	if _, ok := params["relativeRef"]; ok {
+		return fail(fmt.Errorf("%w: relativeRef without service parameter", ErrInvalidURL))
+	}
+
+	deref.Meta.ContentType = JSON
+	if u.RawFragment == "" {
+		deref.Content = doc
+		return &deref, nil
+	}
+
+	// resolve fragment against DID document "id"
+	sel := &URL{DID: doc.Subject, RawFragment: u.RawFragment}
+	if m := doc.verificationMethod(sel); m != nil {
+		deref.Content = m
+		return &deref, nil
+	}
+	if srv := doc.service(sel); srv != nil {
+		deref.Content = srv
+		return &deref, nil
+	}
+	return fail(fmt.Errorf("%w: fragment %q not in DID document", ErrNotFound, u.RawFragment))
+}
+
+// VerificationMethod returns the first verification method with an identifier
+// equal to u, including the ones embedded in verification relationships, or nil
+// when not found.
+func (doc *Document) verificationMethod(u *URL) *VerificationMethod {
+	for _, m := range doc.VerificationMethods {
+		if m != nil && doc.resolve(&m.ID).Equal(u) {
+			return m
+		}
+	}
+
+	relationships := [...]*VerificationRelationship{
+		doc.Authentication,
+		doc.AssertionMethod,
+		doc.KeyAgreement,
+		doc.CapabilityInvocation,
+		doc.CapabilityDelegation,
+	}
+	for _, r := range relationships {
+		if r == nil {
+			continue
+		}
+		for _, m := range r.Methods {
+			if m != nil && doc.resolve(&m.ID).Equal(u) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// Service returns the first service with an identifier equal to u, or nil when
+// not found.
+func (doc *Document) service(u *URL) *Service {
+	for _, srv := range doc.Services {
+		if srv == nil {
+			continue
+		}
+		id, err := ParseURL(srv.ID.String())
+		if err != nil {
+			continue // not a DID URL
+		}
+		if doc.resolve(id).Equal(u) {
+			return srv
+		}
+	}
+	return nil
+}
+
+// Resolve returns u in absolute form, with relative URLs resolved against the
+// document Subject.
+func (doc *Document) resolve(u *URL) *URL {
+	if !u.IsRelative() {
+		return u
+	}
+	r := *u // copy
+	r.DID = doc.Subject
+	return &r
+}
@@ -0,0 +1,153 @@
+package did_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+// Example21 has a dereferencing target for each of the W3C URL examples.
+const example21 = `{
+  "id": "did:example:123",
+  "verificationMethod": [{
+    "id": "did:example:123#public-key-0",
+    "type": "Ed25519VerificationKey2020",
+    "controller": "did:example:123",
+    "publicKeyMultibase": "zH3C2AVvLMv6gmMNam3uVAjZpfkcJCwDwnZn6z3wXmqPV"
+  }],
+  "authentication": [{
+    "id": "#key-2",
+    "type": "Ed25519VerificationKey2020",
+    "controller": "did:example:123",
+    "publicKeyMultibase": "zH3C2AVvLMv6gmMNam3uVAjZpfkcJCwDwnZn6z3wXmqPV"
+  }],
+  "service": [{
+    "id": "#agent",
+    "type": "DIDCommMessaging",
+    "serviceEndpoint": "https://agent.example.com/"
+  }, {
+    "id": "did:example:123#files",
+    "type": "LinkedDomains",
+    "serviceEndpoint": "https://files.example.com/users/123/"
+  }]
+}`
+
+// ResolveExample21 is a did.Resolve for did:example:123 only.
+func resolveExample21(d did.DID) (*did.Document, *did.Meta, error) {
+	if !d.EqualString("did:example:123") {
+		return nil, nil, did.ErrNotFound
+	}
+	doc := new(did.Document)
+	err := json.Unmarshal([]byte(example21), doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, new(did.Meta), nil
+}
+
+func ExampleResolve_Dereference() {
+	u, err := did.ParseURL(example8)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	deref, err := did.Resolve(resolveExample21).Dereference(u)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("content type:", deref.Meta.ContentType)
+	fmt.Println("endpoint:", deref.Content)
+	// Output:
+	// content type: text/uri-list
+	// endpoint: https://files.example.com/resume.pdf
+}
+
+func TestDereference(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantType string
+		wantID   string
+	}{
+		{"did:example:123", did.JSON, "did:example:123"},
+		{example4, did.JSON, "did:example:123#public-key-0"},
+		{"did:example:123#key-2", did.JSON, "#key-2"},
+		{example5, did.JSON, "#agent"},
+		{"did:example:123#files", did.JSON, "did:example:123#files"},
+		{"did:example:123?service=agent", "text/uri-list", "https://agent.example.com/"},
+		{example6, "text/uri-list", "https://agent.example.com/credentials#degree"},
+	}
+
+	for _, test := range tests {
+		u, err := did.ParseURL(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deref, err := did.Resolve(resolveExample21).Dereference(u)
+		if err != nil {
+			t.Errorf("%s got error: %s", test.url, err)
+			continue
+		}
+		if deref.Meta.ContentType != test.wantType {
+			t.Errorf("%s got content type %q, want %q", test.url, deref.Meta.ContentType, test.wantType)
+		}
+
+		var id string
+		switch c := deref.Content.(type) {
+		case *did.Document:
+			id = c.Subject.String()
+		case *did.VerificationMethod:
+			id = c.ID.String()
+		case *did.Service:
+			id = c.ID.String()
+		case *url.URL:
+			id = c.String()
+		default:
+			t.Errorf("%s got content type %T", test.url, c)
+			continue
+		}
+		if id != test.wantID {
+			t.Errorf("%s got %q, want %q", test.url, id, test.wantID)
+		}
+	}
+}
+
+func TestDereferenceErrors(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantErr  error
+		wantCode string
+	}{
+		{"#public-key-0", did.ErrInvalidURL, "invalidDidUrl"},
+		{"did:example:123?service=agent&service=files", did.ErrInvalidURL, "invalidDidUrl"},
+		{"did:example:123?relativeRef=/foo", did.ErrInvalidURL, "invalidDidUrl"},
+		{"did:example:123?service=agent;files", did.ErrInvalidURL, "invalidDidUrl"},
+		{"did:example:456", did.ErrNotFound, "notFound"},
+		{"did:example:123#public-key-1", did.ErrNotFound, "notFound"},
+		{"did:example:123?service=none", did.ErrNotFound, "notFound"},
+		{"did:example:123/path", did.ErrNotFound, "notFound"},
+	}
+
+	for _, test := range tests {
+		u, err := did.ParseURL(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deref, err := did.Resolve(resolveExample21).Dereference(u)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s got error %v, want %v", test.url, err, test.wantErr)
+		}
+		if deref == nil {
+			t.Errorf("%s got no dereferencing result", test.url)
+		} else if deref.Meta.Error != test.wantCode {
+			t.Errorf("%s got error code %q, want %q", test.url, deref.Meta.Error, test.wantCode)
+		}
+	}
+}
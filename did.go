@@ -193,13 +193,19 @@ func readMethodName(s string) (string, error) {
 // Equal returns whether both d and o are valid, and whether they are equivalent
 // according to the “Normalization and Comparison” rules of RFC 3986, section 6.
 func (d DID) Equal(o DID) bool {
-	if d.Method == "" || d.SpecID == "" {
+	if d.SpecID == "" || !validMethodName(d.Method) {
 		return false // invalid
 	}
+	return o == d
+}
 
-	// validate method name
-	for i := 0; i < len(d.Method); i++ {
-		switch d.Method[i] {
+// ValidMethodName returns whether s matches the method-name BNF.
+func validMethodName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
 		// match method-char BNF
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', // DIGIT
 			'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', // %x61-7A
@@ -209,8 +215,7 @@ func (d DID) Equal(o DID) bool {
 			return false // invalid
 		}
 	}
-
-	return o == d
+	return true
 }
 
 // EqualString returns whether s conforms to the DID syntax, and whether the
@@ -242,6 +247,8 @@ func (d DID) EqualString(s string) bool {
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9',
 			'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm',
 			'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z',
+			'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M',
+			'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z',
 			'.', '-', '_':
 			if s[i] != c {
 				return false
@@ -990,19 +997,24 @@ func SetVersionParams(params url.Values, s string, t time.Time) {
 	}
 
 	if !t.IsZero() {
-		// JSON production requires “normalized to UTC 00:00:00 and
-		// without sub-second decimal precision”, as per subsection
-		// 6.2.1 of the v1 specification.
-		t := t.UTC()
-		if t.Nanosecond() != 0 {
-			t = t.Round(time.Second)
-		}
-		params.Set("versionTime", t.Format(time.RFC3339))
+		params.Set("versionTime", formatDateTime(t))
 	} else {
 		params.Del("versionTime")
 	}
 }
 
+// FormatDateTime returns the XML Datetime normalized conform JSON production.
+func formatDateTime(t time.Time) string {
+	// JSON production requires “normalized to UTC 00:00:00 and without
+	// sub-second decimal precision”, as per subsection 6.2.1 of the v1
+	// specification.
+	t = t.UTC()
+	if t.Nanosecond() != 0 {
+		t = t.Round(time.Second)
+	}
+	return t.Format(time.RFC3339)
+}
+
 // Malmormed percent-encodings simply pass as is.
 func bestEffortDecode(s string) string {
 	i := strings.IndexByte(s, '%')
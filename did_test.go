@@ -61,6 +61,9 @@ var GoldenDIDs = []struct {
 	{
 		"did:foo:bar",
 		did.DID{Method: "foo", SpecID: "bar"},
+	}, {
+		"did:foo:Bar",
+		did.DID{Method: "foo", SpecID: "Bar"},
 	}, {
 		"did:foo:b%61r",
 		did.DID{Method: "foo", SpecID: "bar"},
@@ -192,6 +195,18 @@ var DIDEquals = [][]string{
 		"did:tricky:%3Afoo%2F",
 		"did:tricky:%3A%66%6F%6F%2F",
 	},
+	{
+		// upper-case is distinct from lower-case
+		"did:case:Mixed",
+		"did:case:%4Dixed",
+	},
+	{
+		"did:case:mixed",
+		"did:case:%6Dixed",
+	},
+	{
+		"did:case:MIXED",
+	},
 	{
 		// binary value
 		"did:sha256:%e3%b0%c4%42%98%fc%1c%14%9a%fb%f4%c8%99%6f%b9%24%27%ae%41%e4%64%9b%93%4c%a4%95%99%1b%78%52%b8%55",
@@ -0,0 +1,128 @@
+// Package didjwk implements the “did:jwk” method. Documents are derived from
+// the identifier exclusively, without any network access.
+// See https://github.com/quartzjer/did-jwk/blob/main/spec.md for the
+// specification.
+package didjwk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pascaldekloe/did"
+)
+
+// Method is the DID method name.
+const Method = "jwk"
+
+// MethodType is the verification method type in use.
+const MethodType = "JsonWebKey2020"
+
+// New returns the DID of a public JSON Web Key (RFC 7517). Whitespace is
+// removed from jwk. Private keys are denied.
+func New(jwk []byte) (did.DID, error) {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, jwk)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("did:jwk: %w", err)
+	}
+	_, err = parse(buf.Bytes())
+	if err != nil {
+		return did.DID{}, err
+	}
+	return did.DID{Method: Method, SpecID: base64.RawURLEncoding.EncodeToString(buf.Bytes())}, nil
+}
+
+// JWK returns the JSON Web Key (RFC 7517) of a DID.
+func JWK(d did.DID) (json.RawMessage, error) {
+	jwk, _, err := decode(d)
+	return jwk, err
+}
+
+func decode(d did.DID) (jwk json.RawMessage, use string, err error) {
+	if d.Method != Method {
+		return nil, "", fmt.Errorf("%w: method %q is not %q", did.ErrMethodNotSupported, d.Method, Method)
+	}
+	jwk, err = base64.RawURLEncoding.DecodeString(d.SpecID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: did:jwk not in base64url: %s", did.ErrInvalid, err)
+	}
+	use, err = parse(jwk)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", did.ErrInvalid, err)
+	}
+	return jwk, use, nil
+}
+
+// Resolve conforms to the did.Resolve signature. The verification relationships
+// follow the "use" of the key. Signature keys ("sig") apply to all relationships
+// except for key agreement. Encryption keys ("enc") apply to key agreement only.
+// Keys without "use" apply to all five relationships.
+func Resolve(d did.DID) (*did.Document, *did.Meta, error) {
+	jwk, use, err := decode(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: d, RawFragment: "#0"},
+		Type:       MethodType,
+		Controller: d,
+		Additional: map[string]json.RawMessage{"publicKeyJwk": jwk},
+	}
+	doc := &did.Document{
+		Subject:             d,
+		VerificationMethods: []*did.VerificationMethod{m},
+	}
+	if use != "enc" {
+		doc.AssertionMethod = refTo(m)
+		doc.Authentication = refTo(m)
+		doc.CapabilityInvocation = refTo(m)
+		doc.CapabilityDelegation = refTo(m)
+	}
+	if use != "sig" {
+		doc.KeyAgreement = refTo(m)
+	}
+	return doc, new(did.Meta), nil
+}
+
+// RefTo returns a relationship with a reference to m.
+func refTo(m *did.VerificationMethod) *did.VerificationRelationship {
+	u := m.ID // copy
+	return &did.VerificationRelationship{URIRefs: []*did.URL{&u}}
+}
+
+// Parse validates a public JWK, and it returns the "use", if any.
+func parse(jwk []byte) (use string, err error) {
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(jwk, &members)
+	if err != nil {
+		return "", fmt.Errorf("did:jwk: %w", err)
+	}
+
+	var kty string
+	if raw, ok := members["kty"]; !ok {
+		return "", errors.New(`did:jwk: JWK has no "kty"`)
+	} else if err := json.Unmarshal(raw, &kty); err != nil || kty == "" {
+		return "", errors.New(`did:jwk: JWK "kty" is not a string`)
+	}
+	if kty == "oct" {
+		return "", errors.New("did:jwk: symmetric JWK denied")
+	}
+
+	// RFC 7518, section 6
+	for _, name := range [...]string{"d", "p", "q", "dp", "dq", "qi", "oth", "k"} {
+		if _, ok := members[name]; ok {
+			return "", fmt.Errorf("did:jwk: private JWK member %q denied", name)
+		}
+	}
+
+	if raw, ok := members["use"]; ok {
+		if err := json.Unmarshal(raw, &use); err != nil {
+			return "", errors.New(`did:jwk: JWK "use" is not a string`)
+		}
+	}
+	return use, nil
+}
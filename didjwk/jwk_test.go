@@ -0,0 +1,110 @@
+package didjwk_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/didjwk"
+)
+
+// Example from the specification.
+const p256DID = "did:jwk:eyJjcnYiOiJQLTI1NiIsImt0eSI6IkVDIiwieCI6ImFjYklRaXVNczNpOF91c3pFakoydHBUdFJNNEVVM3l6OTFQSDZDZEgyVjAiLCJ5IjoiX0tjeUxqOXZXTXB0bm1LdG00NkdxRHo4d2Y3NEk1TEtncmwyR3pIM25TRSJ9"
+
+func ExampleResolve() {
+	d, err := did.Parse(p256DID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, _, err := didjwk.Resolve(d)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	m := doc.VerificationMethods[0]
+	fmt.Println("method:", m.ID.RawFragment, m.Type)
+	fmt.Printf("JWK: %s\n", m.Additional["publicKeyJwk"])
+	fmt.Println("key agreement:", doc.KeyAgreement != nil)
+	// Output:
+	// method: #0 JsonWebKey2020
+	// JWK: {"crv":"P-256","kty":"EC","x":"acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0","y":"_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE"}
+	// key agreement: true
+}
+
+func TestNew(t *testing.T) {
+	d, err := didjwk.New([]byte(`{
+		"crv": "P-256",
+		"kty": "EC",
+		"x": "acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0",
+		"y": "_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.EqualString(p256DID) {
+		t.Errorf("got %s, want %s", d, p256DID)
+	}
+}
+
+func TestResolveUse(t *testing.T) {
+	tests := []struct {
+		jwk           string
+		wantSig       bool
+		wantAgreement bool
+	}{
+		{`{"kty":"OKP","crv":"Ed25519","use":"sig","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`, true, false},
+		{`{"kty":"OKP","crv":"X25519","use":"enc","x":"3p7bfXt9wbTTW2HC7OQ1Nz-DQ8hbeGdNrfx-FG-IK08"}`, false, true},
+		{`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`, true, true},
+	}
+	for _, test := range tests {
+		d, err := didjwk.New([]byte(test.jwk))
+		if err != nil {
+			t.Fatalf("%s got error: %s", test.jwk, err)
+		}
+		doc, _, err := didjwk.Resolve(d)
+		if err != nil {
+			t.Fatalf("%s got error: %s", test.jwk, err)
+		}
+
+		if got := doc.Authentication != nil && doc.AssertionMethod != nil && doc.CapabilityInvocation != nil && doc.CapabilityDelegation != nil; got != test.wantSig {
+			t.Errorf("%s got signature relationships %t, want %t", test.jwk, got, test.wantSig)
+		}
+		if got := doc.KeyAgreement != nil; got != test.wantAgreement {
+			t.Errorf("%s got key agreement %t, want %t", test.jwk, got, test.wantAgreement)
+		}
+		if _, notFound := doc.VerificationMethodRefs(); len(notFound) != 0 {
+			t.Errorf("%s got references not found: %s", test.jwk, notFound)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, jwk := range []string{
+		`[]`,
+		`{"crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+		`{"kty":"oct","k":"GawgguFyGrWKav7AX4VKUg"}`,
+		`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+	} {
+		if d, err := didjwk.New([]byte(jwk)); err == nil {
+			t.Errorf("%s got %s, want error", jwk, d)
+		}
+	}
+
+	tests := []struct {
+		d    did.DID
+		want error
+	}{
+		{did.DID{Method: "key", SpecID: "eyJ9"}, did.ErrMethodNotSupported},
+		{did.DID{Method: "jwk", SpecID: "eyJ9"}, did.ErrInvalid},
+		{did.DID{Method: "jwk", SpecID: "e30"}, did.ErrInvalid},
+	}
+	for _, test := range tests {
+		_, _, err := didjwk.Resolve(test.d)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s got error %v, want %v", test.d, err, test.want)
+		}
+	}
+}
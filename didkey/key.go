@@ -0,0 +1,121 @@
+// Package didkey implements the “did:key” method. Documents are derived from
+// the identifier exclusively, without any network access.
+// See https://w3c-ccg.github.io/did-method-key/ for the specification.
+package didkey
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/multikey"
+)
+
+// Method is the DID method name.
+const Method = "key"
+
+// MethodType is the verification method type in use.
+const MethodType = did.MultikeyType
+
+// New returns the DID of a public key. Supported types are ed25519.PublicKey,
+// *ecdh.PublicKey for X25519, and *ecdsa.PublicKey for P-256, P-384 and P-521.
+func New(key crypto.PublicKey) (did.DID, error) {
+	s, err := multikey.Encode(key)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("did:key: %w", err)
+	}
+	return did.DID{Method: Method, SpecID: s}, nil
+}
+
+// PublicKey returns the public key of a DID. The return is either an
+// ed25519.PublicKey, an *ecdh.PublicKey for X25519, or an *ecdsa.PublicKey.
+// Errors include did.ErrInvalid for malformed identifiers.
+func PublicKey(d did.DID) (crypto.PublicKey, error) {
+	if d.Method != Method {
+		return nil, fmt.Errorf("%w: method %q is not %q", did.ErrMethodNotSupported, d.Method, Method)
+	}
+	if d.SpecID == "" || d.SpecID[0] != multikey.Base58BTC {
+		return nil, fmt.Errorf("%w: did:key %q not in base58-btc multibase", did.ErrInvalid, d.SpecID)
+	}
+	key, err := multikey.Decode(d.SpecID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: did:key %q: %s", did.ErrInvalid, d.SpecID, err)
+	}
+	return key, nil
+}
+
+// Resolve conforms to the did.Resolve signature. Ed25519 keys get an X25519
+// key, as derived per RFC 7748, for key agreement. X25519 keys are for key
+// agreement only. ECDSA keys apply to all five verification relationships.
+func Resolve(d did.DID) (*did.Document, *did.Meta, error) {
+	key, err := PublicKey(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := &did.Document{Subject: d}
+	m, err := newMethod(d, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc.VerificationMethods = []*did.VerificationMethod{m}
+
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		doc.Authentication = refTo(m)
+		doc.AssertionMethod = refTo(m)
+		doc.CapabilityInvocation = refTo(m)
+		doc.CapabilityDelegation = refTo(m)
+
+		derived, err := multikey.X25519FromEd25519(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: did:key %q: %s", did.ErrInvalid, d.SpecID, err)
+		}
+		agreement, err := newMethod(d, derived)
+		if err != nil {
+			return nil, nil, err
+		}
+		doc.VerificationMethods = append(doc.VerificationMethods, agreement)
+		doc.KeyAgreement = refTo(agreement)
+
+	case *ecdh.PublicKey:
+		doc.KeyAgreement = refTo(m)
+
+	default:
+		doc.Authentication = refTo(m)
+		doc.AssertionMethod = refTo(m)
+		doc.CapabilityInvocation = refTo(m)
+		doc.CapabilityDelegation = refTo(m)
+		doc.KeyAgreement = refTo(m)
+	}
+
+	return doc, new(did.Meta), nil
+}
+
+// NewMethod returns the Multikey of key, with its multibase as the fragment.
+func newMethod(d did.DID, key crypto.PublicKey) (*did.VerificationMethod, error) {
+	s, err := multikey.Encode(key)
+	if err != nil {
+		return nil, fmt.Errorf("did:key: %w", err)
+	}
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: d},
+		Type:       MethodType,
+		Controller: d,
+		Additional: map[string]json.RawMessage{
+			"publicKeyMultibase": json.RawMessage(strconv.Quote(s)),
+		},
+	}
+	m.ID.SetFragment(s)
+	return m, nil
+}
+
+// RefTo returns a relationship with a reference to m.
+func refTo(m *did.VerificationMethod) *did.VerificationRelationship {
+	u := m.ID // copy
+	return &did.VerificationRelationship{URIRefs: []*did.URL{&u}}
+}
@@ -0,0 +1,120 @@
+package didkey_test
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/didkey"
+)
+
+func ExampleResolve() {
+	d, err := did.Parse("did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, _, err := didkey.Resolve(d)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println("authentication:", doc.Authentication.URIRefs)
+	fmt.Println("key agreement:", doc.KeyAgreement.URIRefs)
+	// Output:
+	// authentication: [did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK]
+	// key agreement: [did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK#z6LSj72tK8brWgZja8NLRwPigth2T9QRiG1uH9oKZuKjdh9p]
+}
+
+func TestResolveP256(t *testing.T) {
+	d := did.DID{Method: "key", SpecID: "zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169"}
+	doc, _, err := didkey.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(doc.VerificationMethods); n != 1 {
+		t.Fatalf("got %d verification methods, want 1", n)
+	}
+	m := doc.VerificationMethods[0]
+	if got, want := m.ID.String(), d.String()+"#"+d.SpecID; got != want {
+		t.Errorf("got method ID %q, want %q", got, want)
+	}
+	if got := m.AdditionalString("publicKeyMultibase"); got != d.SpecID {
+		t.Errorf("got publicKeyMultibase %q, want %q", got, d.SpecID)
+	}
+
+	perURI, notFound := doc.VerificationMethodRefs()
+	if len(notFound) != 0 {
+		t.Errorf("references not found: %s", notFound)
+	}
+	if len(perURI) != 5 {
+		t.Errorf("got %d references, want one for each relationship", len(perURI))
+	}
+}
+
+func TestNew(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []crypto.PublicKey{edKey, xKey.PublicKey(), &ecKey.PublicKey} {
+		d, err := didkey.New(key)
+		if err != nil {
+			t.Errorf("%T got error: %s", key, err)
+			continue
+		}
+		got, err := didkey.PublicKey(d)
+		if err != nil {
+			t.Errorf("%s got error: %s", d, err)
+			continue
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Errorf("%s got key %v, want %v", d, got, key)
+		}
+
+		doc, _, err := didkey.Resolve(d)
+		if err != nil {
+			t.Errorf("%s got error: %s", d, err)
+			continue
+		}
+		if doc.KeyAgreement == nil {
+			t.Errorf("%s has no key agreement", d)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		d    did.DID
+		want error
+	}{
+		{did.DID{Method: "web", SpecID: "example.com"}, did.ErrMethodNotSupported},
+		{did.DID{Method: "key", SpecID: "6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"}, did.ErrInvalid},
+		{did.DID{Method: "key", SpecID: "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2do"}, did.ErrInvalid},
+		{did.DID{Method: "key", SpecID: "zQ3s"}, did.ErrInvalid},
+	}
+	for _, test := range tests {
+		_, _, err := didkey.Resolve(test.d)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s got error %v, want %v", test.d, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+// Package didpeer implements the “did:peer” method, with numeric algorithm 0
+// and 2. Documents are derived from the identifier exclusively, without any
+// network access. See https://identity.foundation/peer-did-method-spec/ for the
+// specification.
+package didpeer
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/multikey"
+)
+
+// Method is the DID method name.
+const Method = "peer"
+
+// MethodType is the verification method type in use.
+const MethodType = did.MultikeyType
+
+// Purpose is a numeric algorithm 2 code for a verification relationship.
+type Purpose byte
+
+// Purpose codes map to their respective verification relationship.
+const (
+	AssertionMethod      Purpose = 'A'
+	KeyAgreement         Purpose = 'E' // encryption
+	Authentication       Purpose = 'V' // verification
+	CapabilityInvocation Purpose = 'I'
+	CapabilityDelegation Purpose = 'D'
+)
+
+// Key is a numeric algorithm 2 entry.
+type Key struct {
+	Purpose
+
+	// Supported types are ed25519.PublicKey, *ecdh.PublicKey for X25519,
+	// and *ecdsa.PublicKey for P-256, P-384 and P-521.
+	PublicKey crypto.PublicKey
+}
+
+// New0 returns the numeric algorithm 0 DID of a public key, which is a did:key
+// in disguise. See New2 for the supported key types.
+func New0(key crypto.PublicKey) (did.DID, error) {
+	s, err := multikey.Encode(key)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("did:peer: %w", err)
+	}
+	return did.DID{Method: Method, SpecID: "0" + s}, nil
+}
+
+// New2 returns the numeric algorithm 2 DID of each key and service, in order
+// of appearance. At least one key or service is required. Service identifiers
+// may be omitted (with a zero ID), in which case they get "#service" for the
+// first, followed by "#service-1", etc.
+func New2(keys []Key, services ...*did.Service) (did.DID, error) {
+	if len(keys) == 0 && len(services) == 0 {
+		return did.DID{}, errors.New("did:peer: numeric algorithm 2 without keys nor services")
+	}
+
+	var b strings.Builder
+	b.WriteByte('2')
+
+	for _, k := range keys {
+		switch k.Purpose {
+		case AssertionMethod, KeyAgreement, Authentication, CapabilityInvocation, CapabilityDelegation:
+			break
+		default:
+			return did.DID{}, fmt.Errorf("did:peer: purpose code %q unknown", k.Purpose)
+		}
+		s, err := multikey.Encode(k.PublicKey)
+		if err != nil {
+			return did.DID{}, fmt.Errorf("did:peer: %w", err)
+		}
+		b.WriteByte('.')
+		b.WriteByte(byte(k.Purpose))
+		b.WriteString(s)
+	}
+
+	for i, srv := range services {
+		s, err := encodeService(srv, i)
+		if err != nil {
+			return did.DID{}, err
+		}
+		b.WriteString(".S")
+		b.WriteString(s)
+	}
+
+	return did.DID{Method: Method, SpecID: b.String()}, nil
+}
+
+// Resolve conforms to the did.Resolve signature. Verification methods of the
+// numeric algorithm 2 get a "#key-" identifier, numbered from 1 in order of
+// appearance.
+func Resolve(d did.DID) (*did.Document, *did.Meta, error) {
+	if d.Method != Method {
+		return nil, nil, fmt.Errorf("%w: method %q is not %q", did.ErrMethodNotSupported, d.Method, Method)
+	}
+
+	var doc *did.Document
+	var err error
+	switch {
+	case strings.HasPrefix(d.SpecID, "0"):
+		doc, err = resolve0(d)
+	case strings.HasPrefix(d.SpecID, "2."):
+		doc, err = resolve2(d)
+	default:
+		return nil, nil, fmt.Errorf("%w: did:peer numeric algorithm of %q not supported", did.ErrInvalid, d.SpecID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", did.ErrInvalid, err)
+	}
+	return doc, new(did.Meta), nil
+}
+
+func resolve0(d did.DID) (*did.Document, error) {
+	s := d.SpecID[1:]
+	if s == "" || s[0] != multikey.Base58BTC {
+		return nil, fmt.Errorf("did:peer:0 %q not in base58-btc multibase", s)
+	}
+	key, err := multikey.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("did:peer:0: %w", err)
+	}
+
+	doc := &did.Document{Subject: d}
+	m, err := newMethod(d, s, key)
+	if err != nil {
+		return nil, err
+	}
+	doc.VerificationMethods = []*did.VerificationMethod{m}
+
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		doc.Authentication = refTo(nil, m)
+		doc.AssertionMethod = refTo(nil, m)
+		doc.CapabilityInvocation = refTo(nil, m)
+		doc.CapabilityDelegation = refTo(nil, m)
+
+		derived, err := multikey.X25519FromEd25519(key)
+		if err != nil {
+			return nil, fmt.Errorf("did:peer:0: %w", err)
+		}
+		s, err := multikey.Encode(derived)
+		if err != nil {
+			return nil, err
+		}
+		agreement, err := newMethod(d, s, derived)
+		if err != nil {
+			return nil, err
+		}
+		doc.VerificationMethods = append(doc.VerificationMethods, agreement)
+		doc.KeyAgreement = refTo(nil, agreement)
+
+	case *ecdh.PublicKey:
+		doc.KeyAgreement = refTo(nil, m)
+
+	default:
+		doc.Authentication = refTo(nil, m)
+		doc.AssertionMethod = refTo(nil, m)
+		doc.CapabilityInvocation = refTo(nil, m)
+		doc.CapabilityDelegation = refTo(nil, m)
+		doc.KeyAgreement = refTo(nil, m)
+	}
+	return doc, nil
+}
+
+func resolve2(d did.DID) (*did.Document, error) {
+	doc := &did.Document{Subject: d}
+
+	var keyN, serviceN int
+	for _, elem := range strings.Split(d.SpecID[2:], ".") {
+		if len(elem) < 2 {
+			return nil, fmt.Errorf("did:peer:2 element %q incomplete", elem)
+		}
+
+		var rel **did.VerificationRelationship
+		switch p := Purpose(elem[0]); p {
+		case 'S':
+			srv, err := decodeService(elem[1:], serviceN)
+			if err != nil {
+				return nil, err
+			}
+			doc.Services = append(doc.Services, srv)
+			serviceN++
+			continue
+
+		case AssertionMethod:
+			rel = &doc.AssertionMethod
+		case KeyAgreement:
+			rel = &doc.KeyAgreement
+		case Authentication:
+			rel = &doc.Authentication
+		case CapabilityInvocation:
+			rel = &doc.CapabilityInvocation
+		case CapabilityDelegation:
+			rel = &doc.CapabilityDelegation
+		default:
+			return nil, fmt.Errorf("did:peer:2 purpose code %q unknown", p)
+		}
+
+		key, err := multikey.Decode(elem[1:])
+		if err != nil {
+			return nil, fmt.Errorf("did:peer:2 key %q: %w", elem[1:], err)
+		}
+		keyN++
+		m, err := newMethod(d, "key-"+strconv.Itoa(keyN), key)
+		if err != nil {
+			return nil, err
+		}
+		doc.VerificationMethods = append(doc.VerificationMethods, m)
+		*rel = refTo(*rel, m)
+	}
+	return doc, nil
+}
+
+// NewMethod returns the Multikey of key, with the fragment as identifier.
+func newMethod(d did.DID, fragment string, key crypto.PublicKey) (*did.VerificationMethod, error) {
+	s, err := multikey.Encode(key)
+	if err != nil {
+		return nil, fmt.Errorf("did:peer: %w", err)
+	}
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: d},
+		Type:       MethodType,
+		Controller: d,
+		Additional: map[string]json.RawMessage{
+			"publicKeyMultibase": json.RawMessage(strconv.Quote(s)),
+		},
+	}
+	m.ID.SetFragment(fragment)
+	return m, nil
+}
+
+// RefTo returns r with a reference to m appended. A nil r is allocated.
+func refTo(r *did.VerificationRelationship, m *did.VerificationMethod) *did.VerificationRelationship {
+	if r == nil {
+		r = new(did.VerificationRelationship)
+	}
+	u := m.ID // copy
+	r.URIRefs = append(r.URIRefs, &u)
+	return r
+}
+
+// Service abbreviations from the numeric algorithm 2 specification
+var (
+	abbreviations = map[string]string{
+		"type":            "t",
+		"serviceEndpoint": "s",
+		"routingKeys":     "r",
+		"accept":          "a",
+	}
+	expansions = map[string]string{
+		"t": "type",
+		"s": "serviceEndpoint",
+		"r": "routingKeys",
+		"a": "accept",
+	}
+)
+
+// ServiceID returns the default identifier for the n-th service, counted from
+// zero.
+func serviceID(n int) string {
+	if n == 0 {
+		return "#service"
+	}
+	return "#service-" + strconv.Itoa(n)
+}
+
+func encodeService(srv *did.Service, n int) (string, error) {
+	raw, err := json.Marshal(srv)
+	if err != nil {
+		return "", fmt.Errorf("did:peer service: %w", err)
+	}
+	var v map[string]any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", fmt.Errorf("did:peer service: %w", err)
+	}
+
+	if id, ok := v["id"].(string); ok && (id == "" || id == serviceID(n)) {
+		delete(v, "id")
+	}
+	if v["type"] == "DIDCommMessaging" {
+		v["type"] = "dm"
+	}
+
+	// no HTML escapes, like other implementations
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rename(v, abbreviations)); err != nil {
+		return "", fmt.Errorf("did:peer service: %w", err)
+	}
+	raw = bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeService(s string, n int) (*did.Service, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("did:peer:2 service not in base64url: %w", err)
+	}
+	var v map[string]any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("did:peer:2 service: %w", err)
+	}
+	if v == nil {
+		return nil, errors.New("did:peer:2 service is not a JSON object")
+	}
+
+	v = rename(v, expansions)
+	if v["type"] == "dm" {
+		v["type"] = "DIDCommMessaging"
+	}
+	if _, ok := v["id"]; !ok {
+		v["id"] = serviceID(n)
+	}
+
+	raw, err = json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("did:peer:2 service: %w", err)
+	}
+	srv := new(did.Service)
+	if err := srv.UnmarshalJSON(raw); err != nil {
+		return nil, fmt.Errorf("did:peer:2 service: %w", err)
+	}
+	return srv, nil
+}
+
+// Rename replaces the keys of a service object, and the keys of the objects in
+// its endpoint. The specification defines no abbreviations at any other level.
+func rename(v map[string]any, names map[string]string) map[string]any {
+	m := renameKeys(v, names)
+	endpointKey := "serviceEndpoint"
+	if _, ok := m[endpointKey]; !ok {
+		endpointKey = "s"
+	}
+	switch e := m[endpointKey].(type) {
+	case map[string]any:
+		m[endpointKey] = renameKeys(e, names)
+	case []any:
+		for i := range e {
+			if o, ok := e[i].(map[string]any); ok {
+				e[i] = renameKeys(o, names)
+			}
+		}
+	}
+	return m
+}
+
+// RenameKeys returns a copy of v with the keys replaced.
+func renameKeys(v map[string]any, names map[string]string) map[string]any {
+	m := make(map[string]any, len(v))
+	for k, e := range v {
+		if r, ok := names[k]; ok {
+			k = r
+		}
+		m[k] = e
+	}
+	return m
+}
@@ -0,0 +1,235 @@
+package didpeer_test
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/didpeer"
+)
+
+// Example from the specification.
+const example2 = "did:peer:2" +
+	".Vz6Mkj3PUd1WjvaDhNZhhhXQdz5UnZXmS7ehtx8bsPpD47kKc" +
+	".Ez6LSg8zQom395jKLrGiBNruB9MM6V8PWuf2FpEy4uRFiqQBR" +
+	".SeyJ0IjoiZG0iLCJzIjp7InVyaSI6Imh0dHA6Ly9leGFtcGxlLmNvbS9kaWRjb21tIiwiYSI6WyJkaWRjb21tL3YyIl0sInIiOlsiZGlkOmV4YW1wbGU6MTIzNDU2Nzg5YWJjZGVmZ2hpI2tleS0xIl19fQ"
+
+func ExampleResolve() {
+	d, err := did.Parse(example2)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, _, err := didpeer.Resolve(d)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, m := range doc.VerificationMethods {
+		fmt.Println("method:", m.ID.Fragment(), m.AdditionalString("publicKeyMultibase"))
+	}
+	fmt.Println("authentication:", doc.Authentication.URIRefs[0].Fragment())
+	fmt.Println("key agreement:", doc.KeyAgreement.URIRefs[0].Fragment())
+	for _, srv := range doc.Services {
+		fmt.Printf("service: %s %q %s\n", srv.ID.String(), srv.Types, srv.Endpoint.Maps)
+	}
+	// Output:
+	// method: key-1 z6Mkj3PUd1WjvaDhNZhhhXQdz5UnZXmS7ehtx8bsPpD47kKc
+	// method: key-2 z6LSg8zQom395jKLrGiBNruB9MM6V8PWuf2FpEy4uRFiqQBR
+	// authentication: key-1
+	// key agreement: key-2
+	// service: #service ["DIDCommMessaging"] [{"accept":["didcomm/v2"],"routingKeys":["did:example:123456789abcdefghi#key-1"],"uri":"http://example.com/didcomm"}]
+}
+
+func TestResolve0(t *testing.T) {
+	d := did.DID{Method: didpeer.Method, SpecID: "0z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"}
+	doc, _, err := didpeer.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(doc.VerificationMethods); n != 2 {
+		t.Fatalf("got %d verification methods, want Ed25519 and X25519", n)
+	}
+	if got, want := doc.KeyAgreement.URIRefs[0].Fragment(), "z6LSj72tK8brWgZja8NLRwPigth2T9QRiG1uH9oKZuKjdh9p"; got != want {
+		t.Errorf("got key agreement fragment %q, want %q", got, want)
+	}
+
+	perURI, notFound := doc.VerificationMethodRefs()
+	if len(notFound) != 0 {
+		t.Errorf("references not found: %s", notFound)
+	}
+	if len(perURI) != 5 {
+		t.Errorf("got %d references, want one for each relationship", len(perURI))
+	}
+}
+
+func TestNew2(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint, _ := url.Parse("https://example.com/endpoint")
+
+	d, err := didpeer.New2([]didpeer.Key{
+		{didpeer.Authentication, edKey},
+		{didpeer.AssertionMethod, edKey},
+		{didpeer.KeyAgreement, xKey.PublicKey()},
+	}, &did.Service{
+		Types:    []string{"DIDCommMessaging"},
+		Endpoint: did.ServiceEndpoint{URIRefs: []*url.URL{endpoint}},
+	}, &did.Service{
+		ID:       *must(url.Parse("#custom")),
+		Types:    []string{"LinkedDomains"},
+		Endpoint: did.ServiceEndpoint{URIRefs: []*url.URL{endpoint}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, _, err := didpeer.Resolve(d)
+	if err != nil {
+		t.Fatalf("%s: %s", d, err)
+	}
+	if n := len(doc.VerificationMethods); n != 3 {
+		t.Errorf("got %d verification methods, want 3", n)
+	}
+	if doc.Authentication == nil || doc.AssertionMethod == nil || doc.KeyAgreement == nil {
+		t.Error("relationship missing")
+	}
+	if doc.CapabilityInvocation != nil || doc.CapabilityDelegation != nil {
+		t.Error("relationship without keys present")
+	}
+	perURI, notFound := doc.VerificationMethodRefs()
+	if len(notFound) != 0 {
+		t.Errorf("references not found: %s", notFound)
+	}
+	if len(perURI) != 3 {
+		t.Errorf("got %d references, want 3", len(perURI))
+	}
+
+	if n := len(doc.Services); n != 2 {
+		t.Fatalf("got %d services, want 2", n)
+	}
+	if got := doc.Services[0].ID.String(); got != "#service" {
+		t.Errorf("got service ID %q, want default", got)
+	}
+	if got := doc.Services[1].ID.String(); got != "#custom" {
+		t.Errorf("got service ID %q, want #custom", got)
+	}
+	for _, srv := range doc.Services {
+		if len(srv.Endpoint.URIRefs) != 1 || srv.Endpoint.URIRefs[0].String() != endpoint.String() {
+			t.Errorf("service %s got endpoint %+v, want %s", srv.ID.String(), srv.Endpoint, endpoint)
+		}
+	}
+	if got := doc.Services[0].Types; len(got) != 1 || got[0] != "DIDCommMessaging" {
+		t.Errorf("got service types %q, want DIDCommMessaging", got)
+	}
+}
+
+func TestNew2Empty(t *testing.T) {
+	d, err := didpeer.New2(nil)
+	if err == nil {
+		t.Errorf("got DID %s for no keys nor services, want error", d)
+	}
+}
+
+func TestNew0(t *testing.T) {
+	key, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := didpeer.New0(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _, err := didpeer.Resolve(d)
+	if err != nil {
+		t.Fatalf("%s: %s", d, err)
+	}
+	if got := doc.VerificationMethods[0].ID.Fragment(); got != d.SpecID[1:] {
+		t.Errorf("got method fragment %q, want %q", got, d.SpecID[1:])
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []did.DID{
+		{Method: "key", SpecID: "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"},
+		{Method: didpeer.Method, SpecID: "1zQmZMygzYqNwU6Uhmewx5Xepf2VLp5S4HLSwwgf2aiKZuwa"},
+		{Method: didpeer.Method, SpecID: "0"},
+		{Method: didpeer.Method, SpecID: "2.X6Mkj3PUd1WjvaDhNZhhhXQdz5UnZXmS7ehtx8bsPpD47kKc"},
+		{Method: didpeer.Method, SpecID: "2.V"},
+		{Method: didpeer.Method, SpecID: "2.S!!"},
+	}
+	for _, d := range tests {
+		_, _, err := didpeer.Resolve(d)
+		if err == nil {
+			t.Errorf("%s got no error", d)
+		} else if !errors.Is(err, did.ErrInvalid) && !errors.Is(err, did.ErrMethodNotSupported) {
+			t.Errorf("%s got error %v, want did.ErrInvalid or did.ErrMethodNotSupported", d, err)
+		}
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestNew2ServiceEncoding(t *testing.T) {
+	endpoint := did.ServiceEndpoint{Maps: []json.RawMessage{
+		json.RawMessage(`{"uri": "https://example.com/?a=1&b=2", "accept": ["didcomm/v2"], "extra": {"t": "x", "type": "y"}}`),
+	}}
+	d, err := didpeer.New2(nil, &did.Service{Types: []string{"DIDCommMessaging"}, Endpoint: endpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := strings.Index(d.SpecID, ".S")
+	if i < 0 {
+		t.Fatalf("no service in %s", d)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(d.SpecID[i+2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"s":{"a":["didcomm/v2"],"extra":{"t":"x","type":"y"},"uri":"https://example.com/?a=1&b=2"},"t":"dm"}`
+	if string(raw) != want {
+		t.Errorf("got service encoding %s, want %s", raw, want)
+	}
+
+	doc, _, err := didpeer.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Services) != 1 || len(doc.Services[0].Endpoint.Maps) != 1 {
+		t.Fatalf("got services %+v, want one with an endpoint object", doc.Services)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(doc.Services[0].Endpoint.Maps[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["uri"] != "https://example.com/?a=1&b=2" {
+		t.Errorf("got endpoint URI %v", got["uri"])
+	}
+	if _, ok := got["accept"]; !ok {
+		t.Errorf("endpoint %v has no accept", got)
+	}
+	if extra := fmt.Sprint(got["extra"]); extra != "map[t:x type:y]" {
+		t.Errorf("got nested endpoint object %s, want keys as is", extra)
+	}
+}
@@ -0,0 +1,161 @@
+package didweb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pascaldekloe/did"
+)
+
+// Handler serves documents at the locations which the did:web method derives
+// [URL] from their respective identifiers. Multiple goroutines may invoke
+// methods on a Handler simultaneously.
+type Handler struct {
+	// Store provides the documents. Resolution failures map to HTTP status
+	// codes, with the standardised error code in a JSON body.
+	Store did.Resolver
+}
+
+// ServeHTTP implements the http.Handler interface. Conditional requests apply
+// to the ETag and the Last-Modified date from the document metadata.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "DID document is read-only", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+
+	if !strings.HasSuffix(r.URL.Path, "/did.json") {
+		writeError(w, http.StatusNotFound, did.ErrNotFound)
+		return
+	}
+	s, err := DID("https://" + r.Host + r.URL.EscapedPath())
+	if err != nil {
+		writeError(w, http.StatusNotFound, did.ErrNotFound)
+		return
+	}
+	d, err := did.Parse(s)
+	if err != nil {
+		writeError(w, http.StatusNotFound, did.ErrNotFound)
+		return
+	}
+
+	mediaType := negotiate(r.Header.Values("Accept"))
+	if mediaType == "" {
+		writeError(w, http.StatusNotAcceptable, did.ErrMediaType)
+		return
+	}
+
+	res := h.Store.ResolveContext(r.Context(), d, nil)
+	if err := res.Err(); err != nil {
+		switch res.Meta.Error {
+		case "notFound", "methodNotSupported":
+			writeError(w, http.StatusNotFound, err)
+		case "invalidDid":
+			writeError(w, http.StatusBadRequest, err)
+		case "representationNotSupported":
+			writeError(w, http.StatusNotAcceptable, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	var body []byte
+	if mediaType == did.JSONLD {
+		body, err = res.Document.MarshalJSONLD()
+	} else {
+		body, err = json.Marshal(res.Document)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("ETag", `"`+base64.RawURLEncoding.EncodeToString(sum[:16])+`"`)
+	var modTime time.Time // zero omits Last-Modified
+	if meta := res.DocumentMeta; meta != nil {
+		modTime = meta.Updated
+		if modTime.IsZero() {
+			modTime = meta.Created
+		}
+	}
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(body))
+}
+
+// WriteError sends the error code [did.ErrorCode] conform the resolution
+// metadata, which Client interprets.
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	body, _ := json.Marshal(&did.ResolutionMeta{Error: did.ErrorCode(err)})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// Negotiate returns the preferred media type, with the empty string for none.
+func negotiate(accept []string) string {
+	offers := [...]string{did.JSON, did.JSONLD, "application/json"}
+	if len(accept) == 0 {
+		return offers[0]
+	}
+
+	var best string
+	var bestQ float64
+	for _, offer := range offers {
+		q := acceptQ(accept, offer)
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// AcceptQ returns the quality value of the most specific media range which
+// matches mediaType, as per RFC 9110, subsection 12.5.1.
+func acceptQ(accept []string, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, header := range accept {
+		for _, mediaRange := range strings.Split(header, ",") {
+			params := strings.Split(mediaRange, ";")
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+
+			var s int
+			switch {
+			case name == mediaType:
+				s = 2
+			case name == mediaType[:strings.IndexByte(mediaType, '/')]+"/*":
+				s = 1
+			case name == "*/*":
+				s = 0
+			default:
+				continue
+			}
+			if s <= specificity {
+				continue
+			}
+
+			v := 1.0
+			for _, p := range params[1:] {
+				p = strings.TrimSpace(p)
+				if len(p) > 2 && (p[0] == 'q' || p[0] == 'Q') && p[1] == '=' {
+					f, err := strconv.ParseFloat(p[2:], 64)
+					if err == nil && f >= 0 && f <= 1 {
+						v = f
+					}
+				}
+			}
+			q, specificity = v, s
+		}
+	}
+	return q
+}
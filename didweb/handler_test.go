@@ -0,0 +1,239 @@
+package didweb_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/didweb"
+)
+
+// NewTestHandler serves a document for the DID of path on example.com only.
+func newTestHandler(path string) (*httptest.Server, did.DID) {
+	d := mustDID("https://example.com" + path)
+	updated := time.Date(2023, 7, 20, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewTLSServer(&didweb.Handler{
+		Store: did.Resolve(func(got did.DID) (*did.Document, *did.Meta, error) {
+			if !got.Equal(d) {
+				return nil, nil, did.ErrNotFound
+			}
+			return &did.Document{
+				Subject:  d,
+				Contexts: []json.RawMessage{json.RawMessage(`"https://w3id.org/security/multikey/v1"`)},
+			}, &did.Meta{Updated: updated}, nil
+		}),
+	})
+	return srv, d
+}
+
+// MustDID returns the DID value of a location.
+func mustDID(webURL string) did.DID {
+	s, err := didweb.DID(webURL)
+	if err != nil {
+		panic(err)
+	}
+	d, err := did.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewTestClient returns a client which connects to srv for any host. The
+// certificate of srv is valid for example.com.
+func newTestClient(srv *httptest.Server) *didweb.Client {
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return new(net.Dialer).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	return &didweb.Client{Client: http.Client{Transport: transport}}
+}
+
+func TestHandler(t *testing.T) {
+	srv, d := newTestHandler("/.well-known/did.json")
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	doc, meta, err := c.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Subject.Equal(d) {
+		t.Errorf("got subject %s, want %s", doc.Subject, d)
+	}
+	if want := time.Date(2023, 7, 20, 12, 0, 0, 0, time.UTC); !meta.Updated.Equal(want) {
+		t.Errorf("got updated %s, want %s", meta.Updated, want)
+	}
+
+	res := c.ResolveContext(context.Background(), did.DID{Method: "web", SpecID: d.SpecID + ":alice"}, nil)
+	if err := res.Err(); !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v for an unknown DID, want did.ErrNotFound", err)
+	}
+}
+
+func TestHandlerNegotiation(t *testing.T) {
+	srv, _ := newTestHandler("/users/alice/did.json")
+	defer srv.Close()
+
+	tests := []struct {
+		accept     string
+		wantStatus int
+		wantType   string
+	}{
+		{"", http.StatusOK, did.JSON},
+		{"*/*", http.StatusOK, did.JSON},
+		{"application/did+json, application/did+ld+json;q=0.7, application/json;q=0.1", http.StatusOK, did.JSON},
+		{"application/did+ld+json", http.StatusOK, "application/did+ld+json"},
+		{"application/did+json;q=0.5, application/did+ld+json", http.StatusOK, "application/did+ld+json"},
+		{"application/*;q=0.2, application/json", http.StatusOK, "application/json"},
+		{"text/html, application/did+json;q=0", http.StatusNotAcceptable, "application/json"},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/users/alice/did.json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		res, err := newTestClient(srv).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body map[string]any
+		err = json.NewDecoder(res.Body).Decode(&body)
+		res.Body.Close()
+		if err != nil {
+			t.Errorf("Accept %q got body error: %s", test.accept, err)
+		}
+
+		if res.StatusCode != test.wantStatus {
+			t.Errorf("Accept %q got status %d, want %d", test.accept, res.StatusCode, test.wantStatus)
+		}
+		if got := res.Header.Get("Content-Type"); got != test.wantType {
+			t.Errorf("Accept %q got content type %q, want %q", test.accept, got, test.wantType)
+		}
+		if _, ok := body["@context"]; ok != (test.wantType == did.JSONLD) {
+			t.Errorf("Accept %q got @context %t", test.accept, ok)
+		} else if ok && fmt.Sprint(body["@context"]) != "["+did.V1+" https://w3id.org/security/multikey/v1]" {
+			t.Errorf("Accept %q got @context %v", test.accept, body["@context"])
+		}
+	}
+}
+
+func TestHandlerConditional(t *testing.T) {
+	srv, _ := newTestHandler("/users/alice/did.json")
+	defer srv.Close()
+
+	res, err := newTestClient(srv).Get("https://example.com/users/alice/did.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	etag := res.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if got, want := res.Header.Get("Last-Modified"), "Thu, 20 Jul 2023 12:00:00 GMT"; got != want {
+		t.Errorf("got Last-Modified %q, want %q", got, want)
+	}
+
+	for _, header := range []string{"If-None-Match", "If-Modified-Since"} {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/users/alice/did.json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header == "If-None-Match" {
+			req.Header.Set(header, etag)
+		} else {
+			req.Header.Set(header, "Thu, 20 Jul 2023 12:00:00 GMT")
+		}
+
+		res, err := newTestClient(srv).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotModified {
+			t.Errorf("%s got status %d, want 304", header, res.StatusCode)
+		}
+	}
+}
+
+func TestHandlerMethod(t *testing.T) {
+	srv, _ := newTestHandler("/users/alice/did.json")
+	defer srv.Close()
+
+	res, err := newTestClient(srv).Post("https://example.com/users/alice/did.json", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST got status %d, want 405", res.StatusCode)
+	}
+}
+
+// NoMetaStore resolves any DID without document metadata.
+type noMetaStore struct{}
+
+func (noMetaStore) ResolveContext(ctx context.Context, d did.DID, opts *did.ResolutionOptions) *did.ResolutionResult {
+	return &did.ResolutionResult{
+		Meta:     did.ResolutionMeta{ContentType: did.JSON},
+		Document: &did.Document{Subject: d},
+	}
+}
+
+func TestHandlerNoMeta(t *testing.T) {
+	srv := httptest.NewTLSServer(&didweb.Handler{Store: noMetaStore{}})
+	defer srv.Close()
+
+	res, err := newTestClient(srv).Get("https://example.com/.well-known/did.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want 200", res.StatusCode)
+	}
+	if got := res.Header.Get("Last-Modified"); got != "" {
+		t.Errorf("got Last-Modified %q without metadata, want none", got)
+	}
+}
+
+func TestHandlerPaths(t *testing.T) {
+	srv := httptest.NewTLSServer(&didweb.Handler{Store: noMetaStore{}})
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/.well-known/did.json", http.StatusOK},
+		{"/a%3Ab/did.json", http.StatusOK},
+		{"/a/b/did.json", http.StatusOK},
+		{"/did.json", http.StatusNotFound},
+		{"/a%2Fb/did.json", http.StatusNotFound},
+		{"/a%2fb/did.json", http.StatusNotFound},
+		{"/a//did.json", http.StatusNotFound},
+		{"/a/did", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		res, err := newTestClient(srv).Get("https://example.com" + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != test.wantStatus {
+			t.Errorf("GET %s got status %d, want %d", test.path, res.StatusCode, test.wantStatus)
+		}
+	}
+}
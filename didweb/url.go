@@ -0,0 +1,168 @@
+package didweb
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pascaldekloe/did"
+)
+
+// Method is the DID method name.
+const Method = "web"
+
+// URL returns the HTTPS location of the document for a DID in string form,
+// which is either "/.well-known/did.json" on the host for domain-only
+// identifiers, or the path segments followed by "/did.json". Colons (':')
+// separate the path segments, and a percent-encoded colon ("%3A") separates
+// the host from an optional port number, conform the specification. The string
+// form is required, as DID values do not retain any of the percent-encoding.
+// Errors include did.ErrInvalid.
+func URL(s string) (string, error) {
+	d, err := did.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", did.ErrInvalid, err)
+	}
+	if d.Method != Method {
+		return "", fmt.Errorf("%w: method %q is not %q", did.ErrMethodNotSupported, d.Method, Method)
+	}
+
+	segs := strings.Split(s[len("did:"+Method+":"):], ":")
+	host, port := segs[0], ""
+	if i := strings.Index(strings.ToUpper(host), "%3A"); i >= 0 {
+		host, port = host[:i], host[i+len("%3A"):]
+		if !isPort(port) {
+			return "", fmt.Errorf("%w: did:web port %q", did.ErrInvalid, port)
+		}
+	}
+	for i, seg := range segs[1:] {
+		segs[i+1], err = url.PathUnescape(seg)
+		if err != nil {
+			return "", fmt.Errorf("%w: did:web path segment %q: %s", did.ErrInvalid, seg, err)
+		}
+	}
+	return location(host, port, segs[1:])
+}
+
+// URLOf returns the location for a DID value. The SpecID of a value has its
+// percent-encoding decoded, which makes the port separator ("%3A") a colon like
+// the path separators. The first segment after the host is read as the port
+// when it is a valid port number, conform the percent-encoded string form.
+func urlOf(d did.DID) (string, error) {
+	if d.Method != Method {
+		return "", fmt.Errorf("%w: method %q is not %q", did.ErrMethodNotSupported, d.Method, Method)
+	}
+	segs := strings.Split(d.SpecID, ":")
+	host, port := segs[0], ""
+	segs = segs[1:]
+	if len(segs) != 0 && isPort(segs[0]) {
+		port, segs = segs[0], segs[1:]
+	}
+	return location(host, port, segs)
+}
+
+// Location returns the HTTPS URL of the document.
+func location(host, port string, segs []string) (string, error) {
+	if host == "" || strings.ContainsAny(host, "/?#@%:[] ") {
+		return "", fmt.Errorf("%w: did:web host %q", did.ErrInvalid, host)
+	}
+
+	var b strings.Builder
+	b.WriteString("https://")
+	b.WriteString(host)
+	if port != "" {
+		b.WriteByte(':')
+		b.WriteString(port)
+	}
+	if len(segs) == 0 {
+		b.WriteString("/.well-known")
+	}
+	for _, seg := range segs {
+		if seg == "" {
+			return "", fmt.Errorf("%w: did:web has an empty path segment", did.ErrInvalid)
+		}
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(seg))
+	}
+	b.WriteString("/did.json")
+	return b.String(), nil
+}
+
+// DID returns the identifier, in string form, which resolves to the HTTPS
+// location of a document. This is the reverse of URL. The "/did.json" suffix is
+// optional, yet a bare "/did.json" has no identifier, as domain-only identifiers
+// reside in "/.well-known/did.json". Path segments with an encoded slash ("%2F")
+// are not supported. Any port is percent-encoded ("%3A"), conform the
+// specification.
+func DID(webURL string) (string, error) {
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("did:web needs an HTTPS URL with host and path only; got %q", webURL)
+	}
+
+	host := u.Hostname()
+	if strings.IndexByte(host, ':') >= 0 {
+		return "", fmt.Errorf("did:web IPv6 host of %q not supported", webURL)
+	}
+	var b strings.Builder
+	b.WriteString("did:" + Method + ":")
+	appendEscaped(&b, host)
+	if port := u.Port(); port != "" {
+		b.WriteString("%3A")
+		b.WriteString(port)
+	}
+
+	p := u.EscapedPath()
+	if strings.HasSuffix(p, "/did.json") {
+		p = p[:len(p)-len("/did.json")]
+		// “/.well-known/did.json” only for domain-only identifiers
+		if p == "" {
+			return "", fmt.Errorf("did:web has no document at %q", webURL)
+		}
+	} else {
+		p = strings.TrimSuffix(p, "/")
+	}
+	if p != "" && p != "/.well-known" {
+		for _, seg := range strings.Split(p[1:], "/") {
+			seg, err := url.PathUnescape(seg)
+			if err != nil {
+				return "", err
+			}
+			if seg == "" {
+				return "", fmt.Errorf("did:web empty path segment in %q not supported", webURL)
+			}
+			if strings.IndexByte(seg, '/') >= 0 {
+				return "", fmt.Errorf("did:web path segment %q of %q not supported", seg, webURL)
+			}
+			b.WriteByte(':')
+			appendEscaped(&b, seg)
+		}
+	}
+	return b.String(), nil
+}
+
+// AppendEscaped writes s with percent-encoding of any bytes other than the
+// DID idchar, colon included.
+func appendEscaped(b *strings.Builder, s string) {
+	const hexTable = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexTable[c>>4])
+			b.WriteByte(hexTable[c&15])
+		}
+	}
+}
+
+func isPort(s string) bool {
+	n, err := strconv.ParseUint(s, 10, 16)
+	return err == nil && n != 0 && s[0] != '0'
+}
@@ -0,0 +1,91 @@
+package didweb_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/didweb"
+)
+
+func ExampleURL() {
+	webURL, err := didweb.URL("did:web:example.com%3A8443:users:alice")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(webURL)
+	// Output: https://example.com:8443/users/alice/did.json
+}
+
+var GoldenURLs = []struct{ DID, URL string }{
+	{"did:web:w3c-ccg.github.io", "https://w3c-ccg.github.io/.well-known/did.json"},
+	{"did:web:w3c-ccg.github.io:user:alice", "https://w3c-ccg.github.io/user/alice/did.json"},
+	{"did:web:example.com%3A3000:user:alice", "https://example.com:3000/user/alice/did.json"},
+	{"did:web:example.com%3A3000", "https://example.com:3000/.well-known/did.json"},
+	{"did:web:example.com:2024", "https://example.com/2024/did.json"},
+	{"did:web:example.com:2024:alice", "https://example.com/2024/alice/did.json"},
+	{"did:web:example.com:users:%C3%A5sa", "https://example.com/users/%C3%A5sa/did.json"},
+	{"did:web:example.com:a%20b", "https://example.com/a%20b/did.json"},
+	{"did:web:example.com:a%3Ab", "https://example.com/a:b/did.json"},
+}
+
+func TestURL(t *testing.T) {
+	for _, gold := range GoldenURLs {
+		got, err := didweb.URL(gold.DID)
+		if err != nil {
+			t.Errorf("%s got error: %s", gold.DID, err)
+		} else if got != gold.URL {
+			t.Errorf("%s got %q, want %q", gold.DID, got, gold.URL)
+		}
+	}
+}
+
+func TestDID(t *testing.T) {
+	for _, gold := range GoldenURLs {
+		got, err := didweb.DID(gold.URL)
+		if err != nil {
+			t.Errorf("%s got error: %s", gold.URL, err)
+		} else if got != gold.DID {
+			t.Errorf("%s got %s, want %s", gold.URL, got, gold.DID)
+		}
+	}
+
+	for _, s := range []string{
+		"http://example.com/did.json",
+		"https://example.com/did.json?v=1",
+		"https://user@example.com/did.json",
+		"https://example.com//did.json",
+		"https://[::1]/did.json",
+		"https://example.com/did.json",
+		"https://example.com/a%2Fb/did.json",
+	} {
+		if got, err := didweb.DID(s); err == nil {
+			t.Errorf("%s got %s, want error", s, got)
+		}
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	tests := []struct {
+		d    string
+		want error
+	}{
+		{"did:key:example.com", did.ErrMethodNotSupported},
+		{"did:web:", did.ErrInvalid},
+		{"did:web::example.com", did.ErrInvalid},
+		{"did:web:example.com%2Fx", did.ErrInvalid},
+		{"did:web:example.com::x", did.ErrInvalid},
+		{"did:web:example.com%3A", did.ErrInvalid},
+		{"did:web:example.com%3A0", did.ErrInvalid},
+		{"did:web:example.com%3A99999", did.ErrInvalid},
+		{"did:web:example.com%3Ax:alice", did.ErrInvalid},
+	}
+	for _, test := range tests {
+		got, err := didweb.URL(test.d)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s got %q, error %v, want %v", test.d, got, err, test.want)
+		}
+	}
+}
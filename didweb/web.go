@@ -1,11 +1,21 @@
-// Package didweb provides standard HTTP connectivity.
+// Package didweb provides standard HTTP connectivity, including the “did:web”
+// method. See https://w3c-ccg.github.io/did-method-web/ for the specification.
+//
+// The did:web specification demands a percent-encoded colon ("%3A") as the port
+// separator, while colons (':') separate the path segments. DID values from the
+// did package decode the method-specific identifier, which makes both colons
+// equal. URL and DID work on the string form to keep them apart. Client.Resolve
+// works on DID values, and it reads a port number directly after the host name
+// as the port.
 package didweb
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 
 	"github.com/pascaldekloe/did"
@@ -27,18 +37,54 @@ type Client struct {
 	DownloadMax int
 }
 
-// Resolve fetches a document in a standard compliant manner.
-func (c *Client) Resolve(webURL string) (*did.Document, *did.Meta, error) {
-	req, err := http.NewRequest(http.MethodGet, webURL, nil)
+// Resolve conforms to the did.Resolve signature. The document is fetched from
+// the HTTPS location of d, with a port number directly after the host name as
+// the port, and any other segments as the path. Use URL on the string form of a
+// DID, followed by Fetch, for numeric path segments directly after the host.
+func (c *Client) Resolve(d did.DID) (*did.Document, *did.Meta, error) {
+	return c.resolve(context.Background(), d)
+}
+
+// ResolveContext implements the did.Resolver interface.
+func (c *Client) ResolveContext(ctx context.Context, d did.DID, opts *did.ResolutionOptions) *did.ResolutionResult {
+	return did.Resolve(func(d did.DID) (*did.Document, *did.Meta, error) {
+		return c.resolve(ctx, d)
+	}).Resolution(d, opts)
+}
+
+func (c *Client) resolve(ctx context.Context, d did.DID) (*did.Document, *did.Meta, error) {
+	webURL, err := urlOf(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, meta, err := c.Fetch(ctx, webURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	// “The id in the DID document MUST match the did:web DID being
+	// resolved.”
+	if !doc.Subject.Equal(d) {
+		return nil, nil, fmt.Errorf("DID document %s has id %s, want %s", webURL, doc.Subject, d)
+	}
+	return doc, meta, nil
+}
+
+// Fetch gets a document from a URL in a standard compliant manner. The request
+// is aborted once ctx is done. The JSON-LD constraints on "@context" apply to
+// responses with a did.JSONLD Content-Type only.
+func (c *Client) Fetch(ctx context.Context, webURL string) (*did.Document, *did.Meta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, webURL, nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("%w: %s", did.ErrNotFound, err)
 	}
 	req.Header.Set("Accept", "application/did+json, application/did+ld+json;q=0.7, application/json;q=0.1")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.Do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("DID document lookup: %w", err)
 	}
+	defer res.Body.Close()
+
 	switch res.StatusCode {
 	case http.StatusOK:
 		break
@@ -85,14 +131,31 @@ func (c *Client) Resolve(webURL string) (*did.Document, *did.Meta, error) {
 		N: int64(max),
 	}
 
-	var d did.Document
-	err = json.NewDecoder(&r).Decode(&d)
+	body, err := io.ReadAll(&r)
 	switch {
-	case err == nil:
-		return &d, &m, nil
+	case err != nil:
+		return nil, nil, fmt.Errorf("DID document %q unavailable: %w", webURL, err)
 	case r.N <= 0:
 		return nil, nil, fmt.Errorf("%w: %s reached %d bytes", ErrDownloadMax, webURL, max)
-	default:
+	}
+
+	var d did.Document
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == did.JSONLD {
+		err = d.UnmarshalJSONLD(body)
+	} else {
+		// JSON-LD may be served as JSON too, in which case
+		// the contexts are kept on a best-effort basis.
+		var head struct {
+			Context json.RawMessage `json:"@context"`
+		}
+		if json.Unmarshal(body, &head) != nil || head.Context == nil || d.UnmarshalJSONLD(body) != nil {
+			d = did.Document{}
+			err = json.Unmarshal(body, &d)
+		}
+	}
+	if err != nil {
 		return nil, nil, fmt.Errorf("DID document %q unavailable: %w", webURL, err)
 	}
+	return &d, &m, nil
 }
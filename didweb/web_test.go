@@ -1,19 +1,21 @@
 package didweb_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/pascaldekloe/did"
 	"github.com/pascaldekloe/did/didweb"
 )
 
 func ExampleClient_Resolve() {
-	doc, _, err := new(didweb.Client).Resolve("https://identity.foundation/.well-known/did.json")
+	doc, _, err := new(didweb.Client).Resolve(did.DID{Method: "web", SpecID: "identity.foundation"})
 	switch {
 	case err == nil:
 		fmt.Println("got DID", doc.Subject)
@@ -26,14 +28,14 @@ func ExampleClient_Resolve() {
 	// got DID did:web:identity.foundation
 }
 
-func TestHTTPNotFound(t *testing.T) {
+func TestFetchHTTPNotFound(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(404)
 		io.WriteString(w, "arbitrary")
 	}))
 	defer srv.Close()
 
-	_, _, err := new(didweb.Client).Resolve(srv.URL)
+	_, _, err := new(didweb.Client).Fetch(context.Background(), srv.URL)
 	if err == nil {
 		t.Fatal("no error on Resolve")
 	}
@@ -42,14 +44,14 @@ func TestHTTPNotFound(t *testing.T) {
 	}
 }
 
-func TestHTTPNotAcceptable(t *testing.T) {
+func TestFetchHTTPNotAcceptable(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(406)
 		io.WriteString(w, "arbitrary")
 	}))
 	defer srv.Close()
 
-	_, _, err := new(didweb.Client).Resolve(srv.URL)
+	_, _, err := new(didweb.Client).Fetch(context.Background(), srv.URL)
 	if err == nil {
 		t.Fatal("no error on Resolve")
 	}
@@ -58,14 +60,14 @@ func TestHTTPNotAcceptable(t *testing.T) {
 	}
 }
 
-func TestHTTPGone(t *testing.T) {
+func TestFetchHTTPGone(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(410)
 		io.WriteString(w, "arbitrary")
 	}))
 	defer srv.Close()
 
-	_, _, err := new(didweb.Client).Resolve(srv.URL)
+	_, _, err := new(didweb.Client).Fetch(context.Background(), srv.URL)
 	if err == nil {
 		t.Fatal("no error on Resolve")
 	}
@@ -75,14 +77,14 @@ func TestHTTPGone(t *testing.T) {
 	}
 }
 
-func TestJSONErrorCode(t *testing.T) {
+func TestFetchJSONErrorCode(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(400)
 		io.WriteString(w, `{"error": "invalidDid"}`)
 	}))
 	defer srv.Close()
 
-	_, _, err := new(didweb.Client).Resolve(srv.URL)
+	_, _, err := new(didweb.Client).Fetch(context.Background(), srv.URL)
 	if err == nil {
 		t.Fatal("no error on Resolve")
 	}
@@ -90,3 +92,116 @@ func TestJSONErrorCode(t *testing.T) {
 		t.Errorf("got error %v, want did.ErrInvalid", err)
 	}
 }
+
+func TestFetchContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := new(didweb.Client).Fetch(ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	d := mustDID("https://example.com/users/alice/did.json")
+	mux.HandleFunc("/users/alice/did.json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"id": %q}`, d)
+	})
+	mux.HandleFunc("/users/bob/did.json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"id": %q}`, d)
+	})
+
+	c := newTestClient(srv)
+	doc, _, err := c.Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Subject.Equal(d) {
+		t.Errorf("got subject %s, want %s", doc.Subject, d)
+	}
+
+	bob := did.DID{Method: d.Method, SpecID: d.SpecID[:len(d.SpecID)-len("alice")] + "bob"}
+	res := c.ResolveContext(context.Background(), bob, nil)
+	if err := res.Err(); err == nil {
+		t.Error("got no error for a document with another id")
+	}
+	res = c.ResolveContext(context.Background(), did.DID{Method: "web", SpecID: d.SpecID + ":carol"}, nil)
+	if err := res.Err(); !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v, want did.ErrNotFound", err)
+	}
+}
+
+func TestResolvePort(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "example.com:8443" || r.URL.Path != "/users/alice/did.json" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"id": "did:web:example.com%3A8443:users:alice"}`)
+	}))
+	defer srv.Close()
+
+	d, err := did.Parse("did:web:example.com%3A8443:users:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _, err := newTestClient(srv).Resolve(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Subject.Equal(d) {
+		t.Errorf("got subject %s, want %s", doc.Subject, d)
+	}
+}
+
+func TestFetchJSONLD(t *testing.T) {
+	const multikeyV1 = "https://w3id.org/security/multikey/v1"
+	tests := []struct {
+		contentType string
+		body        string
+		wantErr     bool
+		wantCtx     bool // multikeyV1 in Contexts
+	}{
+		{did.JSONLD, `{"@context": [%q, %q], "id": "did:example:123"}`, false, true},
+		{did.JSONLD, `{"@context": [%[2]q, %[1]q], "id": "did:example:123"}`, true, false},
+		{did.JSON, `{"@context": [%q, %q], "id": "did:example:123"}`, false, true},
+		{"application/json", `{"@context": [%q, %q], "id": "did:example:123"}`, false, true},
+		// no JSON-LD constraints on JSON
+		{did.JSON, `{"@context": [%[2]q, %[1]q], "id": "did:example:123"}`, false, false},
+		{did.JSON, `{"@context": ["https://www.w3.org/ns/did/v1.1", %[2]q], "id": "did:example:123"}`, false, false},
+	}
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", test.contentType)
+			fmt.Fprintf(w, test.body, did.V1, multikeyV1)
+		}))
+
+		doc, _, err := new(didweb.Client).Fetch(context.Background(), srv.URL)
+		srv.Close()
+		switch {
+		case test.wantErr:
+			if err == nil {
+				t.Errorf("%s %s: got no error", test.contentType, test.body)
+			}
+		case err != nil:
+			t.Errorf("%s %s: got error: %s", test.contentType, test.body, err)
+		case !test.wantCtx:
+			if len(doc.Contexts) != 0 {
+				t.Errorf("%s %s: got contexts %q, want none", test.contentType, test.body, doc.Contexts)
+			}
+		case len(doc.Contexts) != 1 || string(doc.Contexts[0]) != `"`+multikeyV1+`"`:
+			t.Errorf("%s %s: got contexts %q, want %q only", test.contentType, test.body, doc.Contexts, multikeyV1)
+		}
+	}
+}
@@ -1,8 +1,10 @@
-// JSON-LD is omitted by design. According to the standard: “A remote context
-// may also be referenced using a relative URL, which is resolved relative to
-// the location of the document containing the reference.”. On top of that,
-// “JSON documents can be interpreted as JSON-LD without having to be modified
-// by referencing a context via an HTTP Link Header …”.
+// JSON-LD processing is omitted by design. According to the standard: “A remote
+// context may also be referenced using a relative URL, which is resolved
+// relative to the location of the document containing the reference.”. On top
+// of that, “JSON documents can be interpreted as JSON-LD without having to be
+// modified by referencing a context via an HTTP Link Header …”. The JSON-LD
+// representation is supported in as far as the "@context" property goes
+// [Document.MarshalJSONLD].
 package did
 
 import (
@@ -11,7 +13,8 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
-	"time"
+
+	"github.com/pascaldekloe/did/internal/sorted"
 )
 
 // V1 is the (W3C) namespace URI.
@@ -20,10 +23,23 @@ const V1 = "https://www.w3.org/ns/did/v1"
 // JSON is the (MIME) media type for JSON document production and consumption.
 const JSON = "application/did+json"
 
+// JSONLD is the (MIME) media type for JSON-LD document production and
+// consumption.
+const JSONLD = "application/did+ld+json"
+
+// CBOR is the (MIME) media type for CBOR document production and consumption.
+const CBOR = "application/did+cbor"
+
 // Document holds the “core properties” of a DID association [Subject].
 type Document struct {
 	Subject DID `json:"id"` // required
 
+	// Contexts has any JSON-LD contexts in addition to V1, each either a
+	// JSON string with a URL, or a JSON object with an embedded context.
+	// The JSON-LD representation has V1 first, followed by Contexts
+	// [MarshalJSONLD]. The JSON representation has no contexts.
+	Contexts []json.RawMessage `json:"-"`
+
 	AlsoKnownAs []string `json:"alsoKnownAs,omitempty"`
 	Controllers Set      `json:"controller,omitempty"`
 
@@ -58,6 +74,112 @@ type Document struct {
 	// Services are used to express ways of communicating with the Subject
 	// or associated entities.
 	Services []*Service `json:"service,omitempty"`
+
+	// A DID document MAY include additional properties. The "@context"
+	// is not one of them [Contexts].
+	Additional map[string]json.RawMessage `json:"-"`
+}
+
+// CoreDocumentProperty returns whether name is reserved, i.e., not permitted in
+// Document.Additional.
+func coreDocumentProperty(name string) bool {
+	switch name {
+	case "@context", "id", "alsoKnownAs", "controller", "verificationMethod",
+		Authentication, AssertionMethod, KeyAgreement,
+		CapabilityInvocation, CapabilityDelegation, "service":
+		return true
+	}
+	return false
+}
+
+// AdditionalString returns the value if, and only if the property is present,
+// and its value is a valid JSON string.
+func (doc *Document) AdditionalString(property string) string {
+	raw, ok := doc.Additional[property]
+	if !ok {
+		return ""
+	}
+	var s string
+	err := json.Unmarshal([]byte(raw), &s)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (doc Document) MarshalJSON() ([]byte, error) {
+	type core Document // without methods
+	buf, err := json.Marshal(core(doc))
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Additional) == 0 {
+		return buf, nil
+	}
+
+	buf = buf[:len(buf)-1] // trim '}'
+	for _, property := range sorted.Keys(doc.Additional) {
+		if coreDocumentProperty(property) {
+			return nil, fmt.Errorf(`core DID document property %q in additional set`, property)
+		}
+
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, property)
+		buf = append(buf, ':')
+		buf = append(buf, doc.Additional[property]...)
+	}
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Any "@context" is
+// ignored, as it belongs to the JSON-LD representation [UnmarshalJSONLD].
+func (doc *Document) UnmarshalJSON(bytes []byte) error {
+	// reset
+	*doc = Document{}
+
+	// Read all properties as Additional first.
+	err := json.Unmarshal(bytes, &doc.Additional)
+	if err != nil {
+		return err
+	}
+	delete(doc.Additional, "@context")
+
+	// Second, extract the core from Additional.
+	core := [...]struct {
+		name    string
+		pointer any
+	}{
+		{"id", &doc.Subject},
+		{"alsoKnownAs", &doc.AlsoKnownAs},
+		{"controller", &doc.Controllers},
+		{"verificationMethod", &doc.VerificationMethods},
+		{Authentication, &doc.Authentication},
+		{AssertionMethod, &doc.AssertionMethod},
+		{KeyAgreement, &doc.KeyAgreement},
+		{CapabilityInvocation, &doc.CapabilityInvocation},
+		{CapabilityDelegation, &doc.CapabilityDelegation},
+		{"service", &doc.Services},
+	}
+	for _, property := range core {
+		raw, ok := doc.Additional[property.name]
+		if !ok {
+			continue
+		}
+		delete(doc.Additional, property.name)
+
+		err := json.Unmarshal([]byte(raw), property.pointer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(doc.Additional) == 0 {
+		doc.Additional = nil
+	}
+	return nil
 }
 
 // VerificationMethodRefs returns each VerificationRelationship.URIRefs pointer
@@ -204,7 +326,7 @@ func (r VerificationRelationship) MarshalJSON() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		buf = bytes
+		buf = bytes[:len(bytes)-1] // trim ']'
 	}
 
 	// URL refererences as JSON strings into array
@@ -311,7 +433,8 @@ func (m *VerificationMethod) MarshalJSON() ([]byte, error) {
 	buf = append(buf, `,"controller":`...)
 	buf = strconv.AppendQuote(buf, m.Controller.String())
 
-	for property, value := range m.Additional {
+	for _, property := range sorted.Keys(m.Additional) {
+		value := m.Additional[property]
 		switch property {
 		case "id", "type", "controller":
 			return nil, fmt.Errorf(`core DID verification-method property %q in additional set`, property)
@@ -424,7 +547,8 @@ func (srv *Service) MarshalJSON() ([]byte, error) {
 	}
 	buf = append(buf, bytes...)
 
-	for property, value := range srv.Additional {
+	for _, property := range sorted.Keys(srv.Additional) {
+		value := srv.Additional[property]
 		switch property {
 		case "id", "type", "serviceEndpoint":
 			return nil, fmt.Errorf(`core DID service property %q in additional set`, property)
@@ -644,23 +768,62 @@ var (
 	// accept input metadata property is not supported by the DID method
 	// and/or DID resolver implementation.”
 	ErrMediaType = errors.New("DID document media type not supported")
+
+	// “The DID URL supplied to the DID URL dereferencing function does not
+	// conform to valid syntax.”
+	ErrInvalidURL = errors.New("invalid DID URL")
+
+	// “This error code is returned if the DID method is not supported by
+	// the DID resolver.”
+	ErrMethodNotSupported = errors.New("DID method not supported")
 )
 
+// ErrorCode returns the standardised error code for err. The empty string is
+// returned for nil, and "internalError" is returned for any unknown error.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInvalid):
+		return "invalidDid"
+	case errors.Is(err, ErrNotFound):
+		return "notFound"
+	case errors.Is(err, ErrMediaType):
+		return "representationNotSupported"
+	case errors.Is(err, ErrInvalidURL):
+		return "invalidDidUrl"
+	case errors.Is(err, ErrMethodNotSupported):
+		return "methodNotSupported"
+	default:
+		return "internalError"
+	}
+}
+
+// ErrorForCode returns the error for a standardised error code, if any.
+func errorForCode(code string) error {
+	switch code {
+	case "":
+		return nil
+	case "invalidDid":
+		return ErrInvalid
+	case "notFound":
+		return ErrNotFound
+	case "representationNotSupported":
+		return ErrMediaType
+	case "invalidDidUrl":
+		return ErrInvalidURL
+	case "methodNotSupported":
+		return ErrMethodNotSupported
+	default:
+		return fmt.Errorf("DID resolution error code %q", code)
+	}
+}
+
 // Resolve a DID into a Document by using the “Read” operation of the DID
 // Method.
 //
 // Implementations should return ErrInvalid when encountering an "invalidDid"
 // error code, or ErrNotFound on the "notFound" code, or ErrMediaType on the
-// "representationNotSupported" code.
+// "representationNotSupported" code, or ErrMethodNotSupported on the
+// "methodNotSupported" code.
 type Resolve func(DID) (*Document, *Meta, error)
-
-// Meta describes a Document. Note that all properties are optional.
-type Meta struct {
-	Created       time.Time `json:"created,omitempty"`
-	Updated       time.Time `json:"updated,omitempty"`
-	Deactivated   time.Time `json:"deactivated,omitempty"`
-	NextUpdate    time.Time `json:"nextUpdate,omitempty"`
-	NextVersionID string    `json:"nextVersionId,omitempty"`
-	EquivalentIDs []DID     `json:"equivalentId,omitempty"`
-	CanonicalID   *DID      `json:"canonicalId,omitempty"`
-}
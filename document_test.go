@@ -237,14 +237,10 @@ func TestVerificationMethodMarshalJSON(t *testing.T) {
 		t.Fatal("sample preparation:", err)
 	}
 
-	var doc struct {
-		Context []string `json:"@context"`
-		did.Document
-	}
-	doc.Context = []string{
-		"https://www.w3.org/ns/did/v1",
-		"https://w3id.org/security/suites/jws-2020/v1",
-		"https://w3id.org/security/suites/ed25519-2020/v1",
+	var doc did.Document
+	doc.Contexts = []json.RawMessage{
+		json.RawMessage(`"https://w3id.org/security/suites/jws-2020/v1"`),
+		json.RawMessage(`"https://w3id.org/security/suites/ed25519-2020/v1"`),
 	}
 	doc.Subject = did.DID{Method: "example", SpecID: "123456789abcdefghi"}
 	doc.VerificationMethods = []*did.VerificationMethod{
@@ -276,7 +272,7 @@ func TestVerificationMethodMarshalJSON(t *testing.T) {
 		},
 	}
 
-	got, err := json.Marshal(doc)
+	got, err := doc.MarshalJSONLD()
 	if err != nil {
 		t.Fatal("DID document encoding error:", err)
 	}
@@ -339,3 +335,94 @@ func TestServiceEndpointUnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestVerificationRelationshipMarshalJSON(t *testing.T) {
+	var doc did.Document
+	err := json.Unmarshal([]byte(example15), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.Marshal(doc.Authentication)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// embedded methods go first
+	const want = `[{"id":"did:example:123456789abcdefghi#keys-2","type":"Ed25519VerificationKey2020","controller":"did:example:123456789abcdefghi","publicKeyMultibase":"zH3C2AVvLMv6gmMNam3uVAjZpfkcJCwDwnZn6z3wXmqPV"},"did:example:123456789abcdefghi#keys-1"]`
+	if string(got) != want {
+		t.Errorf("got:  %s", got)
+		t.Errorf("want: %s", want)
+	}
+}
+
+// Embedded methods and references combine into one JSON array.
+func TestVerificationRelationshipMarshalJSONMixed(t *testing.T) {
+	embedded := &did.VerificationMethod{
+		ID:         did.URL{RawFragment: "#key-1"},
+		Type:       did.MultikeyType,
+		Controller: did.DID{Method: "example", SpecID: "123"},
+	}
+	const embeddedJSON = `{"id":"#key-1","type":"Multikey","controller":"did:example:123"}`
+	ref := &did.URL{RawFragment: "#key-2"}
+
+	tests := []struct {
+		r    did.VerificationRelationship
+		want string
+	}{
+		{did.VerificationRelationship{}, `null`},
+		{did.VerificationRelationship{Methods: []*did.VerificationMethod{embedded}}, `[` + embeddedJSON + `]`},
+		{did.VerificationRelationship{URIRefs: []*did.URL{ref}}, `["#key-2"]`},
+		{did.VerificationRelationship{Methods: []*did.VerificationMethod{embedded}, URIRefs: []*did.URL{ref}},
+			`[` + embeddedJSON + `,"#key-2"]`},
+		{did.VerificationRelationship{Methods: []*did.VerificationMethod{embedded, embedded}, URIRefs: []*did.URL{ref, ref}},
+			`[` + embeddedJSON + `,` + embeddedJSON + `,"#key-2","#key-2"]`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.r)
+		if err != nil {
+			t.Errorf("%+v got error: %s", test.r, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("got JSON %s, want %s", got, test.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("got invalid JSON %s", got)
+		}
+	}
+}
+
+func TestDocumentAdditionalJSON(t *testing.T) {
+	const sample = `{"@context":"https://www.w3.org/ns/did/v1","id":"did:example:123","service":[],"zeta":{"nested":[1,2]},"alpha":"a","Id":"case differs"}`
+	var doc did.Document
+	err := json.Unmarshal([]byte(sample), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Subject.String(); got != "did:example:123" {
+		t.Errorf("got subject %q, want did:example:123", got)
+	}
+	if got := doc.AdditionalString("alpha"); got != "a" {
+		t.Errorf(`got additional "alpha" %q, want "a"`, got)
+	}
+	if len(doc.Additional) != 3 {
+		t.Errorf("got additional %q, want alpha, zeta and Id only", doc.Additional)
+	}
+
+	got, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"id":"did:example:123","Id":"case differs","alpha":"a","zeta":{"nested":[1,2]}}`
+	if string(got) != want {
+		t.Errorf("got JSON %s\nwant %s", got, want)
+	}
+
+	for _, property := range []string{"id", "service", "authentication", "@context"} {
+		doc.Additional = map[string]json.RawMessage{property: json.RawMessage(`null`)}
+		_, err := json.Marshal(&doc)
+		if err == nil {
+			t.Errorf("core property %q in additional set got no error", property)
+		}
+	}
+}
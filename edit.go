@@ -0,0 +1,239 @@
+package did
+
+import "fmt"
+
+// RelationshipField returns the field of a purpose, or nil for unknown purposes.
+func (doc *Document) relationshipField(purpose string) **VerificationRelationship {
+	switch purpose {
+	case Authentication:
+		return &doc.Authentication
+	case AssertionMethod:
+		return &doc.AssertionMethod
+	case KeyAgreement:
+		return &doc.KeyAgreement
+	case CapabilityInvocation:
+		return &doc.CapabilityInvocation
+	case CapabilityDelegation:
+		return &doc.CapabilityDelegation
+	default:
+		return nil
+	}
+}
+
+// RelationshipFields returns each verification relationship field in order of
+// appearance.
+func (doc *Document) relationshipFields() [5]**VerificationRelationship {
+	return [...]**VerificationRelationship{
+		&doc.Authentication,
+		&doc.AssertionMethod,
+		&doc.KeyAgreement,
+		&doc.CapabilityInvocation,
+		&doc.CapabilityDelegation,
+	}
+}
+
+// AddVerificationMethod appends m to VerificationMethods, with a reference in
+// the verification relationship of each purpose. Relationships absent are
+// created as needed. Identifiers already in use, embedded ones included, cause
+// an ErrDuplicateID, in which case doc remains unchanged.
+func (doc *Document) AddVerificationMethod(m *VerificationMethod, purposes ...string) error {
+	for _, purpose := range purposes {
+		if doc.relationshipField(purpose) == nil {
+			return fmt.Errorf("unknown DID verification purpose %q", purpose)
+		}
+	}
+	if doc.verificationMethod(doc.resolve(&m.ID)) != nil {
+		return fmt.Errorf("%w: verification method %q", ErrDuplicateID, m.ID.String())
+	}
+
+	doc.VerificationMethods = append(doc.VerificationMethods, m)
+	for _, purpose := range purposes {
+		field := doc.relationshipField(purpose)
+		if *field == nil {
+			*field = new(VerificationRelationship)
+		}
+		ref := m.ID // copy
+		(*field).URIRefs = append((*field).URIRefs, &ref)
+	}
+	return nil
+}
+
+// RemoveVerificationMethod deletes the verification method identified by id,
+// embedded ones included, together with all of its references. Relationships
+// which become empty are set to nil. Relative URLs resolve against the Subject.
+// ErrNotFound is returned when doc has neither the method nor any reference.
+// Slices and relationships with a removal are replaced rather than modified,
+// such that any other documents which share them remain unaffected.
+func (doc *Document) RemoveVerificationMethod(id *URL) error {
+	id = doc.resolve(id)
+	matchMethod := func(m *VerificationMethod) bool {
+		return doc.resolve(&m.ID).Equal(id)
+	}
+	matchRef := func(u *URL) bool {
+		return doc.resolve(u).Equal(id)
+	}
+
+	methods, found := without(doc.VerificationMethods, matchMethod)
+	if found {
+		doc.VerificationMethods = methods
+	}
+
+	for _, field := range doc.relationshipFields() {
+		r := *field
+		if r == nil {
+			continue
+		}
+		methods, methodFound := without(r.Methods, matchMethod)
+		refs, refFound := without(r.URIRefs, matchRef)
+		if !methodFound && !refFound {
+			continue
+		}
+		found = true
+
+		if len(methods) == 0 && len(refs) == 0 {
+			*field = nil
+		} else {
+			*field = &VerificationRelationship{Methods: methods, URIRefs: refs}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: verification method %q not in DID document", ErrNotFound, id.String())
+	}
+	return nil
+}
+
+// RotateKey replaces the verification method identified by old with m, at the
+// same position, embedded or not. Any references to old are updated to the
+// identifier of m, such that m keeps each verification relationship of its
+// predecessor. Relative URLs resolve against the Subject. ErrNotFound is
+// returned when old is not in doc, and ErrDuplicateID when m has an identifier
+// in use by another verification method. Slices and relationships with a
+// replacement are copied rather than modified, like RemoveVerificationMethod.
+func (doc *Document) RotateKey(old *URL, m *VerificationMethod) error {
+	old = doc.resolve(old)
+	current := doc.verificationMethod(old)
+	if current == nil {
+		return fmt.Errorf("%w: verification method %q not in DID document", ErrNotFound, old.String())
+	}
+	newID := doc.resolve(&m.ID)
+	if other := doc.verificationMethod(newID); other != nil && other != current {
+		return fmt.Errorf("%w: verification method %q", ErrDuplicateID, m.ID.String())
+	}
+
+	replaceMethod := func(p *VerificationMethod) *VerificationMethod {
+		if p == current {
+			return m
+		}
+		return nil
+	}
+	replaceRef := func(u *URL) *URL {
+		if doc.resolve(u).Equal(old) {
+			ref := m.ID // copy
+			return &ref
+		}
+		return nil
+	}
+
+	if methods, ok := replaced(doc.VerificationMethods, replaceMethod); ok {
+		doc.VerificationMethods = methods
+	}
+	for _, field := range doc.relationshipFields() {
+		r := *field
+		if r == nil {
+			continue
+		}
+		methods, methodOK := replaced(r.Methods, replaceMethod)
+		refs, refOK := replaced(r.URIRefs, replaceRef)
+		if methodOK || refOK {
+			*field = &VerificationRelationship{Methods: methods, URIRefs: refs}
+		}
+	}
+	return nil
+}
+
+// AddService appends srv to Services. Identifiers already in use cause an
+// ErrDuplicateID, in which case doc remains unchanged.
+func (doc *Document) AddService(srv *Service) error {
+	if u, err := ParseURL(srv.ID.String()); err == nil && doc.service(doc.resolve(u)) != nil {
+		return fmt.Errorf("%w: service %q", ErrDuplicateID, srv.ID.String())
+	}
+	for _, p := range doc.Services {
+		if p != nil && p.ID == srv.ID {
+			return fmt.Errorf("%w: service %q", ErrDuplicateID, srv.ID.String())
+		}
+	}
+
+	doc.Services = append(doc.Services, srv)
+	return nil
+}
+
+// RemoveService deletes the service identified by id. Relative URLs resolve
+// against the Subject. ErrNotFound is returned when doc has no such service.
+// Services is replaced rather than modified, like RemoveVerificationMethod.
+func (doc *Document) RemoveService(id *URL) error {
+	id = doc.resolve(id)
+	services, found := without(doc.Services, func(srv *Service) bool {
+		u, err := ParseURL(srv.ID.String())
+		return err == nil && doc.resolve(u).Equal(id)
+	})
+	if !found {
+		return fmt.Errorf("%w: service %q not in DID document", ErrNotFound, id.String())
+	}
+	doc.Services = services
+	return nil
+}
+
+// Without returns a new slice with the entries of a which do not match, and
+// whether any did match. The slice is nil when empty. The original is returned
+// when nothing matched. Nil entries are kept, without any match attempt.
+func without[T any](a []*T, match func(*T) bool) ([]*T, bool) {
+	var kept []*T
+	var found bool
+	for i, p := range a {
+		if p != nil && match(p) {
+			if !found {
+				found = true
+				kept = make([]*T, i, len(a)-1)
+				copy(kept, a[:i])
+			}
+			continue
+		}
+		if found {
+			kept = append(kept, p)
+		}
+	}
+	if !found {
+		return a, false
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	return kept, true
+}
+
+// Replaced returns a new slice with each entry of a substituted by the return
+// of replace, if not nil, and whether any such substitution took place. The
+// original is returned when nothing was substituted. Nil entries are kept,
+// without any replace attempt.
+func replaced[T any](a []*T, replace func(*T) *T) ([]*T, bool) {
+	var c []*T
+	for i, p := range a {
+		if p == nil {
+			continue
+		}
+		r := replace(p)
+		if r == nil {
+			continue
+		}
+		if c == nil {
+			c = make([]*T, len(a))
+			copy(c, a)
+		}
+		c[i] = r
+	}
+	if c == nil {
+		return a, false
+	}
+	return c, true
+}
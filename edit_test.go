@@ -0,0 +1,279 @@
+package did_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func mustURL(t testing.TB, s string) *did.URL {
+	u, err := did.ParseURL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func ExampleDocument_RotateKey() {
+	var doc did.Document
+	err := json.Unmarshal([]byte(example9), &doc)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = doc.RotateKey(&did.URL{RawFragment: "#key-1"}, &did.VerificationMethod{
+		ID:         did.URL{RawFragment: "#key-2"},
+		Type:       did.MultikeyType,
+		Controller: doc.Subject,
+		Additional: map[string]json.RawMessage{
+			"publicKeyMultibase": json.RawMessage(`"z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"`),
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	out, err := json.Marshal(&doc)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", out)
+	// Output:
+	// {"id":"did:example:123456789abcdefghi","verificationMethod":[{"id":"#key-2","type":"Multikey","controller":"did:example:123456789abcdefghi","publicKeyMultibase":"z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"}],"authentication":["#key-2"]}
+}
+
+func TestAddRemoveVerificationMethod(t *testing.T) {
+	doc := did.Document{Subject: did.DID{Method: "example", SpecID: "123"}}
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: doc.Subject, RawFragment: "#key-1"},
+		Type:       did.MultikeyType,
+		Controller: doc.Subject,
+	}
+	err := doc.AddVerificationMethod(m, did.Authentication, did.AssertionMethod)
+	if err != nil {
+		t.Fatal("add error:", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("invalid after add:", err)
+	}
+	if got, err := doc.MethodFor(did.AssertionMethod, &did.URL{RawFragment: "#key-1"}); err != nil || got != m {
+		t.Errorf("got method %p, error %v, want %p", got, err, m)
+	}
+
+	dupe := *m
+	err = doc.AddVerificationMethod(&dupe, did.KeyAgreement)
+	if !errors.Is(err, did.ErrDuplicateID) {
+		t.Errorf("got error %v for duplicate add, want a did.ErrDuplicateID", err)
+	}
+	if doc.KeyAgreement != nil || len(doc.VerificationMethods) != 1 {
+		t.Error("document changed on duplicate add")
+	}
+	if err := doc.AddVerificationMethod(&dupe, "unknown"); err == nil {
+		t.Error("no error for unknown purpose")
+	}
+
+	// an embedded method and an external reference with the same fragment
+	doc.CapabilityInvocation = &did.VerificationRelationship{
+		Methods: []*did.VerificationMethod{{ID: did.URL{RawFragment: "#key-1"}, Type: did.MultikeyType, Controller: doc.Subject}},
+		URIRefs: []*did.URL{mustURL(t, "did:example:456#key-1")},
+	}
+
+	err = doc.RemoveVerificationMethod(&did.URL{RawFragment: "#key-1"})
+	if err != nil {
+		t.Fatal("remove error:", err)
+	}
+	if len(doc.VerificationMethods) != 0 || doc.Authentication != nil || doc.AssertionMethod != nil {
+		t.Error("method remains after remove")
+	}
+	if r := doc.CapabilityInvocation; r == nil || len(r.Methods) != 0 || len(r.URIRefs) != 1 {
+		t.Errorf("got capability invocation %+v, want the external reference only", r)
+	}
+
+	err = doc.RemoveVerificationMethod(&did.URL{RawFragment: "#key-1"})
+	if !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v for second remove, want a did.ErrNotFound", err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	var doc did.Document
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123",
+		"verificationMethod": [
+			{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"},
+			{"id": "#key-2", "type": "Multikey", "controller": "did:example:123"}
+		],
+		"authentication": ["did:example:123#key-1", "#key-2"],
+		"keyAgreement": [{"id": "#key-3", "type": "Multikey", "controller": "did:example:123"}],
+		"capabilityInvocation": ["#key-1"]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = doc.RotateKey(mustURL(t, "did:example:123#key-1"), &did.VerificationMethod{
+		ID: did.URL{RawFragment: "#key-2"}, Type: did.MultikeyType, Controller: doc.Subject,
+	})
+	if !errors.Is(err, did.ErrDuplicateID) {
+		t.Errorf("got error %v for rotate into existing ID, want a did.ErrDuplicateID", err)
+	}
+	err = doc.RotateKey(&did.URL{RawFragment: "#key-9"}, &did.VerificationMethod{
+		ID: did.URL{RawFragment: "#key-10"}, Type: did.MultikeyType, Controller: doc.Subject,
+	})
+	if !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v for rotate of absent key, want a did.ErrNotFound", err)
+	}
+
+	for _, rotation := range []struct{ old, new string }{{"#key-1", "#key-4"}, {"#key-3", "#key-5"}, {"#key-2", "#key-2"}} {
+		err := doc.RotateKey(mustURL(t, rotation.old), &did.VerificationMethod{
+			ID: *mustURL(t, rotation.new), Type: "JsonWebKey2020", Controller: doc.Subject,
+		})
+		if err != nil {
+			t.Errorf("rotate %s to %s: %s", rotation.old, rotation.new, err)
+		}
+	}
+	if err := doc.Validate(); err != nil {
+		t.Error("invalid after rotation:", err)
+	}
+
+	out, err := json.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"id":"did:example:123","verificationMethod":[{"id":"#key-4","type":"JsonWebKey2020","controller":"did:example:123"},{"id":"#key-2","type":"JsonWebKey2020","controller":"did:example:123"}],"authentication":["#key-4","#key-2"],"keyAgreement":[{"id":"#key-5","type":"JsonWebKey2020","controller":"did:example:123"}],"capabilityInvocation":["#key-4"]}`
+	if string(out) != want {
+		t.Errorf("got JSON %s\nwant %s", out, want)
+	}
+}
+
+func TestAddRemoveService(t *testing.T) {
+	var doc did.Document
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123",
+		"service": [{"id": "#hub", "type": "Hub", "serviceEndpoint": "https://example.com/hub"}]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var srv did.Service
+	err = json.Unmarshal([]byte(`{"id": "did:example:123#hub", "type": "Hub", "serviceEndpoint": "https://example.com/other"}`), &srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.AddService(&srv); !errors.Is(err, did.ErrDuplicateID) {
+		t.Errorf("got error %v for duplicate service, want a did.ErrDuplicateID", err)
+	}
+
+	srv.ID.Fragment = "linked"
+	if err := doc.AddService(&srv); err != nil {
+		t.Fatal("add error:", err)
+	}
+	if len(doc.Services) != 2 {
+		t.Fatalf("got %d services, want 2", len(doc.Services))
+	}
+
+	if err := doc.RemoveService(&did.URL{RawFragment: "#hub"}); err != nil {
+		t.Fatal("remove error:", err)
+	}
+	if len(doc.Services) != 1 || doc.Services[0] != &srv {
+		t.Errorf("got services %v, want the added one only", doc.Services)
+	}
+	if err := doc.RemoveService(&did.URL{RawFragment: "#hub"}); !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v for second remove, want a did.ErrNotFound", err)
+	}
+}
+
+func TestEditNilEntries(t *testing.T) {
+	key1 := &did.VerificationMethod{ID: did.URL{RawFragment: "#key-1"}, Type: did.MultikeyType}
+	doc := did.Document{
+		Subject:             did.DID{Method: "example", SpecID: "123"},
+		VerificationMethods: []*did.VerificationMethod{nil, key1},
+		Authentication: &did.VerificationRelationship{
+			Methods: []*did.VerificationMethod{nil},
+			URIRefs: []*did.URL{nil, {RawFragment: "#key-1"}},
+		},
+		Services: []*did.Service{nil},
+	}
+
+	err := doc.RotateKey(&did.URL{RawFragment: "#key-1"}, &did.VerificationMethod{ID: did.URL{RawFragment: "#key-2"}, Type: did.MultikeyType})
+	if err != nil {
+		t.Fatal("rotate error:", err)
+	}
+	if err := doc.RemoveVerificationMethod(&did.URL{RawFragment: "#key-2"}); err != nil {
+		t.Fatal("remove error:", err)
+	}
+	if len(doc.VerificationMethods) != 1 || doc.VerificationMethods[0] != nil {
+		t.Errorf("got verification methods %v, want the nil entry only", doc.VerificationMethods)
+	}
+	if r := doc.Authentication; r == nil || len(r.Methods) != 1 || len(r.URIRefs) != 1 {
+		t.Errorf("got authentication %+v, want the nil entries only", r)
+	}
+
+	if err := doc.AddService(&did.Service{ID: url.URL{Fragment: "hub"}}); err != nil {
+		t.Fatal("add service error:", err)
+	}
+	if err := doc.RemoveService(&did.URL{RawFragment: "#hub"}); err != nil {
+		t.Fatal("remove service error:", err)
+	}
+	if len(doc.Services) != 1 || doc.Services[0] != nil {
+		t.Errorf("got services %v, want the nil entry only", doc.Services)
+	}
+}
+
+func TestEditShared(t *testing.T) {
+	var doc did.Document
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123",
+		"verificationMethod": [
+			{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"},
+			{"id": "#key-2", "type": "Multikey", "controller": "did:example:123"}
+		],
+		"authentication": ["#key-1", "#key-2"],
+		"service": [
+			{"id": "#a", "type": "Hub", "serviceEndpoint": "https://a.example/"},
+			{"id": "#b", "type": "Hub", "serviceEndpoint": "https://b.example/"}
+		]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shallow copies share slices and relationships
+	for _, edit := range []func(*did.Document) error{
+		func(doc *did.Document) error {
+			return doc.RemoveVerificationMethod(&did.URL{RawFragment: "#key-1"})
+		},
+		func(doc *did.Document) error {
+			return doc.RotateKey(&did.URL{RawFragment: "#key-1"}, &did.VerificationMethod{
+				ID: did.URL{RawFragment: "#key-3"}, Type: did.MultikeyType, Controller: doc.Subject,
+			})
+		},
+		func(doc *did.Document) error {
+			return doc.RemoveService(&did.URL{RawFragment: "#a"})
+		},
+	} {
+		edited := doc
+		if err := edit(&edited); err != nil {
+			t.Fatal("edit error:", err)
+		}
+		got, err := json.Marshal(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("original changed by edit of a copy:\ngot  %s\nwant %s", got, want)
+		}
+	}
+}
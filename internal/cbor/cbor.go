@@ -0,0 +1,486 @@
+// Package cbor converts between JSON and the “Concise Binary Object
+// Representation (CBOR)” of RFC 8949. Output is in the deterministic encoding
+// of section 4.2.1 always. The conversion is limited to the JSON data model:
+// maps with text keys, arrays, text, numbers, booleans and null.
+package cbor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Major types
+const (
+	unsignedInt = 0 << 5
+	negativeInt = 1 << 5
+	byteString  = 2 << 5
+	textString  = 3 << 5
+	array       = 4 << 5
+	mapping     = 5 << 5
+	tag         = 6 << 5
+	simple      = 7 << 5
+)
+
+// Simple values and floating-point heads
+const (
+	simpleFalse = simple | 20
+	simpleTrue  = simple | 21
+	simpleNull  = simple | 22
+	floatHalf   = simple | 25
+	floatSingle = simple | 26
+	floatDouble = simple | 27
+)
+
+// MaxDepth limits the nesting of arrays and maps on decoding.
+const maxDepth = 1000
+
+// FromJSON returns the deterministic CBOR encoding of a JSON value. Integers
+// map to the integer types, and any other numbers map to the shortest floating
+// point which preserves the value.
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("data after JSON value")
+	}
+	return appendValue(make([]byte, 0, len(data)), v)
+}
+
+func appendValue(buf []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, simpleNull), nil
+	case bool:
+		if v {
+			return append(buf, simpleTrue), nil
+		}
+		return append(buf, simpleFalse), nil
+	case string:
+		return appendText(buf, v), nil
+	case json.Number:
+		return appendNumber(buf, string(v))
+
+	case []any:
+		buf = appendHead(buf, array, uint64(len(v)))
+		var err error
+		for _, e := range v {
+			buf, err = appendValue(buf, e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	case map[string]any:
+		// “The keys in every map MUST be sorted in the bytewise
+		// lexicographic order of their deterministic encodings.”
+		// Text heads encode the length first.
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+
+		buf = appendHead(buf, mapping, uint64(len(v)))
+		var err error
+		for _, k := range keys {
+			buf = appendText(buf, k)
+			buf, err = appendValue(buf, v[k])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported JSON type %T", v)
+	}
+}
+
+// AppendHead encodes the initial byte with its argument in the shortest form.
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(buf, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	default:
+		return append(buf, major|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func appendText(buf []byte, s string) []byte {
+	buf = appendHead(buf, textString, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendNumber(buf []byte, s string) ([]byte, error) {
+	if !bytes.ContainsAny([]byte(s), ".eE") {
+		if s[0] == '-' {
+			n, err := strconv.ParseUint(s[1:], 10, 64)
+			if err == nil && n != 0 {
+				return appendHead(buf, negativeInt, n-1), nil
+			}
+			if err == nil {
+				return appendHead(buf, unsignedInt, 0), nil // minus zero
+			}
+			if s[1:] == "18446744073709551616" {
+				return appendHead(buf, negativeInt, math.MaxUint64), nil
+			}
+		} else {
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err == nil {
+				return appendHead(buf, unsignedInt, n), nil
+			}
+		}
+		// out of range falls back to floating point
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("JSON number %q: %w", s, err)
+	}
+	return appendFloat(buf, f), nil
+}
+
+// AppendFloat uses the shortest of half, single and double precision which
+// preserves the value, conform the preferred serialization.
+func appendFloat(buf []byte, f float64) []byte {
+	f32 := float32(f)
+	if float64(f32) != f {
+		bits := math.Float64bits(f)
+		return append(buf, floatDouble, byte(bits>>56), byte(bits>>48), byte(bits>>40), byte(bits>>32), byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+	}
+	if half, ok := toHalf(f32); ok {
+		return append(buf, floatHalf, byte(half>>8), byte(half))
+	}
+	bits := math.Float32bits(f32)
+	return append(buf, floatSingle, byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+}
+
+// ToHalf returns the IEEE 754 half-precision of f, if exact.
+func toHalf(f float32) (half uint16, ok bool) {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0 && mant == 0:
+		return sign, true // zero
+	case exp == 0 || exp == 0xff:
+		return 0, false // subnormal or non-finite
+	}
+
+	e := exp - 127
+	switch {
+	case e >= -14 && e <= 15:
+		// normal
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(e+15)<<10 | uint16(mant>>13), true
+	case e >= -24 && e < -14:
+		// subnormal
+		significand := mant | 1<<23
+		shift := uint(-e - 1)
+		if significand&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(significand>>shift), true
+	default:
+		return 0, false
+	}
+}
+
+// FromHalf returns the value of an IEEE 754 half-precision.
+func fromHalf(half uint16) float64 {
+	exp := int(half>>10) & 0x1f
+	mant := float64(half & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if half&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// ToJSON returns the JSON of a single CBOR data item. Byte strings map to
+// base64url without padding, conform RFC 8949, subsection 6.1. Tags, undefined,
+// non-finite numbers, and maps with any other than text keys are not supported.
+// Input must be in the deterministic encoding, i.e., arguments and floating
+// points in their shortest form, and map keys sorted.
+func ToJSON(data []byte) ([]byte, error) {
+	d := decoder{data: data}
+	buf, err := d.item(make([]byte, 0, len(data)*2), 0)
+	if err != nil {
+		return nil, err
+	}
+	if d.i < len(d.data) {
+		return nil, fmt.Errorf("CBOR data after item at byte № %d", d.i+1)
+	}
+	return buf, nil
+}
+
+// Decoder reads data items in sequence.
+type decoder struct {
+	data []byte
+	i    int // read position
+}
+
+var errEnd = errors.New("CBOR data incomplete")
+
+// Head reads the initial byte with its argument.
+func (d *decoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.i >= len(d.data) {
+		return 0, 0, 0, errEnd
+	}
+	b := d.data[d.i]
+	d.i++
+	major, info = b&0xe0, b&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == 31:
+		return 0, 0, 0, fmt.Errorf("CBOR indefinite length at byte № %d not supported", d.i)
+	default:
+		return 0, 0, 0, fmt.Errorf("CBOR reserved additional information %d at byte № %d", info, d.i)
+	}
+
+	if len(d.data)-d.i < size {
+		return 0, 0, 0, errEnd
+	}
+	for _, c := range d.data[d.i : d.i+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.i += size
+
+	// floating points have their own preferred serialization
+	shortest := uint64(24)
+	if size > 1 {
+		shortest = 1 << (4 * size)
+	}
+	if major != simple && arg < shortest {
+		return 0, 0, 0, fmt.Errorf("CBOR argument at byte № %d not in its shortest form", d.i-size)
+	}
+	return major, info, arg, nil
+}
+
+// Bytes reads the content of a byte or text string.
+func (d *decoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.i) {
+		return nil, errEnd
+	}
+	b := d.data[d.i : d.i+int(n)]
+	d.i += int(n)
+	return b, nil
+}
+
+// Text reads a text string.
+func (d *decoder) text(n uint64) (string, error) {
+	b, err := d.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("CBOR text string before byte № %d is not valid UTF-8", d.i+1)
+	}
+	return string(b), nil
+}
+
+// Item appends the JSON of the next data item to buf.
+func (d *decoder) item(buf []byte, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("CBOR nesting exceeds limit")
+	}
+	offset := d.i
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case unsignedInt:
+		return strconv.AppendUint(buf, arg, 10), nil
+
+	case negativeInt:
+		if arg == math.MaxUint64 {
+			return append(buf, "-18446744073709551616"...), nil
+		}
+		buf = append(buf, '-')
+		return strconv.AppendUint(buf, arg+1, 10), nil
+
+	case byteString:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, '"')
+		buf = append(buf, base64.RawURLEncoding.EncodeToString(b)...)
+		return append(buf, '"'), nil
+
+	case textString:
+		s, err := d.text(arg)
+		if err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, s), nil
+
+	case array:
+		if arg > uint64(len(d.data)-d.i) {
+			return nil, errEnd // each item takes a byte at least
+		}
+		buf = append(buf, '[')
+		for n := uint64(0); n < arg; n++ {
+			if n != 0 {
+				buf = append(buf, ',')
+			}
+			buf, err = d.item(buf, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+
+	case mapping:
+		if arg > uint64(len(d.data)-d.i)/2 {
+			return nil, errEnd // each pair takes two bytes at least
+		}
+		var lastKey string
+		buf = append(buf, '{')
+		for n := uint64(0); n < arg; n++ {
+			if n != 0 {
+				buf = append(buf, ',')
+			}
+			keyOffset := d.i
+			major, _, keyLen, err := d.head()
+			if err != nil {
+				return nil, err
+			}
+			if major != textString {
+				return nil, fmt.Errorf("CBOR map key at byte № %d is not a text string", keyOffset+1)
+			}
+			key, err := d.text(keyLen)
+			if err != nil {
+				return nil, err
+			}
+			// “The keys in every map MUST be sorted in the bytewise
+			// lexicographic order of their deterministic encodings.”
+			if n != 0 {
+				switch {
+				case key == lastKey:
+					return nil, fmt.Errorf("CBOR map key %q duplicate at byte № %d", key, keyOffset+1)
+				case len(key) < len(lastKey) || len(key) == len(lastKey) && key < lastKey:
+					return nil, fmt.Errorf("CBOR map key %q at byte № %d not sorted", key, keyOffset+1)
+				}
+			}
+			lastKey = key
+
+			buf = appendJSONString(buf, key)
+			buf = append(buf, ':')
+			buf, err = d.item(buf, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	case tag:
+		return nil, fmt.Errorf("CBOR tag %d at byte № %d not supported", arg, offset+1)
+
+	default: // simple
+		switch info {
+		case 20:
+			return append(buf, "false"...), nil
+		case 21:
+			return append(buf, "true"...), nil
+		case 22:
+			return append(buf, "null"...), nil
+		case 25, 26, 27:
+			var f float64
+			switch info {
+			case 25:
+				f = fromHalf(uint16(arg))
+			case 26:
+				f = float64(math.Float32frombits(uint32(arg)))
+			default:
+				f = math.Float64frombits(arg)
+			}
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return nil, fmt.Errorf("CBOR float at byte № %d not finite", offset+1)
+			}
+			if len(appendFloat(nil, f)) != d.i-offset {
+				return nil, fmt.Errorf("CBOR float at byte № %d not in its shortest form", offset+1)
+			}
+			return strconv.AppendFloat(buf, f, 'g', -1, 64), nil
+		default:
+			return nil, fmt.Errorf("CBOR simple value %d at byte № %d not supported", arg, offset+1)
+		}
+	}
+}
+
+// AppendJSONString appends s as a JSON string, without any HTML escapes.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '"')
+}
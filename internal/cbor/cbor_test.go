@@ -0,0 +1,146 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Examples from RFC 8949, appendix A, in the deterministic encoding.
+var goldenJSON = []struct{ json, hex string }{
+	{`0`, "00"},
+	{`1`, "01"},
+	{`10`, "0a"},
+	{`23`, "17"},
+	{`24`, "1818"},
+	{`100`, "1864"},
+	{`1000`, "1903e8"},
+	{`1000000`, "1a000f4240"},
+	{`1000000000000`, "1b000000e8d4a51000"},
+	{`18446744073709551615`, "1bffffffffffffffff"},
+	{`-18446744073709551616`, "3bffffffffffffffff"},
+	{`-1`, "20"},
+	{`-10`, "29"},
+	{`-100`, "3863"},
+	{`-1000`, "3903e7"},
+	{`1.1`, "fb3ff199999999999a"},
+	{`1.5`, "f93e00"},
+	{`65504`, "19ffe0"},
+	{`65504.5`, "fa477fe080"},
+	{`100000.5`, "fa47c35040"},
+	{`3.4028234663852886e+38`, "fa7f7fffff"},
+	{`1e+300`, "fb7e37e43c8800759c"},
+	{`5.960464477539063e-08`, "f90001"},
+	{`6.103515625e-05`, "f90400"},
+	{`-4.1`, "fbc010666666666666"},
+	{`false`, "f4"},
+	{`true`, "f5"},
+	{`null`, "f6"},
+	{`""`, "60"},
+	{`"a"`, "6161"},
+	{`"IETF"`, "6449455446"},
+	{`"\"\\"`, "62225c"},
+	{`"ü"`, "62c3bc"},
+	{`"水"`, "63e6b0b4"},
+	{`[]`, "80"},
+	{`[1,2,3]`, "83010203"},
+	{`[1,[2,3],[4,5]]`, "8301820203820405"},
+	{`{}`, "a0"},
+	{`{"a":1,"b":[2,3]}`, "a26161016162820203"},
+	{`["a",{"b":"c"}]`, "826161a161626163"},
+	{`{"b":"A","c":"C","aa":"B"}`, "a361626141616361436261616142"},
+}
+
+func TestFromJSON(t *testing.T) {
+	for _, gold := range goldenJSON {
+		got, err := FromJSON([]byte(gold.json))
+		if err != nil {
+			t.Errorf("%s got error: %s", gold.json, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.hex {
+			t.Errorf("%s got CBOR %x, want %s", gold.json, got, gold.hex)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	for _, gold := range goldenJSON {
+		data, err := hex.DecodeString(gold.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ToJSON(data)
+		if err != nil {
+			t.Errorf("%s got error: %s", gold.hex, err)
+			continue
+		}
+		if string(got) != gold.json {
+			t.Errorf("%s got JSON %s, want %s", gold.hex, got, gold.json)
+		}
+	}
+}
+
+func TestToJSONNonDeterministic(t *testing.T) {
+	tests := []struct{ hex, json string }{
+		{"f93c00", "1"},                          // integer float
+		{"4401020304", `"AQIDBA"`},               // byte string
+		{"6401020a1f", `"\u0001\u0002\n\u001f"`}, // control characters
+		{"63e280a8", "\"\u2028\""},               // no JavaScript escapes
+		{"623c3e", `"<>"`},                       // no HTML escapes
+		{"fa3dcccccd", "0.10000000149011612"},    // single precision
+		{"f98000", "-0"},                         // negative zero
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ToJSON(data)
+		if err != nil {
+			t.Errorf("%s got error: %s", test.hex, err)
+			continue
+		}
+		if string(got) != test.json {
+			t.Errorf("%s got JSON %s, want %s", test.hex, got, test.json)
+		}
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	tests := []struct{ hex, want string }{
+		{"", "incomplete"},
+		{"19ff", "incomplete"},
+		{"83010203" + "04", "data after item"},
+		{"9f01ff", "indefinite length"},
+		{"c11a514b67b0", "tag 1"},
+		{"f7", "simple value 23"},
+		{"f97c00", "not finite"},
+		{"a1016161", "not a text string"},
+		{"a2616101616102", "duplicate"},
+		{"a2616260616160", "not sorted"},
+		{"a2626161606162606160", "not sorted"},
+		{"1800", "shortest form"},
+		{"190017", "shortest form"},
+		{"1a0000ffff", "shortest form"},
+		{"1b00000000ffffffff", "shortest form"},
+		{"7800", "shortest form"},
+		{"fa3f800000", "shortest form"},
+		{"fb3ff0000000000000", "shortest form"},
+		{"fb3fb99999a0000000", "shortest form"},
+		{"62c328", "not valid UTF-8"},
+		{"9bffffffffffffffff", "incomplete"},
+		{"1c", "reserved"},
+		{strings.Repeat("81", maxDepth+2) + "00", "nesting"},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ToJSON(data)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s got error %v, want %q", test.hex, err, test.want)
+		}
+	}
+}
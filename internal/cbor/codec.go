@@ -0,0 +1,219 @@
+package cbor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AppendText appends a text string.
+func AppendText(buf []byte, s string) []byte {
+	return appendText(buf, s)
+}
+
+// AppendNull appends the null value.
+func AppendNull(buf []byte) []byte {
+	return append(buf, simpleNull)
+}
+
+// AppendArrayHead appends the head of an array with n items. The items must
+// follow.
+func AppendArrayHead(buf []byte, n int) []byte {
+	return appendHead(buf, array, uint64(n))
+}
+
+// AppendJSON appends the deterministic encoding of a JSON value, conform
+// FromJSON.
+func AppendJSON(buf, data []byte) ([]byte, error) {
+	item, err := FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, item...), nil
+}
+
+// Map collects the entries of a map with text keys. The zero value is ready for
+// use.
+type Map struct {
+	keys  []string
+	items [][]byte
+}
+
+// Set adds an entry with the encoding of a single data item as its value.
+func (m *Map) Set(key string, item []byte) {
+	m.keys = append(m.keys, key)
+	m.items = append(m.items, item)
+}
+
+// Len returns the number of entries.
+func (m *Map) Len() int { return len(m.keys) }
+
+func (m *Map) Less(i, j int) bool {
+	if len(m.keys[i]) != len(m.keys[j]) {
+		return len(m.keys[i]) < len(m.keys[j])
+	}
+	return m.keys[i] < m.keys[j]
+}
+
+func (m *Map) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.items[i], m.items[j] = m.items[j], m.items[i]
+}
+
+// Append appends the map in deterministic encoding. Keys must be unique.
+func (m *Map) Append(buf []byte) []byte {
+	// “The keys in every map MUST be sorted in the bytewise
+	// lexicographic order of their deterministic encodings.”
+	// Text heads encode the length first.
+	sort.Sort(m)
+	buf = appendHead(buf, mapping, uint64(len(m.keys)))
+	for i, key := range m.keys {
+		buf = appendText(buf, key)
+		buf = append(buf, m.items[i]...)
+	}
+	return buf
+}
+
+// Decoder reads data items from deterministic encodings, with the same
+// constraints as ToJSON.
+type Decoder struct {
+	d decoder
+}
+
+// NewDecoder returns a new Decoder which reads from data.
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{decoder{data: data}}
+}
+
+// End returns an error when data remains after the items read.
+func (dec *Decoder) End() error {
+	if dec.d.i < len(dec.d.data) {
+		return fmt.Errorf("CBOR data after item at byte № %d", dec.d.i+1)
+	}
+	return nil
+}
+
+// Peek returns the initial byte of the next data item.
+func (dec *Decoder) peek() (byte, error) {
+	if dec.d.i >= len(dec.d.data) {
+		return 0, errEnd
+	}
+	return dec.d.data[dec.d.i], nil
+}
+
+// IsNull returns whether the next data item is null, without reading it.
+func (dec *Decoder) IsNull() bool {
+	b, err := dec.peek()
+	return err == nil && b == simpleNull
+}
+
+// IsText returns whether the next data item is a text string, without reading
+// it.
+func (dec *Decoder) IsText() bool {
+	b, err := dec.peek()
+	return err == nil && b&0xe0 == textString
+}
+
+// IsArray returns whether the next data item is an array, without reading it.
+func (dec *Decoder) IsArray() bool {
+	b, err := dec.peek()
+	return err == nil && b&0xe0 == array
+}
+
+// IsMap returns whether the next data item is a map, without reading it.
+func (dec *Decoder) IsMap() bool {
+	b, err := dec.peek()
+	return err == nil && b&0xe0 == mapping
+}
+
+// Null reads a null value.
+func (dec *Decoder) Null() error {
+	offset := dec.d.i
+	major, info, _, err := dec.d.head()
+	if err != nil {
+		return err
+	}
+	if major != simple || info != 22 {
+		return fmt.Errorf("CBOR data item at byte № %d is not null", offset+1)
+	}
+	return nil
+}
+
+// Text reads a text string.
+func (dec *Decoder) Text() (string, error) {
+	offset := dec.d.i
+	major, _, n, err := dec.d.head()
+	if err != nil {
+		return "", err
+	}
+	if major != textString {
+		return "", fmt.Errorf("CBOR data item at byte № %d is not a text string", offset+1)
+	}
+	return dec.d.text(n)
+}
+
+// ArrayLen reads the head of an array, and it returns the number of items. The
+// items must be read next.
+func (dec *Decoder) ArrayLen() (int, error) {
+	offset := dec.d.i
+	major, _, n, err := dec.d.head()
+	if err != nil {
+		return 0, err
+	}
+	if major != array {
+		return 0, fmt.Errorf("CBOR data item at byte № %d is not an array", offset+1)
+	}
+	if n > uint64(len(dec.d.data)-dec.d.i) {
+		return 0, errEnd // each item takes a byte at least
+	}
+	return int(n), nil
+}
+
+// Map reads a map with text keys. Function f is called for each key in order of
+// appearance, and it must read the value.
+func (dec *Decoder) Map(f func(key string) error) error {
+	offset := dec.d.i
+	major, _, n, err := dec.d.head()
+	if err != nil {
+		return err
+	}
+	if major != mapping {
+		return fmt.Errorf("CBOR data item at byte № %d is not a map", offset+1)
+	}
+	if n > uint64(len(dec.d.data)-dec.d.i)/2 {
+		return errEnd // each pair takes two bytes at least
+	}
+
+	var lastKey string
+	for i := uint64(0); i < n; i++ {
+		keyOffset := dec.d.i
+		if !dec.IsText() {
+			if _, err := dec.peek(); err != nil {
+				return err
+			}
+			return fmt.Errorf("CBOR map key at byte № %d is not a text string", keyOffset+1)
+		}
+		key, err := dec.Text()
+		if err != nil {
+			return err
+		}
+		if i != 0 {
+			switch {
+			case key == lastKey:
+				return fmt.Errorf("CBOR map key %q duplicate at byte № %d", key, keyOffset+1)
+			case len(key) < len(lastKey) || len(key) == len(lastKey) && key < lastKey:
+				return fmt.Errorf("CBOR map key %q at byte № %d not sorted", key, keyOffset+1)
+			}
+		}
+		lastKey = key
+
+		if err := f(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSON reads the next data item, and it returns the JSON conform ToJSON.
+func (dec *Decoder) JSON() ([]byte, error) {
+	return dec.d.item(nil, 0)
+}
@@ -0,0 +1,100 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	var m Map
+	m.Set("service", AppendNull(nil))
+	m.Set("id", AppendText(nil, "x"))
+	m.Set("type", AppendArrayHead(nil, 0))
+	m.Set("ab", AppendNull(nil))
+
+	got := hex.EncodeToString(m.Append(nil))
+	const want = "a4" + "626162f6" + "6269646178" + "647479706580" + "67736572766963" + "65f6"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if _, err := ToJSON(m.Append(nil)); err != nil {
+		t.Error("deterministic encoding rejected:", err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	data, err := FromJSON([]byte(`{"a":["b",null],"cc":{"d":1.5}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(data)
+	var keys []string
+	err = dec.Map(func(key string) error {
+		keys = append(keys, key)
+		switch key {
+		case "a":
+			if !dec.IsArray() {
+				t.Error("array not recognised")
+			}
+			n, err := dec.ArrayLen()
+			if err != nil || n != 2 {
+				t.Errorf("got array length %d, error %v; want 2", n, err)
+			}
+			if s, err := dec.Text(); err != nil || s != "b" {
+				t.Errorf("got text %q, error %v; want b", s, err)
+			}
+			if !dec.IsNull() {
+				t.Error("null not recognised")
+			}
+			return dec.Null()
+		default:
+			json, err := dec.JSON()
+			if string(json) != `{"d":1.5}` {
+				t.Errorf("got JSON %s, want {\"d\":1.5}", json)
+			}
+			return err
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "a,cc" {
+		t.Errorf("got keys %q, want a and cc", keys)
+	}
+	if err := dec.End(); err != nil {
+		t.Error("end error:", err)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	tests := []struct {
+		hex  string
+		read func(*Decoder) error
+		want string
+	}{
+		{"f6", func(dec *Decoder) error { _, err := dec.Text(); return err }, "not a text string"},
+		{"60", func(dec *Decoder) error { return dec.Null() }, "not null"},
+		{"a0", func(dec *Decoder) error { _, err := dec.ArrayLen(); return err }, "not an array"},
+		{"80", func(dec *Decoder) error { return dec.Map(nil) }, "not a map"},
+		{"a1f6f6", func(dec *Decoder) error { return dec.Map(nil) }, "not a text string"},
+		{"8200", func(dec *Decoder) error { _, err := dec.ArrayLen(); return err }, "incomplete"},
+		{"f6f6", func(dec *Decoder) error {
+			if err := dec.Null(); err != nil {
+				return err
+			}
+			return dec.End()
+		}, "data after item"},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = test.read(NewDecoder(data))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s got error %v, want %q", test.hex, err, test.want)
+		}
+	}
+}
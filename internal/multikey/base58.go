@@ -0,0 +1,72 @@
+package multikey
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Bitcoin alphabet
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Index = func() (index [256]int8) {
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		index[base58Alphabet[i]] = int8(i)
+	}
+	return
+}()
+
+var big58 = big.NewInt(58)
+
+// EncodeBase58 returns the Bitcoin encoding of bytes.
+func EncodeBase58(bytes []byte) string {
+	// leading zeros map to leading ones
+	var zeroN int
+	for zeroN < len(bytes) && bytes[zeroN] == 0 {
+		zeroN++
+	}
+
+	// each digit holds log(58)/log(256) ≈ 0.73 bytes
+	digits := make([]byte, 0, zeroN+len(bytes)*138/100+1)
+	v := new(big.Int).SetBytes(bytes)
+	mod := new(big.Int)
+	for v.Sign() != 0 {
+		v.DivMod(v, big58, mod)
+		digits = append(digits, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeroN; i++ {
+		digits = append(digits, '1')
+	}
+
+	// reverse
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// DecodeBase58 parses the Bitcoin encoding of s.
+func DecodeBase58(s string) ([]byte, error) {
+	var oneN int
+	for oneN < len(s) && s[oneN] == '1' {
+		oneN++
+	}
+
+	v := new(big.Int)
+	digit := new(big.Int)
+	for i := oneN; i < len(s); i++ {
+		d := base58Index[s[i]]
+		if d < 0 {
+			return nil, fmt.Errorf("base58 illegal %q at byte № %d", s[i], i+1)
+		}
+		v.Mul(v, big58)
+		v.Add(v, digit.SetInt64(int64(d)))
+	}
+
+	bytes := v.Bytes()
+	out := make([]byte, oneN+len(bytes))
+	copy(out[oneN:], bytes)
+	return out, nil
+}
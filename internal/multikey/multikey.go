@@ -0,0 +1,225 @@
+// Package multikey provides the multibase and multicodec encodings of public
+// keys, as used by Multikey verification methods and the key-based DID methods.
+package multikey
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Multicodec identifiers of public keys.
+const (
+	Ed25519 = 0xed
+	X25519  = 0xec
+	P256    = 0x1200
+	P384    = 0x1201
+	P521    = 0x1202
+)
+
+// Multibase prefixes.
+const (
+	Base58BTC = 'z'
+	Base64URL = 'u'
+)
+
+// ErrUnsupported signals a key type or a multicodec not supported.
+var ErrUnsupported = errors.New("multikey not supported")
+
+// Decode parses a multibase encoding of a multicodec key.
+func Decode(s string) (crypto.PublicKey, error) {
+	bytes, err := DecodeMultibase(s)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeMulticodec(bytes)
+}
+
+// Encode returns the multicodec of key in base58-btc multibase.
+func Encode(key crypto.PublicKey) (string, error) {
+	bytes, err := EncodeMulticodec(key)
+	if err != nil {
+		return "", err
+	}
+	return string(Base58BTC) + EncodeBase58(bytes), nil
+}
+
+// DecodeMultibase parses either base58-btc or base64url (without padding).
+func DecodeMultibase(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("multibase empty")
+	}
+	switch s[0] {
+	case Base58BTC:
+		return DecodeBase58(s[1:])
+	case Base64URL:
+		bytes, err := base64.RawURLEncoding.DecodeString(s[1:])
+		if err != nil {
+			return nil, fmt.Errorf("multibase base64url: %w", err)
+		}
+		return bytes, nil
+	default:
+		return nil, fmt.Errorf("multibase prefix %q not supported", s[0])
+	}
+}
+
+// DecodeMulticodec parses a key with its multicodec prefix.
+func DecodeMulticodec(bytes []byte) (crypto.PublicKey, error) {
+	code, n := readUvarint(bytes)
+	if n <= 0 {
+		return nil, errors.New("multicodec prefix malformed")
+	}
+	return DecodeKey(code, bytes[n:])
+}
+
+// DecodeKey parses the raw encoding of a public key for a multicodec.
+func DecodeKey(code uint64, raw []byte) (crypto.PublicKey, error) {
+	switch code {
+	case Ed25519:
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Ed25519 public key of %d bytes", len(raw))
+		}
+		return ed25519.PublicKey(append([]byte(nil), raw...)), nil
+
+	case X25519:
+		key, err := ecdh.X25519().NewPublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("X25519 public key: %w", err)
+		}
+		return key, nil
+
+	case P256:
+		return decodeECDSA(elliptic.P256(), raw)
+	case P384:
+		return decodeECDSA(elliptic.P384(), raw)
+	case P521:
+		return decodeECDSA(elliptic.P521(), raw)
+
+	default:
+		return nil, fmt.Errorf("%w: multicodec 0x%x", ErrUnsupported, code)
+	}
+}
+
+func decodeECDSA(curve elliptic.Curve, raw []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.UnmarshalCompressed(curve, raw)
+	if x == nil {
+		// uncompressed form is not standard, yet unambiguous
+		x, y = elliptic.Unmarshal(curve, raw)
+		if x == nil {
+			return nil, fmt.Errorf("%s public key malformed", curve.Params().Name)
+		}
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// EncodeMulticodec returns key with its multicodec prefix.
+func EncodeMulticodec(key crypto.PublicKey) ([]byte, error) {
+	code, raw, err := EncodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return append(appendUvarint(nil, code), raw...), nil
+}
+
+// EncodeKey returns the multicodec with the raw encoding of key. EC points are
+// compressed.
+func EncodeKey(key crypto.PublicKey) (code uint64, raw []byte, err error) {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return 0, nil, fmt.Errorf("Ed25519 public key of %d bytes", len(key))
+		}
+		return Ed25519, key, nil
+
+	case *ecdh.PublicKey:
+		if key.Curve() != ecdh.X25519() {
+			return 0, nil, fmt.Errorf("%w: ECDH curve %s", ErrUnsupported, key.Curve())
+		}
+		return X25519, key.Bytes(), nil
+
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			code = P256
+		case elliptic.P384():
+			code = P384
+		case elliptic.P521():
+			code = P521
+		default:
+			return 0, nil, fmt.Errorf("%w: ECDSA curve %s", ErrUnsupported, key.Curve.Params().Name)
+		}
+		return code, elliptic.MarshalCompressed(key.Curve, key.X, key.Y), nil
+
+	default:
+		return 0, nil, fmt.Errorf("%w: key type %T", ErrUnsupported, key)
+	}
+}
+
+// Field prime of Curve25519: 2²⁵⁵ − 19
+var p25519 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// X25519FromEd25519 returns the Montgomery form of an Edwards point, as per
+// RFC 7748, section 4.1. The birational map is u = (1 + y) / (1 − y).
+func X25519FromEd25519(key ed25519.PublicKey) (*ecdh.PublicKey, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Ed25519 public key of %d bytes", len(key))
+	}
+
+	// little-endian y-coordinate with the sign bit of x cleared
+	var be [32]byte
+	for i, b := range key {
+		be[31-i] = b
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be[:])
+	if y.Cmp(p25519) >= 0 {
+		return nil, errors.New("Ed25519 public key not canonical")
+	}
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, p25519)
+	if denominator.Sign() == 0 {
+		return nil, errors.New("Ed25519 public key has no Montgomery form")
+	}
+	denominator.ModInverse(denominator, p25519)
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator)
+	u.Mod(u, p25519)
+
+	var raw [32]byte
+	u.FillBytes(be[:])
+	for i, b := range be {
+		raw[31-i] = b
+	}
+	return ecdh.X25519().NewPublicKey(raw[:])
+}
+
+func readUvarint(bytes []byte) (v uint64, n int) {
+	for i, b := range bytes {
+		if i >= 9 {
+			return 0, -1 // overflow
+		}
+		v |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			if b == 0 && i != 0 {
+				return 0, -1 // not minimal
+			}
+			return v, i + 1
+		}
+	}
+	return 0, 0 // incomplete
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
@@ -0,0 +1,112 @@
+package multikey
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		raw     string
+		encoded string
+	}{
+		{"", ""},
+		{"\x00", "1"},
+		{"\x00\x00a", "112g"},
+		{"Hello World!", "2NEpo7TZRRrLZSi2U"},
+		{"The quick brown fox jumps over the lazy dog.", "USm3fpXnKG5EUBx2ndxBDMPVciP5hGey2Jh4NDv6gmeo1LkMeiKrLJUUBk6Z"},
+	}
+	for _, test := range tests {
+		if got := EncodeBase58([]byte(test.raw)); got != test.encoded {
+			t.Errorf("%q got encoding %q, want %q", test.raw, got, test.encoded)
+		}
+		got, err := DecodeBase58(test.encoded)
+		if err != nil {
+			t.Errorf("%q got decode error: %s", test.encoded, err)
+		} else if string(got) != test.raw {
+			t.Errorf("%q got decoding %q, want %q", test.encoded, got, test.raw)
+		}
+	}
+
+	if _, err := DecodeBase58("0OIl"); err == nil {
+		t.Error("illegal characters got no error")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type equaler interface{ Equal(x crypto.PublicKey) bool }
+	for _, key := range []equaler{edKey, xKey.PublicKey(), &p256Key.PublicKey, &p384Key.PublicKey} {
+		s, err := Encode(key)
+		if err != nil {
+			t.Errorf("%T encode error: %s", key, err)
+			continue
+		}
+		got, err := Decode(s)
+		if err != nil {
+			t.Errorf("%T decode %q error: %s", key, s, err)
+			continue
+		}
+		if !key.Equal(got) {
+			t.Errorf("%T %q got key %v, want %v", key, s, got, key)
+		}
+	}
+}
+
+func TestEncodePrefix(t *testing.T) {
+	key, _, _ := ed25519.GenerateKey(rand.Reader)
+	s, err := Encode(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s[:4] != "z6Mk" {
+		t.Errorf("Ed25519 got %q, want z6Mk prefix", s)
+	}
+}
+
+func TestX25519FromEd25519(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 8032, subsection 5.1.5: the private scalar is the first half
+	// of the hashed seed, which X25519 clamps the same way.
+	h := sha512.Sum512(edPriv.Seed())
+	xPriv, err := ecdh.X25519().NewPrivateKey(h[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := xPriv.PublicKey().Bytes()
+
+	got, err := X25519FromEd25519(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("got X25519 %x, want %x", got.Bytes(), want)
+	}
+}
@@ -0,0 +1,14 @@
+// Package sorted provides deterministic iteration of maps.
+package sorted
+
+import "sort"
+
+// Keys returns the keys of m in lexical order.
+func Keys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
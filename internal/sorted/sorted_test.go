@@ -0,0 +1,17 @@
+package sorted
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	got := Keys(map[string]int{"b": 2, "aa": 3, "a": 1, "B": 4})
+	want := []string{"B", "a", "aa", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Keys[any](nil); len(got) != 0 {
+		t.Errorf("got %q for nil map, want none", got)
+	}
+}
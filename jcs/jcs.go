@@ -0,0 +1,219 @@
+// Package jcs implements the “JSON Canonicalization Scheme” (JCS), as defined
+// by RFC 8785. Canonical output is deterministic, which makes it suitable for
+// hashing and signing.
+package jcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Marshal returns the canonical JSON encoding of v. Any json.Marshaler in v,
+// such as did.Document, is transformed as such.
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Transform(data)
+}
+
+// Transform returns the canonical form of JSON data. Errors include malformed
+// JSON, duplicate object keys, invalid UTF-8, and numbers out of range for
+// IEEE 754 double precision.
+func Transform(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("JCS: invalid UTF-8")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	buf, err := appendValue(make([]byte, 0, len(data)), dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("JCS: data after top-level value")
+	}
+	return buf, nil
+}
+
+func appendValue(buf []byte, dec *json.Decoder) ([]byte, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("JCS: %w", err)
+	}
+
+	switch v := token.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case string:
+		return AppendString(buf, v), nil
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("JCS: number %s: %w", v, err)
+		}
+		return AppendNumber(buf, f)
+
+	case json.Delim:
+		if v == '[' {
+			buf = append(buf, '[')
+			for i := 0; dec.More(); i++ {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf, err = appendValue(buf, dec)
+				if err != nil {
+					return nil, err
+				}
+			}
+			dec.Token() // ']'
+			return append(buf, ']'), nil
+		}
+
+		// object members are sorted on their encoded form
+		type member struct {
+			key   string
+			value []byte
+		}
+		var members []member
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("JCS: %w", err)
+			}
+			key := token.(string)
+			value, err := appendValue(nil, dec)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member{key, value})
+		}
+		dec.Token() // '}'
+
+		sort.Slice(members, func(i, j int) bool {
+			return lessUTF16(members[i].key, members[j].key)
+		})
+		buf = append(buf, '{')
+		for i, m := range members {
+			if i != 0 {
+				if members[i-1].key == m.key {
+					return nil, fmt.Errorf("JCS: duplicate object key %q", m.key)
+				}
+				buf = append(buf, ',')
+			}
+			buf = AppendString(buf, m.key)
+			buf = append(buf, ':')
+			buf = append(buf, m.value...)
+		}
+		return append(buf, '}'), nil
+	}
+	return nil, fmt.Errorf("JCS: unexpected token %v", token)
+}
+
+// LessUTF16 compares strings on their UTF-16 code units, as per RFC 8785,
+// section 3.2.3.
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// AppendString appends the canonical JSON string of s. Only the quotation
+// mark, the reverse solidus and the control characters are escaped.
+func AppendString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c >= 0x20:
+			buf = append(buf, c)
+		case c == '\b':
+			buf = append(buf, '\\', 'b')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\f':
+			buf = append(buf, '\\', 'f')
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		}
+	}
+	return append(buf, '"')
+}
+
+// AppendNumber appends the canonical JSON number of f, which is the ECMAScript
+// Number.prototype.toString serialization. NaN and infinity are errors.
+func AppendNumber(buf []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("JCS: number %v not permitted", f)
+	}
+	if f == 0 {
+		return append(buf, '0'), nil // including negative zero
+	}
+	if f < 0 {
+		buf = append(buf, '-')
+		f = -f
+	}
+
+	// shortest decimal that round trips, as d.ddde±x
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(e, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	k := len(digits)
+	n := x + 1 // decimal point position
+
+	switch {
+	case k <= n && n <= 21:
+		buf = append(buf, digits...)
+		for i := k; i < n; i++ {
+			buf = append(buf, '0')
+		}
+	case 0 < n && n <= 21:
+		buf = append(buf, digits[:n]...)
+		buf = append(buf, '.')
+		buf = append(buf, digits[n:]...)
+	case -6 < n && n <= 0:
+		buf = append(buf, '0', '.')
+		for i := n; i < 0; i++ {
+			buf = append(buf, '0')
+		}
+		buf = append(buf, digits...)
+	default:
+		buf = append(buf, digits[0])
+		if k > 1 {
+			buf = append(buf, '.')
+			buf = append(buf, digits[1:]...)
+		}
+		buf = append(buf, 'e')
+		if n-1 >= 0 {
+			buf = append(buf, '+')
+		}
+		buf = strconv.AppendInt(buf, int64(n-1), 10)
+	}
+	return buf, nil
+}
@@ -0,0 +1,183 @@
+package jcs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/jcs"
+)
+
+func ExampleTransform() {
+	// example from RFC 8785, section 3.2.2
+	canonical, err := jcs.Transform([]byte(`{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", canonical)
+	// Output:
+	// {"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}
+}
+
+func ExampleMarshal() {
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: did.DID{Method: "example", SpecID: "123"}, RawFragment: "#key-1"},
+		Type:       "Multikey",
+		Controller: did.DID{Method: "example", SpecID: "123"},
+		Additional: map[string]json.RawMessage{
+			"publicKeyMultibase": json.RawMessage(`"z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"`),
+			"expires":            json.RawMessage(`"2030-01-01T00:00:00Z"`),
+		},
+	}
+	canonical, err := jcs.Marshal(m)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", canonical)
+	// Output:
+	// {"controller":"did:example:123","expires":"2030-01-01T00:00:00Z","id":"did:example:123#key-1","publicKeyMultibase":"z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK","type":"Multikey"}
+}
+
+// Test vectors from RFC 8785, appendix B.
+func TestAppendNumber(t *testing.T) {
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, test := range tests {
+		got, err := jcs.AppendNumber(nil, math.Float64frombits(test.bits))
+		if err != nil {
+			t.Errorf("%#016x got error: %s", test.bits, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%#016x got %s, want %s", test.bits, got, test.want)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := jcs.AppendNumber(nil, f); err == nil {
+			t.Errorf("%v got no error", f)
+		}
+	}
+}
+
+func TestTransformSort(t *testing.T) {
+	// example from RFC 8785, section 3.2.3
+	got, err := jcs.Transform([]byte(`{
+		"\u20ac": "Euro Sign",
+		"\r": "Carriage Return",
+		"\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"\ud83d\ude00": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"\u00f6": "Latin Small Letter O With Diaeresis"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`{`,
+		`[1,]`,
+		`{"a":1,"a":2}`,
+		`1e400`,
+		"\"\xff\"",
+		`{} {}`,
+	}
+	for _, sample := range tests {
+		got, err := jcs.Transform([]byte(sample))
+		if err == nil {
+			t.Errorf("%q got %s, want error", sample, got)
+		}
+	}
+}
+
+func TestMarshalDocument(t *testing.T) {
+	var doc did.Document
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123",
+		"verificationMethod": [{
+			"id": "did:example:123#key-1",
+			"type": "JsonWebKey2020",
+			"controller": "did:example:123",
+			"publicKeyJwk": {"kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			"a": 1, "b": 2, "c": 3, "d": 4, "e": 5
+		}],
+		"service": [{
+			"id": "#linked",
+			"type": "LinkedDomains",
+			"serviceEndpoint": "https://example.com/",
+			"z": 1, "y": 2, "x": 3, "w": 4, "v": 5
+		}]
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := jcs.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		bytes, err := json.Marshal(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonical, err := jcs.Transform(bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(canonical) != string(first) {
+			t.Fatalf("got %s, want %s", canonical, first)
+		}
+
+		again, err := json.Marshal(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(bytes) {
+			t.Fatalf("JSON encoding not deterministic:\n%s\n%s", bytes, again)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package did
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// MarshalJSONLD returns the JSON-LD representation of doc, which is the JSON
+// representation with an "@context" of V1 followed by any Contexts.
+func (doc *Document) MarshalJSONLD() ([]byte, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < 2 || body[0] != '{' {
+		return nil, errors.New("DID document JSON is not an object")
+	}
+
+	buf := make([]byte, 0, len(body)+64)
+	buf = append(buf, `{"@context":[`...)
+	buf = strconv.AppendQuote(buf, V1)
+	for _, raw := range doc.Contexts {
+		if !validContext(raw) {
+			return nil, fmt.Errorf("DID document JSON-LD context %q is not a JSON string nor an object", raw)
+		}
+		buf = append(buf, ',')
+		buf = append(buf, raw...)
+	}
+	buf = append(buf, ']')
+	if len(body) > 2 {
+		buf = append(buf, ',')
+	}
+	return append(buf, body[1:]...), nil
+}
+
+// UnmarshalJSONLD parses the JSON-LD representation into doc. The "@context"
+// property must be present, with V1 as the first entry. Any other entries are
+// kept as Contexts.
+func (doc *Document) UnmarshalJSONLD(data []byte) error {
+	var head struct {
+		Context json.RawMessage `json:"@context"`
+	}
+	err := json.Unmarshal(data, &head)
+	if err != nil {
+		return err
+	}
+
+	var contexts []json.RawMessage
+	switch {
+	case len(head.Context) == 0 || string(head.Context) == "null":
+		return errors.New(`DID document JSON-LD has no "@context"`)
+	case head.Context[0] == '"':
+		contexts = []json.RawMessage{head.Context}
+	case head.Context[0] == '[':
+		err := json.Unmarshal(head.Context, &contexts)
+		if err != nil {
+			return fmt.Errorf(`DID document JSON-LD "@context": %w`, err)
+		}
+	default:
+		return fmt.Errorf(`JSON start %q of DID document "@context" is not a string nor an array`, head.Context[0])
+	}
+
+	var first string
+	if len(contexts) == 0 || json.Unmarshal(contexts[0], &first) != nil || first != V1 {
+		return fmt.Errorf(`DID document JSON-LD "@context" does not start with %q`, V1)
+	}
+	for _, raw := range contexts[1:] {
+		if !validContext(raw) {
+			return fmt.Errorf(`DID document JSON-LD "@context" entry %s is not a string nor an object`, raw)
+		}
+	}
+
+	err = json.Unmarshal(data, doc)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 1 {
+		doc.Contexts = contexts[1:]
+	} else {
+		doc.Contexts = nil
+	}
+	return nil
+}
+
+// ValidContext returns whether raw is a JSON string or a JSON object.
+func validContext(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) != 0 && (raw[0] == '"' || raw[0] == '{') && json.Valid(raw)
+}
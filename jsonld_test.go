@@ -0,0 +1,88 @@
+package did_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleDocument_MarshalJSONLD() {
+	doc := did.Document{
+		Subject:  did.DID{Method: "example", SpecID: "123"},
+		Contexts: []json.RawMessage{json.RawMessage(`"https://w3id.org/security/multikey/v1"`)},
+	}
+	out, err := doc.MarshalJSONLD()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", out)
+	// Output:
+	// {"@context":["https://www.w3.org/ns/did/v1","https://w3id.org/security/multikey/v1"],"id":"did:example:123"}
+}
+
+func TestDocumentJSONLD(t *testing.T) {
+	var doc did.Document
+	err := doc.UnmarshalJSONLD([]byte(example9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Contexts) != 1 || string(doc.Contexts[0]) != `"https://w3id.org/security/suites/ed25519-2020/v1"` {
+		t.Errorf("got contexts %q, want the Ed25519 suite only", doc.Contexts)
+	}
+	if len(doc.VerificationMethods) != 1 {
+		t.Errorf("got %d verification methods, want 1", len(doc.VerificationMethods))
+	}
+
+	out, err := doc.MarshalJSONLD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again did.Document
+	if err := again.UnmarshalJSONLD(out); err != nil {
+		t.Fatalf("round trip error: %s; JSON-LD %s", err, out)
+	}
+	if len(again.Contexts) != 1 || string(again.Contexts[0]) != string(doc.Contexts[0]) {
+		t.Errorf("got contexts %q after round trip, want %q", again.Contexts, doc.Contexts)
+	}
+
+	// single string and embedded contexts
+	err = doc.UnmarshalJSONLD([]byte(`{"@context": "https://www.w3.org/ns/did/v1", "id": "did:example:123"}`))
+	if err != nil || doc.Contexts != nil {
+		t.Errorf("got contexts %q, error %v, want none", doc.Contexts, err)
+	}
+	err = doc.UnmarshalJSONLD([]byte(`{"@context": ["https://www.w3.org/ns/did/v1", {"@vocab": "https://example.com/#"}], "id": "did:example:123"}`))
+	if err != nil || len(doc.Contexts) != 1 || string(doc.Contexts[0]) != `{"@vocab": "https://example.com/#"}` {
+		t.Errorf("got contexts %q, error %v, want the embedded one", doc.Contexts, err)
+	}
+}
+
+func TestUnmarshalJSONLDErrors(t *testing.T) {
+	tests := []struct{ doc, want string }{
+		{`{"id": "did:example:123"}`, `no "@context"`},
+		{`{"@context": null, "id": "did:example:123"}`, `no "@context"`},
+		{`{"@context": [], "id": "did:example:123"}`, `does not start with`},
+		{`{"@context": ["https://w3id.org/security/multikey/v1", "https://www.w3.org/ns/did/v1"], "id": "did:example:123"}`, `does not start with`},
+		{`{"@context": "https://w3id.org/did/v1", "id": "did:example:123"}`, `does not start with`},
+		{`{"@context": ["https://www.w3.org/ns/did/v1", 42], "id": "did:example:123"}`, `not a string nor an object`},
+		{`{"@context": {"@vocab": "https://example.com/#"}, "id": "did:example:123"}`, `not a string nor an array`},
+	}
+	for _, test := range tests {
+		var doc did.Document
+		err := doc.UnmarshalJSONLD([]byte(test.doc))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.doc, err, test.want)
+		}
+	}
+
+	doc := did.Document{
+		Subject:  did.DID{Method: "example", SpecID: "123"},
+		Contexts: []json.RawMessage{json.RawMessage(`["nested"]`)},
+	}
+	if _, err := doc.MarshalJSONLD(); err == nil {
+		t.Error("marshal of an array context got no error")
+	}
+}
@@ -0,0 +1,208 @@
+package did
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a JSON Web Key (RFC 7517) with public key parameters only. Binary
+// values are in base64url encoding without padding.
+type JWK struct {
+	KeyType string `json:"kty"` // required
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+}
+
+// NewJWK returns the JSON Web Key of a public key. Supported types are
+// ed25519.PublicKey, *ecdh.PublicKey for X25519, *ecdsa.PublicKey for P-256,
+// P-384 and P-521, and *rsa.PublicKey.
+func NewJWK(key crypto.PublicKey) (*JWK, error) {
+	enc := base64.RawURLEncoding
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("JWK: Ed25519 public key of %d bytes", len(key))
+		}
+		return &JWK{KeyType: "OKP", Curve: "Ed25519", X: enc.EncodeToString(key)}, nil
+
+	case *ecdh.PublicKey:
+		if key.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("JWK: ECDH curve %s not supported", key.Curve())
+		}
+		return &JWK{KeyType: "OKP", Curve: "X25519", X: enc.EncodeToString(key.Bytes())}, nil
+
+	case *ecdsa.PublicKey:
+		var crv string
+		switch key.Curve {
+		case elliptic.P256():
+			crv = "P-256"
+		case elliptic.P384():
+			crv = "P-384"
+		case elliptic.P521():
+			crv = "P-521"
+		default:
+			return nil, fmt.Errorf("JWK: ECDSA curve %s not supported", key.Curve.Params().Name)
+		}
+		// “The length of this octet string MUST be the full size of a
+		// coordinate for the curve specified in the "crv" parameter.”
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			KeyType: "EC",
+			Curve:   crv,
+			X:       enc.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:       enc.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+
+	case *rsa.PublicKey:
+		return &JWK{
+			KeyType: "RSA",
+			N:       enc.EncodeToString(key.N.Bytes()),
+			E:       enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("JWK: key type %T not supported", key)
+	}
+}
+
+// PublicKey returns the key as either an ed25519.PublicKey, an *ecdh.PublicKey
+// for X25519, an *ecdsa.PublicKey or an *rsa.PublicKey.
+func (jwk *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "OKP":
+		x, err := jwk.param("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		switch jwk.Curve {
+		case "Ed25519":
+			if len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("JWK: Ed25519 public key of %d bytes", len(x))
+			}
+			return ed25519.PublicKey(x), nil
+		case "X25519":
+			key, err := ecdh.X25519().NewPublicKey(x)
+			if err != nil {
+				return nil, fmt.Errorf("JWK: %w", err)
+			}
+			return key, nil
+		default:
+			return nil, fmt.Errorf("JWK: OKP curve %q not supported", jwk.Curve)
+		}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("JWK: EC curve %q not supported", jwk.Curve)
+		}
+		x, err := jwk.param("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := jwk.param("y", jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("JWK: %s coordinates of %d and %d bytes, want %d", jwk.Curve, len(x), len(y), size)
+		}
+		// uncompressed form validates the point
+		point := append(append([]byte{4}, x...), y...)
+		X, Y := elliptic.Unmarshal(curve, point)
+		if X == nil {
+			return nil, fmt.Errorf("JWK: %s point not on curve", jwk.Curve)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: X, Y: Y}, nil
+
+	case "RSA":
+		n, err := jwk.param("n", jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := jwk.param("e", jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) > 4 || n[0] == 0 || e[0] == 0 {
+			return nil, errors.New("JWK: RSA parameters malformed")
+		}
+		E := new(big.Int).SetBytes(e).Int64()
+		if E < 2 || E > 1<<31-1 {
+			return nil, fmt.Errorf("JWK: RSA exponent %d out of range", E)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(E)}, nil
+
+	case "":
+		return nil, errors.New(`JWK: no "kty"`)
+	default:
+		return nil, fmt.Errorf("JWK: key type %q not supported", jwk.KeyType)
+	}
+}
+
+// Param decodes a required binary parameter.
+func (jwk *JWK) param(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("JWK: %s key has no %q", jwk.KeyType, name)
+	}
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("JWK: %s key %q: %w", jwk.KeyType, name, err)
+	}
+	return bytes, nil
+}
+
+// JWK returns the "publicKeyJwk" property, if any. The return is nil when the
+// property is absent.
+func (m *VerificationMethod) JWK() (*JWK, error) {
+	raw, ok := m.Additional["publicKeyJwk"]
+	if !ok {
+		return nil, nil
+	}
+	jwk := new(JWK)
+	err := json.Unmarshal([]byte(raw), jwk)
+	if err != nil {
+		return nil, fmt.Errorf("DID verification-method publicKeyJwk: %w", err)
+	}
+	return jwk, nil
+}
+
+// SetJWK sets the "publicKeyJwk" property. A nil jwk removes the property.
+func (m *VerificationMethod) SetJWK(jwk *JWK) error {
+	if jwk == nil {
+		delete(m.Additional, "publicKeyJwk")
+		return nil
+	}
+	bytes, err := json.Marshal(jwk)
+	if err != nil {
+		return err
+	}
+	if m.Additional == nil {
+		m.Additional = make(map[string]json.RawMessage)
+	}
+	m.Additional["publicKeyJwk"] = json.RawMessage(bytes)
+	return nil
+}
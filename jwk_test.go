@@ -0,0 +1,185 @@
+package did_test
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleVerificationMethod_JWK() {
+	var m did.VerificationMethod
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123#key-0",
+		"type": "JsonWebKey2020",
+		"controller": "did:example:123",
+		"publicKeyJwk": {
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+		}
+	}`), &m)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	jwk, err := m.JWK()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%T %x\n", key, key)
+	// Output:
+	// ed25519.PublicKey d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a
+}
+
+func TestJWKRFC7517(t *testing.T) {
+	// Example A.1 from RFC 7517
+	jwk := did.JWK{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+		Y:       "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatalf("got key type %T, want *ecdsa.PublicKey", key)
+	}
+	got, err := did.NewJWK(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != jwk {
+		t.Errorf("got %+v, want %+v", got, jwk)
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []crypto.PublicKey{edKey, xKey.PublicKey()}
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, &ecKey.PublicKey)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = append(keys, &rsaKey.PublicKey)
+
+	for _, key := range keys {
+		jwk, err := did.NewJWK(key)
+		if err != nil {
+			t.Errorf("%T: %s", key, err)
+			continue
+		}
+
+		m := did.VerificationMethod{
+			ID:         did.URL{DID: did.DID{Method: "example", SpecID: "123"}, RawFragment: "#key-0"},
+			Type:       "JsonWebKey2020",
+			Controller: did.DID{Method: "example", SpecID: "123"},
+		}
+		if err := m.SetJWK(jwk); err != nil {
+			t.Fatal(err)
+		}
+		bytes, err := json.Marshal(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded did.VerificationMethod
+		if err := json.Unmarshal(bytes, &decoded); err != nil {
+			t.Fatalf("%T: %s", key, err)
+		}
+		got, err := decoded.JWK()
+		if err != nil {
+			t.Fatalf("%T: %s", key, err)
+		}
+		if got == nil || *got != *jwk {
+			t.Errorf("%T: got JWK %+v, want %+v", key, got, jwk)
+			continue
+		}
+
+		back, err := got.PublicKey()
+		if err != nil {
+			t.Errorf("%T: %s", key, err)
+			continue
+		}
+		if !back.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Errorf("%T: got public key %v, want %v", key, back, key)
+		}
+	}
+}
+
+func TestJWKErrors(t *testing.T) {
+	tests := []did.JWK{
+		{},
+		{KeyType: "oct"},
+		{KeyType: "OKP", Curve: "Ed448", X: "AAAA"},
+		{KeyType: "OKP", Curve: "Ed25519"},
+		{KeyType: "OKP", Curve: "Ed25519", X: "AAAA"},
+		{KeyType: "OKP", Curve: "Ed25519", X: "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{KeyType: "EC", Curve: "secp256k1", X: "AAAA", Y: "AAAA"},
+		{KeyType: "EC", Curve: "P-256", X: "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4"},
+		// y-coordinate off by one
+		{KeyType: "EC", Curve: "P-256", X: "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4", Y: "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyI"},
+		{KeyType: "RSA", N: "AQAB"},
+		{KeyType: "RSA", N: "AQAB", E: "AA"},
+	}
+	for _, jwk := range tests {
+		key, err := jwk.PublicKey()
+		if err == nil {
+			t.Errorf("%+v got %T, want error", jwk, key)
+		}
+	}
+
+	if _, err := did.NewJWK("not a key"); err == nil {
+		t.Error("NewJWK got no error for string")
+	}
+}
+
+func TestVerificationMethodJWKAbsent(t *testing.T) {
+	var m did.VerificationMethod
+	jwk, err := m.JWK()
+	if jwk != nil || err != nil {
+		t.Errorf("got (%v, %v), want (nil, nil)", jwk, err)
+	}
+
+	m.Additional = map[string]json.RawMessage{"publicKeyJwk": json.RawMessage(`"oops"`)}
+	if _, err := m.JWK(); err == nil {
+		t.Error("got no error for string JWK")
+	}
+	if err := m.SetJWK(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Additional["publicKeyJwk"]; ok {
+		t.Error("publicKeyJwk present after SetJWK(nil)")
+	}
+}
@@ -0,0 +1,380 @@
+// Package jws implements JSON Web Signature (RFC 7515) with DID URLs as key
+// identifiers. The "kid" header parameter identifies a verification method
+// within the DID document of the signer.
+package jws
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // link crypto.SHA256
+	_ "crypto/sha512" // link crypto.SHA384 and crypto.SHA512
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pascaldekloe/did"
+)
+
+// Algorithm names from RFC 7518 and RFC 8037.
+const (
+	EdDSA = "EdDSA"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+)
+
+// ErrAlgorithm signals an "alg" header parameter not supported, or one which
+// does not match the key type.
+var ErrAlgorithm = errors.New("JWS algorithm not supported")
+
+// Header is the JOSE header with the registered parameters of interest.
+type Header struct {
+	Algorithm   string   `json:"alg"`
+	KeyID       string   `json:"kid,omitempty"`
+	Type        string   `json:"typ,omitempty"`
+	ContentType string   `json:"cty,omitempty"`
+	Critical    []string `json:"crit,omitempty"`
+}
+
+// KeyURL returns the "kid" as a DID URL.
+func (h *Header) KeyURL() (*did.URL, error) {
+	if h.KeyID == "" {
+		return nil, errors.New("JWS header has no kid")
+	}
+	u, err := did.ParseURL(h.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("JWS header kid: %w", err)
+	}
+	if u.IsRelative() {
+		return nil, fmt.Errorf("JWS header kid %q has no DID", h.KeyID)
+	}
+	return u, nil
+}
+
+// Sign returns the compact serialization of payload, signed with key. The
+// algorithm follows from the public key type, with RS256 for RSA. The "kid" is
+// set to the identifier of verification method m.
+func Sign(payload []byte, key crypto.Signer, m *did.VerificationMethod) (string, error) {
+	return SignHeader(&Header{}, payload, key, m)
+}
+
+// SignHeader is like Sign, yet it includes the parameters from h. Both the
+// "alg" and the "kid" in h are overwritten.
+func SignHeader(h *Header, payload []byte, key crypto.Signer, m *did.VerificationMethod) (string, error) {
+	if m.ID.IsRelative() {
+		return "", fmt.Errorf("JWS verification method %s has no DID", m.ID.String())
+	}
+
+	header := *h // copy
+	header.KeyID = m.ID.String()
+	switch pub := key.Public().(type) {
+	case ed25519.PublicKey:
+		header.Algorithm = EdDSA
+	case *ecdsa.PublicKey:
+		switch pub.Curve.Params().BitSize {
+		case 256:
+			header.Algorithm = ES256
+		case 384:
+			header.Algorithm = ES384
+		case 521:
+			header.Algorithm = ES512
+		default:
+			return "", fmt.Errorf("%w: ECDSA curve %s", ErrAlgorithm, pub.Curve.Params().Name)
+		}
+	case *rsa.PublicKey:
+		header.Algorithm = RS256
+	default:
+		return "", fmt.Errorf("%w: key type %T", ErrAlgorithm, pub)
+	}
+
+	headerJSON, err := json.Marshal(&header)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(headerJSON) + "." + enc.EncodeToString(payload)
+
+	sig, err := sign(header.Algorithm, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func sign(alg string, key crypto.Signer, signingInput []byte) ([]byte, error) {
+	if alg == EdDSA {
+		return key.Sign(rand.Reader, signingInput, crypto.Hash(0))
+	}
+
+	hash := hashOf(alg)
+	digest := hash.New()
+	digest.Write(signingInput)
+	sig, err := key.Sign(rand.Reader, digest.Sum(nil), hash)
+	if err != nil {
+		return nil, fmt.Errorf("JWS signature: %w", err)
+	}
+	if pub, ok := key.Public().(*ecdsa.PublicKey); ok {
+		// ASN.1 DER to R‖S
+		var rs struct{ R, S *big.Int }
+		rest, err := asn1.Unmarshal(sig, &rs)
+		if err != nil || len(rest) != 0 {
+			return nil, errors.New("JWS ECDSA signature not in ASN.1 DER")
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		rs.R.FillBytes(sig[:size])
+		rs.S.FillBytes(sig[size:])
+	}
+	return sig, nil
+}
+
+// HashOf returns the hash function of an RSA or ECDSA algorithm.
+func hashOf(alg string) crypto.Hash {
+	switch alg {
+	case ES256, RS256, PS256:
+		return crypto.SHA256
+	case ES384, RS384, PS384:
+		return crypto.SHA384
+	default:
+		return crypto.SHA512
+	}
+}
+
+// Verifier checks signatures with verification methods from DID documents.
+type Verifier struct {
+	// Resolve retrieves the DID document of each "kid".
+	Resolve did.Resolve
+
+	// Purpose is the verification relationship which the key must be in,
+	// e.g., did.AssertionMethod or did.Authentication.
+	Purpose string
+}
+
+// VerifyCompact checks a JWS in compact serialization. The "kid" must be a DID
+// URL to a verification method in the relationship of v.Purpose. Errors include
+// did.ErrSignature for mismatches, did.ErrNotAuthorized for keys not in the
+// verification relationship, and any of the errors from v.Resolve.
+func (v *Verifier) VerifyCompact(s string) (payload []byte, h *Header, err error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("JWS compact serialization has %d parts, want 3", len(parts))
+	}
+
+	h, err = decodeHeader(parts[0], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("JWS payload: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("JWS signature: %w", err)
+	}
+
+	err = v.verify(h, []byte(s[:len(parts[0])+1+len(parts[1])]), sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, h, nil
+}
+
+// VerifyJSON checks a JWS in either the general or the flattened JSON
+// serialization. Verification passes when any of the signatures verifies, with
+// h as the merged header (protected and unprotected) of the first such
+// signature. See VerifyCompact for the error conditions.
+func (v *Verifier) VerifyJSON(serial []byte) (payload []byte, h *Header, err error) {
+	type signature struct {
+		Protected string          `json:"protected"`
+		Header    json.RawMessage `json:"header"`
+		Signature string          `json:"signature"`
+	}
+	var fields struct {
+		Payload    *string     `json:"payload"`
+		Signatures []signature `json:"signatures"`
+		signature
+	}
+	err = json.Unmarshal(serial, &fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("JWS JSON serialization: %w", err)
+	}
+	if fields.Payload == nil {
+		return nil, nil, errors.New("JWS JSON serialization has no payload")
+	}
+	payload, err = base64.RawURLEncoding.DecodeString(*fields.Payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("JWS payload: %w", err)
+	}
+
+	sigs := fields.Signatures
+	if fields.Signature != "" {
+		if len(sigs) != 0 {
+			return nil, nil, errors.New("JWS JSON serialization mixes general and flattened syntax")
+		}
+		sigs = []signature{fields.signature}
+	}
+	if len(sigs) == 0 {
+		return nil, nil, errors.New("JWS JSON serialization has no signatures")
+	}
+
+	var errs []error
+	for _, s := range sigs {
+		h, err := decodeHeader(s.Protected, s.Header)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(s.Signature)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("JWS signature: %w", err))
+			continue
+		}
+		err = v.verify(h, []byte(s.Protected+"."+*fields.Payload), sig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return payload, h, nil
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+// DecodeHeader parses the protected header, and it merges any unprotected
+// parameters in.
+func decodeHeader(protected string, unprotected json.RawMessage) (*Header, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return nil, fmt.Errorf("JWS protected header: %w", err)
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("JWS protected header: %w", err)
+	}
+
+	if len(unprotected) != 0 && !bytes.Equal(unprotected, []byte("null")) {
+		var extra map[string]json.RawMessage
+		if err := json.Unmarshal(unprotected, &extra); err != nil {
+			return nil, fmt.Errorf("JWS unprotected header: %w", err)
+		}
+		for name, value := range extra {
+			if name == "crit" {
+				return nil, errors.New("JWS crit in unprotected header")
+			}
+			if _, ok := params[name]; ok {
+				return nil, fmt.Errorf("JWS header parameter %q both protected and unprotected", name)
+			}
+			if params == nil {
+				params = make(map[string]json.RawMessage)
+			}
+			params[name] = value
+		}
+	}
+
+	merged, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	h := new(Header)
+	if err := json.Unmarshal(merged, h); err != nil {
+		return nil, fmt.Errorf("JWS header: %w", err)
+	}
+	// “Recipients MAY consider the JWS to be invalid if the critical list
+	// contains any Header Parameter names defined by this specification”
+	if len(h.Critical) != 0 {
+		return nil, fmt.Errorf("JWS critical header parameters %q not supported", h.Critical)
+	}
+	return h, nil
+}
+
+func (v *Verifier) verify(h *Header, signingInput, sig []byte) error {
+	u, err := h.KeyURL()
+	if err != nil {
+		return err
+	}
+	doc, _, err := v.Resolve(u.DID)
+	if err != nil {
+		return fmt.Errorf("JWS kid %s: %w", h.KeyID, err)
+	}
+	m, err := doc.MethodFor(v.Purpose, u)
+	if err != nil {
+		return fmt.Errorf("JWS kid %s: %w", h.KeyID, err)
+	}
+	key, err := m.PublicKey()
+	if err != nil {
+		return fmt.Errorf("JWS kid %s: %w", h.KeyID, err)
+	}
+	return verifySignature(h.Algorithm, key, signingInput, sig)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput, sig []byte) error {
+	switch alg {
+	case EdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s with %T", ErrAlgorithm, alg, key)
+		}
+		if !ed25519.Verify(pub, signingInput, sig) {
+			return did.ErrSignature
+		}
+		return nil
+
+	case ES256, ES384, ES512:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s with %T", ErrAlgorithm, alg, key)
+		}
+		bitSize := pub.Curve.Params().BitSize
+		if (alg == ES256) != (bitSize == 256) || (alg == ES384) != (bitSize == 384) || (alg == ES512) != (bitSize == 521) {
+			return fmt.Errorf("%w: %s with curve %s", ErrAlgorithm, alg, pub.Curve.Params().Name)
+		}
+		size := (bitSize + 7) / 8
+		if len(sig) != 2*size {
+			return did.ErrSignature
+		}
+		digest := hashOf(alg).New()
+		digest.Write(signingInput)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest.Sum(nil), r, s) {
+			return did.ErrSignature
+		}
+		return nil
+
+	case RS256, RS384, RS512, PS256, PS384, PS512:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s with %T", ErrAlgorithm, alg, key)
+		}
+		hash := hashOf(alg)
+		digest := hash.New()
+		digest.Write(signingInput)
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest.Sum(nil), sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return did.ErrSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %q", ErrAlgorithm, alg)
+	}
+}
@@ -0,0 +1,235 @@
+package jws_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/jws"
+)
+
+var subject = did.DID{Method: "example", SpecID: "123"}
+
+// NewTestDoc returns a DID document with a verification method for each key.
+// The first key is for assertions, the second for authentication, and any
+// others for key agreement.
+func newTestDoc(t testing.TB, keys ...crypto.PublicKey) (*did.Document, did.Resolve) {
+	doc := &did.Document{Subject: subject}
+	for i, key := range keys {
+		m := &did.VerificationMethod{
+			ID:         did.URL{DID: subject},
+			Type:       "JsonWebKey2020",
+			Controller: subject,
+		}
+		m.ID.SetFragment(fmt.Sprintf("key-%d", i+1))
+		jwk, err := did.NewJWK(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetJWK(jwk); err != nil {
+			t.Fatal(err)
+		}
+		doc.VerificationMethods = append(doc.VerificationMethods, m)
+
+		ref := &did.VerificationRelationship{URIRefs: []*did.URL{&m.ID}}
+		switch i {
+		case 0:
+			doc.AssertionMethod = ref
+		case 1:
+			doc.Authentication = ref
+		default:
+			doc.KeyAgreement = ref
+		}
+	}
+
+	resolve := func(d did.DID) (*did.Document, *did.Meta, error) {
+		if d != subject {
+			return nil, nil, did.ErrNotFound
+		}
+		return doc, new(did.Meta), nil
+	}
+	return doc, resolve
+}
+
+func Example() {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, resolve := newTestDoc(nil, public)
+
+	token, err := jws.Sign([]byte("hello"), private, doc.VerificationMethods[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	v := jws.Verifier{Resolve: resolve, Purpose: did.AssertionMethod}
+	payload, header, err := v.VerifyCompact(token)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s signed by %s with %s\n", payload, header.KeyID, header.Algorithm)
+
+	v.Purpose = did.Authentication
+	_, _, err = v.VerifyCompact(token)
+	fmt.Println("authentication denied:", errors.Is(err, did.ErrNotAuthorized))
+	// Output:
+	// hello signed by did:example:123#key-1 with EdDSA
+	// authentication denied: true
+}
+
+func TestSignAlgorithms(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []crypto.Signer{edKey}
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, key)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers = append(signers, rsaKey)
+
+	for _, signer := range signers {
+		doc, resolve := newTestDoc(t, signer.Public())
+		token, err := jws.SignHeader(&jws.Header{Type: "test"}, []byte("payload"), signer, doc.VerificationMethods[0])
+		if err != nil {
+			t.Errorf("%T: %s", signer, err)
+			continue
+		}
+
+		v := jws.Verifier{Resolve: resolve, Purpose: did.AssertionMethod}
+		payload, h, err := v.VerifyCompact(token)
+		if err != nil {
+			t.Errorf("%T: %s", signer, err)
+			continue
+		}
+		if string(payload) != "payload" || h.Type != "test" {
+			t.Errorf("%T: got payload %q with header %+v", signer, payload, h)
+		}
+
+		// flip a bit in the signature
+		i := strings.LastIndexByte(token, '.') + 1
+		sig, err := base64.RawURLEncoding.DecodeString(token[i:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig[0] ^= 1
+		tampered := token[:i] + base64.RawURLEncoding.EncodeToString(sig)
+		if _, _, err := v.VerifyCompact(tampered); !errors.Is(err, did.ErrSignature) {
+			t.Errorf("%T: tampered signature got error %v, want did.ErrSignature", signer, err)
+		}
+	}
+}
+
+func TestVerifyJSON(t *testing.T) {
+	_, assertKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, resolve := newTestDoc(t, assertKey.Public(), authKey.Public())
+
+	assertToken, err := jws.Sign([]byte("payload"), assertKey, doc.VerificationMethods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	authToken, err := jws.Sign([]byte("payload"), authKey, doc.VerificationMethods[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := strings.Split(assertToken, ".")
+	b := strings.Split(authToken, ".")
+
+	general := fmt.Sprintf(`{"payload":%q,"signatures":[{"protected":%q,"signature":%q},{"protected":%q,"header":{"typ":"test"},"signature":%q}]}`,
+		a[1], a[0], a[2], b[0], b[2])
+	flattened := fmt.Sprintf(`{"payload":%q,"protected":%q,"signature":%q}`, b[1], b[0], b[2])
+
+	tests := []struct {
+		serial  string
+		purpose string
+		wantAlg string
+		wantErr error
+	}{
+		{general, did.AssertionMethod, jws.EdDSA, nil},
+		{general, did.Authentication, jws.ES256, nil},
+		{general, did.CapabilityInvocation, "", did.ErrNotAuthorized},
+		{flattened, did.Authentication, jws.ES256, nil},
+		{flattened, did.AssertionMethod, "", did.ErrNotAuthorized},
+	}
+	for _, test := range tests {
+		v := jws.Verifier{Resolve: resolve, Purpose: test.purpose}
+		payload, h, err := v.VerifyJSON([]byte(test.serial))
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s for %s got error %v, want %v", test.serial, test.purpose, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s for %s got error: %s", test.serial, test.purpose, err)
+			continue
+		}
+		if string(payload) != "payload" || h.Algorithm != test.wantAlg {
+			t.Errorf("%s for %s got payload %q with header %+v, want algorithm %s", test.serial, test.purpose, payload, h, test.wantAlg)
+		}
+	}
+}
+
+func TestVerifyCompactErrors(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, resolve := newTestDoc(t, key.Public())
+	v := jws.Verifier{Resolve: resolve, Purpose: did.AssertionMethod}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	tests := []struct {
+		header string
+		want   error
+	}{
+		{`{"alg":"none","kid":"did:example:123#key-1"}`, jws.ErrAlgorithm},
+		{`{"alg":"ES256","kid":"did:example:123#key-1"}`, jws.ErrAlgorithm},
+		{`{"alg":"EdDSA","kid":"did:example:456#key-1"}`, did.ErrNotFound},
+		{`{"alg":"EdDSA","kid":"did:example:123#key-2"}`, did.ErrNotAuthorized},
+		{`{"alg":"EdDSA","kid":"#key-1"}`, nil},
+		{`{"alg":"EdDSA"}`, nil},
+		{`{"alg":"EdDSA","kid":"did:example:123#key-1","crit":["b64"],"b64":false}`, nil},
+	}
+	for _, test := range tests {
+		token := enc([]byte(test.header)) + "." + enc([]byte("payload")) + "." + enc(make([]byte, 64))
+		_, _, err := v.VerifyCompact(token)
+		if err == nil {
+			t.Errorf("header %s got no error", test.header)
+		} else if test.want != nil && !errors.Is(err, test.want) {
+			t.Errorf("header %s got error %v, want %v", test.header, err, test.want)
+		}
+	}
+
+	if _, _, err := v.VerifyCompact("a.b"); err == nil {
+		t.Error("two parts got no error")
+	}
+}
@@ -0,0 +1,316 @@
+// Package jwt implements JSON Web Tokens (RFC 7519) issued by DID subjects. The
+// "kid" of each token must identify a verification method of the issuer.
+package jwt
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/sorted"
+	"github.com/pascaldekloe/did/jws"
+)
+
+// Validation errors distinguish between the claims checked.
+var (
+	ErrExpired     = errors.New("JWT expired")
+	ErrNotYetValid = errors.New("JWT not valid yet")
+	ErrAudience    = errors.New("JWT audience not accepted")
+	ErrIssuer      = errors.New("JWT issuer does not match key identifier")
+)
+
+// Claims are the JWT claims with the registered claim names as fields. Times
+// are zero when absent.
+type Claims struct {
+	Issuer    string    // "iss"
+	Subject   string    // "sub"
+	Audiences []string  // "aud"
+	Expires   time.Time // "exp"
+	NotBefore time.Time // "nbf"
+	Issued    time.Time // "iat"
+	ID        string    // "jti"
+
+	// Additional has any other claims.
+	Additional map[string]json.RawMessage
+}
+
+// IssuerDID returns the issuer as a DID.
+func (c *Claims) IssuerDID() (did.DID, error) {
+	if c.Issuer == "" {
+		return did.DID{}, errors.New("JWT has no iss")
+	}
+	d, err := did.Parse(c.Issuer)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("JWT iss: %w", err)
+	}
+	return d, nil
+}
+
+// AcceptAudience returns whether the audience includes s.
+func (c *Claims) AcceptAudience(s string) bool {
+	for _, aud := range c.Audiences {
+		if aud == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AdditionalString returns the value if, and only if the claim is present, and
+// its value is a valid JSON string.
+func (c *Claims) AdditionalString(name string) string {
+	raw, ok := c.Additional[name]
+	if !ok {
+		return ""
+	}
+	var s string
+	err := json.Unmarshal([]byte(raw), &s)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// MarshalJSON implements the json.Marshaler interface. A single audience is
+// written as a string, rather than an array.
+func (c *Claims) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 1, 256)
+	buf[0] = '{'
+	appendName := func(name string) {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, name)
+		buf = append(buf, ':')
+	}
+
+	for _, p := range [...]struct{ name, value string }{
+		{"iss", c.Issuer},
+		{"sub", c.Subject},
+		{"jti", c.ID},
+	} {
+		if p.value != "" {
+			appendName(p.name)
+			buf = strconv.AppendQuote(buf, p.value)
+		}
+	}
+
+	switch len(c.Audiences) {
+	case 0:
+		break
+	case 1:
+		appendName("aud")
+		buf = strconv.AppendQuote(buf, c.Audiences[0])
+	default:
+		appendName("aud")
+		for i, aud := range c.Audiences {
+			if i == 0 {
+				buf = append(buf, '[')
+			} else {
+				buf = append(buf, ',')
+			}
+			buf = strconv.AppendQuote(buf, aud)
+		}
+		buf = append(buf, ']')
+	}
+
+	for _, p := range [...]struct {
+		name  string
+		value time.Time
+	}{
+		{"exp", c.Expires},
+		{"nbf", c.NotBefore},
+		{"iat", c.Issued},
+	} {
+		if !p.value.IsZero() {
+			appendName(p.name)
+			buf = strconv.AppendInt(buf, p.value.Unix(), 10)
+		}
+	}
+
+	for _, name := range sorted.Keys(c.Additional) {
+		value := c.Additional[name]
+		switch name {
+		case "iss", "sub", "aud", "exp", "nbf", "iat", "jti":
+			return nil, fmt.Errorf("registered JWT claim %q in additional set", name)
+		}
+		appendName(name)
+		buf = append(buf, value...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *Claims) UnmarshalJSON(bytes []byte) error {
+	// Read all claims as Additional first.
+	*c = Claims{}
+	err := json.Unmarshal(bytes, &c.Additional)
+	if err != nil {
+		return fmt.Errorf("JWT claims: %w", err)
+	}
+
+	// Second, extract the registered claims from Additional.
+	for _, p := range [...]struct {
+		name    string
+		pointer *string
+	}{
+		{"iss", &c.Issuer},
+		{"sub", &c.Subject},
+		{"jti", &c.ID},
+	} {
+		if err := c.popClaimInto(p.name, p.pointer); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := c.Additional["aud"]; ok && len(raw) != 0 && raw[0] == '"' {
+		c.Audiences = make([]string, 1)
+		err = c.popClaimInto("aud", &c.Audiences[0])
+	} else {
+		err = c.popClaimInto("aud", &c.Audiences)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, p := range [...]struct {
+		name    string
+		pointer *time.Time
+	}{
+		{"exp", &c.Expires},
+		{"nbf", &c.NotBefore},
+		{"iat", &c.Issued},
+	} {
+		var seconds *float64
+		if err := c.popClaimInto(p.name, &seconds); err != nil {
+			return err
+		}
+		if seconds == nil {
+			continue
+		}
+		if math.IsNaN(*seconds) || math.Abs(*seconds) > 1<<53 {
+			return fmt.Errorf("JWT claim %q out of range", p.name)
+		}
+		whole, frac := math.Modf(*seconds)
+		*p.pointer = time.Unix(int64(whole), int64(frac*1e9))
+	}
+	return nil
+}
+
+// PopClaimInto unmarshals a registered claim, if present.
+func (c *Claims) popClaimInto(name string, pointer any) error {
+	raw, ok := c.Additional[name]
+	if !ok {
+		return nil
+	}
+	delete(c.Additional, name)
+
+	err := json.Unmarshal([]byte(raw), pointer)
+	if err != nil {
+		return fmt.Errorf("JWT claim %q: %w", name, err)
+	}
+	return nil
+}
+
+// Sign returns a compact serialization of the claims, signed with key. The
+// issuer defaults to the DID of verification method m, which also provides the
+// key identifier.
+func Sign(c *Claims, key crypto.Signer, m *did.VerificationMethod) (string, error) {
+	if c.Issuer == "" {
+		withIssuer := *c // copy
+		withIssuer.Issuer = m.ID.DID.String()
+		c = &withIssuer
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return jws.SignHeader(&jws.Header{Type: "JWT"}, payload, key, m)
+}
+
+// Verifier validates tokens with verification methods from DID documents.
+type Verifier struct {
+	// Resolve retrieves the DID document of each "kid".
+	Resolve did.Resolve
+
+	// Purpose is the verification relationship which the key must be in.
+	// Options are either did.AssertionMethod or did.Authentication.
+	Purpose string
+
+	// Audience is the required "aud" value, if any. Tokens with an "aud"
+	// claim are denied when Audience is not set, as per RFC 7519, section
+	// 4.1.3.
+	Audience string
+
+	// Leeway is the tolerance for clock skew on "exp" and "nbf".
+	Leeway time.Duration
+
+	// Now is the time of validation. The zero value defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verify checks the signature of token, and it validates its claims. The "kid"
+// must be a verification method of the issuer. Errors include ErrExpired,
+// ErrNotYetValid, ErrAudience and ErrIssuer, as well as the errors from
+// jws.Verifier.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	switch v.Purpose {
+	case did.AssertionMethod, did.Authentication:
+		break
+	default:
+		return nil, fmt.Errorf("JWT verification relationship %q not permitted", v.Purpose)
+	}
+
+	payload, h, err := (&jws.Verifier{Resolve: v.Resolve, Purpose: v.Purpose}).VerifyCompact(token)
+	if err != nil {
+		return nil, err
+	}
+	if h.Type != "" && !strings.EqualFold(h.Type, "JWT") {
+		return nil, fmt.Errorf("JWT header typ %q", h.Type)
+	}
+	if h.ContentType != "" {
+		return nil, fmt.Errorf("JWT header cty %q not supported", h.ContentType)
+	}
+
+	c := new(Claims)
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, err
+	}
+
+	issuer, err := c.IssuerDID()
+	if err != nil {
+		return nil, err
+	}
+	keyURL, err := h.KeyURL()
+	if err != nil {
+		return nil, err
+	}
+	if !keyURL.DID.Equal(issuer) {
+		return nil, fmt.Errorf("%w: iss %s with kid %s", ErrIssuer, c.Issuer, h.KeyID)
+	}
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if !c.Expires.IsZero() && !now.Before(c.Expires.Add(v.Leeway)) {
+		return nil, fmt.Errorf("%w: exp %s", ErrExpired, c.Expires.UTC().Format(time.RFC3339))
+	}
+	if !c.NotBefore.IsZero() && now.Add(v.Leeway).Before(c.NotBefore) {
+		return nil, fmt.Errorf("%w: nbf %s", ErrNotYetValid, c.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if len(c.Audiences) != 0 && (v.Audience == "" || !c.AcceptAudience(v.Audience)) {
+		return nil, fmt.Errorf("%w: aud %q", ErrAudience, c.Audiences)
+	}
+	if v.Audience != "" && len(c.Audiences) == 0 {
+		return nil, fmt.Errorf("%w: no aud", ErrAudience)
+	}
+	return c, nil
+}
@@ -0,0 +1,229 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/jws"
+	"github.com/pascaldekloe/did/jwt"
+)
+
+// NewTestIssuer returns a key with a DID document that has it for both
+// assertions and authentication, and a key that is for key agreement only.
+func newTestIssuer(t testing.TB, subject did.DID) (assertKey, agreeKey ed25519.PrivateKey, doc *did.Document, resolve did.Resolve) {
+	doc = &did.Document{Subject: subject}
+	for i := 0; i < 2; i++ {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &did.VerificationMethod{
+			ID:         did.URL{DID: subject, RawFragment: fmt.Sprintf("#key-%d", i+1)},
+			Type:       did.MultikeyType,
+			Controller: subject,
+		}
+		if err := m.SetPublicKeyMultibase(public); err != nil {
+			t.Fatal(err)
+		}
+		doc.VerificationMethods = append(doc.VerificationMethods, m)
+		ref := &did.VerificationRelationship{URIRefs: []*did.URL{&m.ID}}
+		if i == 0 {
+			assertKey = private
+			doc.AssertionMethod = ref
+			doc.Authentication = ref
+		} else {
+			agreeKey = private
+			doc.KeyAgreement = ref
+		}
+	}
+
+	resolve = func(d did.DID) (*did.Document, *did.Meta, error) {
+		if d != subject {
+			return nil, nil, did.ErrNotFound
+		}
+		return doc, new(did.Meta), nil
+	}
+	return
+}
+
+func Example() {
+	issuer := did.DID{Method: "example", SpecID: "issuer"}
+	key, _, doc, resolve := newTestIssuer(nil, issuer)
+
+	token, err := jwt.Sign(&jwt.Claims{
+		Subject:   "did:example:holder",
+		Audiences: []string{"https://api.example.com"},
+		Expires:   time.Now().Add(time.Minute),
+	}, key, doc.VerificationMethods[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	v := jwt.Verifier{
+		Resolve:  resolve,
+		Purpose:  did.Authentication,
+		Audience: "https://api.example.com",
+	}
+	claims, err := v.Verify(token)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("issuer:", claims.Issuer)
+	fmt.Println("subject:", claims.Subject)
+	// Output:
+	// issuer: did:example:issuer
+	// subject: did:example:holder
+}
+
+func TestClaimsJSON(t *testing.T) {
+	const sample = `{"iss":"did:example:123","sub":"alice","aud":["a","b"],"exp":1300819380,"nbf":1300819370.5,"http://example.com/is_root":true}`
+
+	var c jwt.Claims
+	if err := json.Unmarshal([]byte(sample), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Issuer != "did:example:123" || c.Subject != "alice" || len(c.Audiences) != 2 {
+		t.Errorf("got claims %+v", c)
+	}
+	if want := time.Unix(1300819380, 0); !c.Expires.Equal(want) {
+		t.Errorf("got exp %s, want %s", c.Expires, want)
+	}
+	if want := time.Unix(1300819370, 5e8); !c.NotBefore.Equal(want) {
+		t.Errorf("got nbf %s, want %s", c.NotBefore, want)
+	}
+	if !c.Issued.IsZero() {
+		t.Errorf("got iat %s, want zero", c.Issued)
+	}
+	if got := string(c.Additional["http://example.com/is_root"]); got != "true" {
+		t.Errorf("got additional claim %q, want true", got)
+	}
+	if len(c.Additional) != 1 {
+		t.Errorf("got additional claims %q, want 1", c.Additional)
+	}
+
+	bytes, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"iss":"did:example:123","sub":"alice","aud":["a","b"],"exp":1300819380,"nbf":1300819370,"http://example.com/is_root":true}`
+	if string(bytes) != want {
+		t.Errorf("got JSON %s\nwant %s", bytes, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"aud":"single"}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Audiences) != 1 || c.Audiences[0] != "single" {
+		t.Errorf("got audiences %q, want [single]", c.Audiences)
+	}
+	bytes, err = json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != `{"aud":"single"}` {
+		t.Errorf("got JSON %s, want single audience as string", bytes)
+	}
+}
+
+func TestClaimsJSONDeterministic(t *testing.T) {
+	c := jwt.Claims{
+		Issuer: "did:example:123",
+		Additional: map[string]json.RawMessage{
+			"nonce": json.RawMessage(`"n-0S6_WzA2Mj"`),
+			"scope": json.RawMessage(`"openid"`),
+			"acr":   json.RawMessage(`"0"`),
+			"azp":   json.RawMessage(`"s6BhdRkqt3"`),
+			"amr":   json.RawMessage(`["pwd"]`),
+		},
+	}
+	const want = `{"iss":"did:example:123","acr":"0","amr":["pwd"],"azp":"s6BhdRkqt3","nonce":"n-0S6_WzA2Mj","scope":"openid"}`
+	// map iteration order is random
+	for i := 0; i < 20; i++ {
+		bytes, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bytes) != want {
+			t.Fatalf("got JSON %s\nwant %s", bytes, want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	issuer := did.DID{Method: "example", SpecID: "issuer"}
+	key, agreeKey, doc, resolve := newTestIssuer(t, issuer)
+	_, otherKey, otherDoc, _ := newTestIssuer(t, did.DID{Method: "example", SpecID: "other"})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		claims  jwt.Claims
+		key     ed25519.PrivateKey
+		method  *did.VerificationMethod
+		purpose string
+		want    error
+	}{
+		{"valid", jwt.Claims{Audiences: []string{"me"}, Expires: now.Add(time.Second)}, key, doc.VerificationMethods[0], did.AssertionMethod, nil},
+		{"expired", jwt.Claims{Audiences: []string{"me"}, Expires: now.Add(-2 * time.Second)}, key, doc.VerificationMethods[0], did.AssertionMethod, jwt.ErrExpired},
+		{"expired in leeway", jwt.Claims{Audiences: []string{"me"}, Expires: now.Add(-time.Second)}, key, doc.VerificationMethods[0], did.AssertionMethod, nil},
+		{"not before", jwt.Claims{Audiences: []string{"me"}, NotBefore: now.Add(3 * time.Second)}, key, doc.VerificationMethods[0], did.AssertionMethod, jwt.ErrNotYetValid},
+		{"not before in leeway", jwt.Claims{Audiences: []string{"me"}, NotBefore: now.Add(2 * time.Second)}, key, doc.VerificationMethods[0], did.AssertionMethod, nil},
+		{"other audience", jwt.Claims{Audiences: []string{"you"}}, key, doc.VerificationMethods[0], did.AssertionMethod, jwt.ErrAudience},
+		{"no audience", jwt.Claims{}, key, doc.VerificationMethods[0], did.AssertionMethod, jwt.ErrAudience},
+		{"issuer mismatch", jwt.Claims{Issuer: "did:example:other", Audiences: []string{"me"}}, key, doc.VerificationMethods[0], did.AssertionMethod, jwt.ErrIssuer},
+		{"issuer not in kid", jwt.Claims{Issuer: "did:example:issuer", Audiences: []string{"me"}}, otherKey, otherDoc.VerificationMethods[0], did.AssertionMethod, did.ErrNotFound},
+		{"key agreement", jwt.Claims{Audiences: []string{"me"}}, agreeKey, doc.VerificationMethods[1], did.AssertionMethod, did.ErrNotAuthorized},
+		{"authentication", jwt.Claims{Audiences: []string{"me"}}, key, doc.VerificationMethods[0], did.Authentication, nil},
+	}
+	for _, test := range tests {
+		token, err := jwt.Sign(&test.claims, test.key, test.method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := jwt.Verifier{
+			Resolve:  resolve,
+			Purpose:  test.purpose,
+			Audience: "me",
+			Leeway:   2 * time.Second,
+			Now:      func() time.Time { return now },
+		}
+
+		_, err = v.Verify(token)
+		switch {
+		case test.want == nil && err != nil:
+			t.Errorf("%s: got error: %s", test.name, err)
+		case test.want != nil && !errors.Is(err, test.want):
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestVerifyPurpose(t *testing.T) {
+	key, _, doc, resolve := newTestIssuer(t, did.DID{Method: "example", SpecID: "issuer"})
+	token, err := jwt.Sign(&jwt.Claims{}, key, doc.VerificationMethods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := jwt.Verifier{Resolve: resolve, Purpose: did.KeyAgreement}
+	if _, err := v.Verify(token); err == nil {
+		t.Error("key agreement purpose got no error")
+	}
+
+	// plain JWS without claims
+	s, err := jws.SignHeader(&jws.Header{Type: "JWT"}, []byte(`"text"`), key, doc.VerificationMethods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Purpose = did.AssertionMethod
+	if _, err := v.Verify(s); err == nil {
+		t.Error("JSON string as claims got no error")
+	}
+}
@@ -0,0 +1,190 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pascaldekloe/did/internal/sorted"
+)
+
+// Meta is the “DID document metadata” of a Document. Note that all properties
+// are optional. The zero value of each property is omitted in JSON.
+type Meta struct {
+	// Created has the moment of the create operation.
+	Created time.Time
+	// Updated has the moment of the last update operation.
+	Updated time.Time
+
+	// Deactivated is set when the DID was deactivated.
+	Deactivated bool
+
+	// NextUpdate has the moment of the next update operation, when the
+	// resolved document version is not the latest.
+	NextUpdate time.Time
+
+	// VersionID has the version of the last update operation.
+	VersionID string
+	// NextVersionID has the version of the next update operation, when
+	// the resolved document version is not the latest.
+	NextVersionID string
+
+	// EquivalentIDs has logically equivalent DIDs, as asserted by the
+	// method.
+	EquivalentIDs []DID
+	// CanonicalID has the single, canonical DID, if any.
+	CanonicalID *DID
+
+	// DID methods MAY include additional metadata properties.
+	Additional map[string]json.RawMessage
+}
+
+// CoreMetaProperty returns whether name is reserved, i.e., not permitted in
+// Meta.Additional.
+func coreMetaProperty(name string) bool {
+	switch name {
+	case "created", "updated", "deactivated", "nextUpdate",
+		"versionId", "nextVersionId", "equivalentId", "canonicalId":
+		return true
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface. Times are normalized
+// to UTC, without sub-second precision, as with SetVersionParams.
+func (meta Meta) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 1, 256)
+	buf[0] = '{'
+
+	appendName := func(name string) {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, name)
+		buf = append(buf, ':')
+	}
+	appendTime := func(name string, t time.Time) {
+		if !t.IsZero() {
+			appendName(name)
+			buf = strconv.AppendQuote(buf, formatDateTime(t))
+		}
+	}
+	appendString := func(name, s string) {
+		if s != "" {
+			appendName(name)
+			buf = strconv.AppendQuote(buf, s)
+		}
+	}
+
+	appendTime("created", meta.Created)
+	appendTime("updated", meta.Updated)
+	if meta.Deactivated {
+		appendName("deactivated")
+		buf = append(buf, "true"...)
+	}
+	appendTime("nextUpdate", meta.NextUpdate)
+	appendString("versionId", meta.VersionID)
+	appendString("nextVersionId", meta.NextVersionID)
+	if len(meta.EquivalentIDs) != 0 {
+		appendName("equivalentId")
+		for i, d := range meta.EquivalentIDs {
+			if i == 0 {
+				buf = append(buf, '[')
+			} else {
+				buf = append(buf, ',')
+			}
+			buf = strconv.AppendQuote(buf, d.String())
+		}
+		buf = append(buf, ']')
+	}
+	if meta.CanonicalID != nil {
+		appendString("canonicalId", meta.CanonicalID.String())
+	}
+
+	for _, property := range sorted.Keys(meta.Additional) {
+		if coreMetaProperty(property) {
+			return nil, fmt.Errorf(`core DID document metadata property %q in additional set`, property)
+		}
+		appendName(property)
+		buf = append(buf, meta.Additional[property]...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (meta *Meta) UnmarshalJSON(bytes []byte) error {
+	// reset
+	*meta = Meta{}
+
+	// Read all properties as Additional first.
+	err := json.Unmarshal(bytes, &meta.Additional)
+	if err != nil {
+		return fmt.Errorf("DID document metadata: %w", err)
+	}
+
+	// Second, extract the core from Additional.
+	for _, property := range [...]struct {
+		name string
+		t    *time.Time
+	}{
+		{"created", &meta.Created},
+		{"updated", &meta.Updated},
+		{"nextUpdate", &meta.NextUpdate},
+	} {
+		var s string
+		err := meta.popPropertyInto(property.name, &s)
+		if err != nil {
+			return err
+		}
+		if s == "" {
+			continue
+		}
+		*property.t, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("DID document metadata %q: %w", property.name, err)
+		}
+	}
+
+	err = meta.popPropertyInto("deactivated", &meta.Deactivated)
+	if err != nil {
+		return err
+	}
+	err = meta.popPropertyInto("versionId", &meta.VersionID)
+	if err != nil {
+		return err
+	}
+	err = meta.popPropertyInto("nextVersionId", &meta.NextVersionID)
+	if err != nil {
+		return err
+	}
+	err = meta.popPropertyInto("equivalentId", &meta.EquivalentIDs)
+	if err != nil {
+		return err
+	}
+	err = meta.popPropertyInto("canonicalId", &meta.CanonicalID)
+	if err != nil {
+		return err
+	}
+
+	if len(meta.Additional) == 0 {
+		meta.Additional = nil
+	}
+	return nil
+}
+
+// PopPropertyInto unmarshals a core property, if present.
+func (meta *Meta) popPropertyInto(name string, pointer any) error {
+	raw, ok := meta.Additional[name]
+	if !ok {
+		return nil
+	}
+	delete(meta.Additional, name)
+
+	err := json.Unmarshal([]byte(raw), pointer)
+	if err != nil {
+		return fmt.Errorf("DID document metadata %q: %w", name, err)
+	}
+	return nil
+}
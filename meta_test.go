@@ -0,0 +1,106 @@
+package did_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleMeta_MarshalJSON() {
+	meta := did.Meta{
+		Created:     time.Date(2021, 5, 10, 19, 0, 0, 500e6, time.FixedZone("CEST", 2*60*60)),
+		Deactivated: true,
+		VersionID:   "2",
+	}
+	bytes, err := json.Marshal(&meta)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", bytes)
+	// Output:
+	// {"created":"2021-05-10T17:00:01Z","deactivated":true,"versionId":"2"}
+}
+
+func TestMetaJSON(t *testing.T) {
+	golden := []string{
+		`{}`,
+		`{"updated":"2020-01-01T00:00:00Z"}`,
+		`{"created":"2019-03-23T06:35:22Z","updated":"2023-08-10T13:40:06Z","nextUpdate":"2024-01-01T00:00:00Z","versionId":"1","nextVersionId":"2"}`,
+		`{"deactivated":true,"equivalentId":["did:example:123","did:example:456"],"canonicalId":"did:example:123"}`,
+		`{"versionId":"3","method":{"published":true},"x-extension":[1,"two"]}`,
+	}
+	for _, sample := range golden {
+		var meta did.Meta
+		err := json.Unmarshal([]byte(sample), &meta)
+		if err != nil {
+			t.Errorf("%s: unmarshal error: %s", sample, err)
+			continue
+		}
+		got, err := json.Marshal(&meta)
+		if err != nil {
+			t.Errorf("%s: marshal error: %s", sample, err)
+			continue
+		}
+		if string(got) != sample {
+			t.Errorf("got JSON %s, want %s", got, sample)
+		}
+	}
+
+	// normalization
+	var meta did.Meta
+	err := json.Unmarshal([]byte(`{"created":"2019-03-23T07:35:22.7+01:00","deactivated":false}`), &meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(&meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"created":"2019-03-23T06:35:23Z"}`; string(got) != want {
+		t.Errorf("got JSON %s, want %s", got, want)
+	}
+}
+
+func TestMetaJSONValue(t *testing.T) {
+	meta := did.Meta{VersionID: "1"}
+	got, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"versionId":"1"}`; string(got) != want {
+		t.Errorf("got JSON %s, want %s", got, want)
+	}
+
+	// embedded as a value
+	got, err = json.Marshal(struct{ Meta did.Meta }{meta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Meta":{"versionId":"1"}}`; string(got) != want {
+		t.Errorf("got JSON %s, want %s", got, want)
+	}
+}
+
+func TestMetaJSONErrors(t *testing.T) {
+	for _, sample := range []string{
+		`{"created":"2019-03-23"}`,
+		`{"deactivated":"true"}`,
+		`{"equivalentId":"did:example:123"}`,
+		`{"canonicalId":"example:123"}`,
+		`[]`,
+	} {
+		var meta did.Meta
+		if err := json.Unmarshal([]byte(sample), &meta); err == nil {
+			t.Errorf("%s: got no error", sample)
+		}
+	}
+
+	meta := did.Meta{Additional: map[string]json.RawMessage{"versionId": json.RawMessage(`"1"`)}}
+	if _, err := json.Marshal(&meta); err == nil {
+		t.Error("core property in additional set got no error")
+	}
+}
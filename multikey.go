@@ -0,0 +1,73 @@
+package did
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/pascaldekloe/did/internal/multikey"
+)
+
+// Verification method types with a "publicKeyMultibase" property.
+const (
+	MultikeyType                   = "Multikey"
+	Ed25519VerificationKey2020Type = "Ed25519VerificationKey2020"
+)
+
+// PublicKeyMultibase returns the "publicKeyMultibase" property, if any. The
+// return is nil when the property is absent. Both base58-btc and base64url
+// multibase are supported, with a multicodec prefix for either an Ed25519, an
+// X25519, or a P-256, P-384 or P-521 key. The Ed25519VerificationKey2020Type
+// also permits the raw key without multicodec, which was in use by earlier
+// drafts of the specification.
+func (m *VerificationMethod) PublicKeyMultibase() (crypto.PublicKey, error) {
+	if _, ok := m.Additional["publicKeyMultibase"]; !ok {
+		return nil, nil
+	}
+	s := m.AdditionalString("publicKeyMultibase")
+	if s == "" {
+		return nil, errors.New("DID verification-method publicKeyMultibase is not a JSON string")
+	}
+
+	bytes, err := multikey.DecodeMultibase(s)
+	if err != nil {
+		return nil, fmt.Errorf("DID verification-method publicKeyMultibase: %w", err)
+	}
+	if m.Type == Ed25519VerificationKey2020Type && len(bytes) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(bytes), nil
+	}
+	key, err := multikey.DecodeMulticodec(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("DID verification-method publicKeyMultibase: %w", err)
+	}
+	if _, ok := key.(ed25519.PublicKey); !ok && m.Type == Ed25519VerificationKey2020Type {
+		return nil, fmt.Errorf("DID verification-method publicKeyMultibase has %T for type %s", key, m.Type)
+	}
+	return key, nil
+}
+
+// SetPublicKeyMultibase sets the "publicKeyMultibase" property, with key in
+// base58-btc multibase, including its multicodec prefix. Supported types are
+// ed25519.PublicKey, *ecdh.PublicKey for X25519, and *ecdsa.PublicKey for P-256,
+// P-384 and P-521. A nil key removes the property.
+func (m *VerificationMethod) SetPublicKeyMultibase(key crypto.PublicKey) error {
+	if key == nil {
+		delete(m.Additional, "publicKeyMultibase")
+		return nil
+	}
+	if _, ok := key.(ed25519.PublicKey); !ok && m.Type == Ed25519VerificationKey2020Type {
+		return fmt.Errorf("DID verification-method type %s can't hold %T", m.Type, key)
+	}
+	s, err := multikey.Encode(key)
+	if err != nil {
+		return fmt.Errorf("DID verification-method publicKeyMultibase: %w", err)
+	}
+	if m.Additional == nil {
+		m.Additional = make(map[string]json.RawMessage)
+	}
+	m.Additional["publicKeyMultibase"] = json.RawMessage(strconv.Quote(s))
+	return nil
+}
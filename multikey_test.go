@@ -0,0 +1,154 @@
+package did_test
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleVerificationMethod_PublicKeyMultibase() {
+	var m did.VerificationMethod
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		"type": "Multikey",
+		"controller": "did:example:123",
+		"publicKeyMultibase": "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	}`), &m)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	key, err := m.PublicKeyMultibase()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%T %x\n", key, key)
+	// Output:
+	// ed25519.PublicKey 2e6fcce36701dc791488e0d0b1745cc1e33a4c1c9fcc41c63bd343dbbe0970e6
+}
+
+func TestPublicKeyMultibaseEd25519VerificationKey2020(t *testing.T) {
+	want := must(multibaseMethod("Multikey", "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK").PublicKeyMultibase())
+
+	tests := []string{
+		// with multicodec prefix
+		"z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		// raw key from earlier drafts
+		"z48GdbJyVULjHDaBNS6ct9oAGtckZUS5v8asrPzvZ7R1w",
+		// base64url with multicodec prefix
+		"u7QEub8zjZwHceRSI4NCxdFzB4zpMHJ_MQcY700Pbvglw5g",
+	}
+	for _, s := range tests {
+		got, err := multibaseMethod(did.Ed25519VerificationKey2020Type, s).PublicKeyMultibase()
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+			continue
+		}
+		if !want.(ed25519.PublicKey).Equal(got) {
+			t.Errorf("%s: got key %x, want %x", s, got, want)
+		}
+	}
+
+	// X25519 for type Ed25519VerificationKey2020
+	m := multibaseMethod(did.Ed25519VerificationKey2020Type, "z6LSj72tK8brWgZja8NLRwPigth2T9QRiG1uH9oKZuKjdh9p")
+	if key, err := m.PublicKeyMultibase(); err == nil {
+		t.Errorf("got %T for X25519, want error", key)
+	}
+}
+
+func TestPublicKeyMultibaseRoundTrip(t *testing.T) {
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []crypto.PublicKey{edKey, xKey.PublicKey()}
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, &ecKey.PublicKey)
+	}
+
+	for _, key := range keys {
+		m := multibaseMethod(did.MultikeyType, "")
+		if err := m.SetPublicKeyMultibase(key); err != nil {
+			t.Errorf("%T: %s", key, err)
+			continue
+		}
+		if s := m.AdditionalString("publicKeyMultibase"); s == "" || s[0] != 'z' {
+			t.Errorf("%T: got publicKeyMultibase %q, want base58-btc", key, s)
+		}
+		got, err := m.PublicKeyMultibase()
+		if err != nil {
+			t.Errorf("%T: %s", key, err)
+			continue
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Errorf("%T: got public key %v, want %v", key, got, key)
+		}
+	}
+
+	m := multibaseMethod(did.Ed25519VerificationKey2020Type, "")
+	if err := m.SetPublicKeyMultibase(xKey.PublicKey()); err == nil {
+		t.Error("X25519 set on Ed25519VerificationKey2020 got no error")
+	}
+	if err := m.SetPublicKeyMultibase(nil); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := m.PublicKeyMultibase(); key != nil || err != nil {
+		t.Errorf("got (%v, %v) after removal, want (nil, nil)", key, err)
+	}
+}
+
+func TestPublicKeyMultibaseErrors(t *testing.T) {
+	tests := []string{
+		"f00",                 // base16 not supported
+		"z0OIl",               // not in base58 alphabet
+		"z6MkhaXgBZDvotDkL52", // truncated
+		"u7QEub8zjZwHceRSI4NCxdFzB4zpMHJ_MQcY700Pbvglw5g=",
+	}
+	for _, s := range tests {
+		key, err := multibaseMethod(did.MultikeyType, s).PublicKeyMultibase()
+		if err == nil {
+			t.Errorf("%q got %T, want error", s, key)
+		}
+	}
+}
+
+// MultibaseMethod returns a verification method with s as publicKeyMultibase,
+// or without publicKeyMultibase for the empty string.
+func multibaseMethod(methodType, s string) *did.VerificationMethod {
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: did.DID{Method: "example", SpecID: "123"}, RawFragment: "#key-1"},
+		Type:       methodType,
+		Controller: did.DID{Method: "example", SpecID: "123"},
+	}
+	if s != "" {
+		m.Additional = map[string]json.RawMessage{
+			"publicKeyMultibase": json.RawMessage(fmt.Sprintf("%q", s)),
+		}
+	}
+	return m
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,645 @@
+package did
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrPatch signals a patch which can not be applied.
+var ErrPatch = errors.New("DID document patch not applicable")
+
+// PatchOp is an operation conform “JavaScript Object Notation (JSON) Patch”
+// RFC 6902. Paths are JSON Pointers in the JSON of a Document.
+type PatchOp struct {
+	Op    string          `json:"op"` // add, remove, replace, move, copy or test
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`  // move and copy only
+	Value json.RawMessage `json:"value,omitempty"` // add, replace and test only
+}
+
+// String returns the operation in a human readable form, i.e., a change log
+// entry.
+func (op PatchOp) String() string {
+	switch op.Op {
+	case "remove":
+		return "remove " + op.Path
+	case "move", "copy":
+		return op.Op + " " + op.From + " to " + op.Path
+	default:
+		return op.Op + " " + op.Path + " " + string(op.Value)
+	}
+}
+
+// Patch is a sequence of operations, to be applied in order.
+type Patch []PatchOp
+
+// Diff returns the operations which transform the JSON of from into the JSON
+// of to. Verification methods, references to verification methods, services,
+// and controllers match on identity, with DID URL Equal, and DID Equal, after
+// resolution of relative URLs against the Subject of their respective
+// document. Equivalent notations produce no operations, e.g., a relative
+// reference "#key-1" equals "did:example:123#key-1" in a document with subject
+// "did:example:123".
+func Diff(from, to *Document) (Patch, error) {
+	a, err := documentTree(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := documentTree(to)
+	if err != nil {
+		return nil, err
+	}
+
+	d := differ{from: from, to: to}
+	d.object("", a, b, d.documentProperty)
+	return d.patch, nil
+}
+
+// ApplyPatch executes each operation in order. The result must decode as a
+// Document. Errors include ErrPatch for operations which can not be applied,
+// including failed tests, in which case doc remains unchanged.
+func (doc *Document) ApplyPatch(p Patch) error {
+	tree, err := documentTree(doc)
+	if err != nil {
+		return err
+	}
+	var root any = tree
+
+	for i, op := range p {
+		root, err = applyOp(root, op)
+		if err != nil {
+			return fmt.Errorf("%w: operation № %d (%s): %w", ErrPatch, i+1, op, err)
+		}
+	}
+
+	bytes, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	var patched Document
+	err = json.Unmarshal(bytes, &patched)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPatch, err)
+	}
+	*doc = patched
+	return nil
+}
+
+// DocumentTree returns the JSON of doc in generic form.
+func documentTree(doc *Document) (map[string]any, error) {
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeTree(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+// DecodeTree parses JSON with numbers as is.
+func decodeTree(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("data after JSON value")
+	}
+	return v, nil
+}
+
+// Differ collects the operations in order of discovery.
+type differ struct {
+	from, to *Document
+	patch    Patch
+}
+
+// ValueDiff emits the operations needed to get from a to b at path.
+type valueDiff func(path string, a, b any)
+
+func (d *differ) emit(op, path string, value any) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		panic(err) // decoded JSON
+	}
+	d.patch = append(d.patch, PatchOp{Op: op, Path: path, Value: bytes})
+}
+
+func (d *differ) documentProperty(name string) valueDiff {
+	switch name {
+	case "id":
+		return d.didValue
+	case "controller":
+		return func(path string, a, b any) {
+			d.array(path, a, b, func(a, b any) bool {
+				return sameDID(a, b)
+			}, d.didValue)
+		}
+	case "verificationMethod":
+		return func(path string, a, b any) {
+			d.array(path, a, b, d.sameObjectID, d.methodValue)
+		}
+	case Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation:
+		return func(path string, a, b any) {
+			d.array(path, a, b, func(a, b any) bool {
+				// references are strings, embedded methods are objects
+				if _, ok := a.(string); ok {
+					return d.sameURL(a, b)
+				}
+				return d.sameObjectID(a, b)
+			}, d.methodValue)
+		}
+	case "service":
+		return func(path string, a, b any) {
+			d.array(path, a, b, d.sameObjectID, func(path string, a, b any) {
+				d.object(path, a, b, d.serviceProperty)
+			})
+		}
+	default:
+		return d.generic
+	}
+}
+
+// MethodValue diffs either an embedded verification method, or a reference.
+func (d *differ) methodValue(path string, a, b any) {
+	if _, ok := a.(string); ok {
+		d.urlValue(path, a, b)
+		return
+	}
+	d.object(path, a, b, func(name string) valueDiff {
+		switch name {
+		case "id":
+			return d.urlValue
+		case "controller":
+			return d.didValue
+		default:
+			return d.generic
+		}
+	})
+}
+
+func (d *differ) serviceProperty(name string) valueDiff {
+	if name == "id" {
+		return d.urlValue
+	}
+	return d.generic
+}
+
+func (d *differ) didValue(path string, a, b any) {
+	if !sameDID(a, b) {
+		d.emit("replace", path, b)
+	}
+}
+
+func (d *differ) urlValue(path string, a, b any) {
+	if !d.sameURL(a, b) {
+		d.emit("replace", path, b)
+	}
+}
+
+// SameDID returns whether a and b are equal DID strings.
+func sameDID(a, b any) bool {
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	d1, err := Parse(s1)
+	if err != nil {
+		return s1 == s2
+	}
+	return d1.EqualString(s2)
+}
+
+// SameURL returns whether a and b are equal URL strings, with a resolved in the
+// from document, and b resolved in the to document. Any URLs other than DID
+// URLs compare byte-for-byte.
+func (d *differ) sameURL(a, b any) bool {
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	u1, err1 := ParseURL(s1)
+	u2, err2 := ParseURL(s2)
+	if err1 != nil || err2 != nil {
+		return s1 == s2
+	}
+	return d.from.resolve(u1).Equal(d.to.resolve(u2))
+}
+
+// SameObjectID returns whether a and b are objects with the same "id".
+func (d *differ) sameObjectID(a, b any) bool {
+	o1, ok1 := a.(map[string]any)
+	o2, ok2 := b.(map[string]any)
+	return ok1 && ok2 && d.sameURL(o1["id"], o2["id"])
+}
+
+// Object diffs a and b as JSON objects, with property to select the diff per
+// property name.
+func (d *differ) object(path string, a, b any, property func(name string) valueDiff) {
+	o1, ok1 := a.(map[string]any)
+	o2, ok2 := b.(map[string]any)
+	if !ok1 || !ok2 {
+		d.generic(path, a, b)
+		return
+	}
+
+	names := make([]string, 0, len(o1)+len(o2))
+	for name := range o1 {
+		names = append(names, name)
+	}
+	for name := range o2 {
+		if _, ok := o1[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := path + "/" + escapePointerToken(name)
+		v1, ok1 := o1[name]
+		v2, ok2 := o2[name]
+		switch {
+		case !ok2:
+			d.patch = append(d.patch, PatchOp{Op: "remove", Path: p})
+		case !ok1:
+			d.emit("add", p, v2)
+		default:
+			property(name)(p, v1, v2)
+		}
+	}
+}
+
+// Array diffs a and b as JSON arrays, with same to match elements on identity,
+// and with element to diff the matched elements.
+func (d *differ) array(path string, a, b any, same func(a, b any) bool, element valueDiff) {
+	a1, ok1 := a.([]any)
+	a2, ok2 := b.([]any)
+	if !ok1 || !ok2 {
+		d.generic(path, a, b)
+		return
+	}
+
+	// match each element in b with the first unmatched in a, if any
+	matchOf := make([]int, len(a2)) // index in a1, or -1 for none
+	matched := make([]bool, len(a1))
+	for j, v2 := range a2 {
+		matchOf[j] = -1
+		for i, v1 := range a1 {
+			if !matched[i] && same(v1, v2) {
+				matched[i] = true
+				matchOf[j] = i
+				break
+			}
+		}
+	}
+
+	// remove unmatched in descending order, for stable indices
+	for i := len(a1) - 1; i >= 0; i-- {
+		if !matched[i] {
+			d.patch = append(d.patch, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+	}
+	var current []int // indices in a1 at their current position
+	for i := range a1 {
+		if matched[i] {
+			current = append(current, i)
+		}
+	}
+
+	// place each element of b at its position
+	for j, i := range matchOf {
+		p := path + "/" + strconv.Itoa(j)
+		if i < 0 {
+			d.emit("add", p, a2[j])
+			current = append(current[:j], append([]int{-1}, current[j:]...)...)
+			continue
+		}
+
+		at := j
+		for current[at] != i {
+			at++
+		}
+		if at != j {
+			d.patch = append(d.patch, PatchOp{Op: "move", From: path + "/" + strconv.Itoa(at), Path: p})
+			copy(current[j+1:at+1], current[j:at])
+			current[j] = i
+		}
+		element(p, a1[i], a2[j])
+	}
+}
+
+// Generic diffs a and b without any identity semantics.
+func (d *differ) generic(path string, a, b any) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	_, isObj1 := a.(map[string]any)
+	_, isObj2 := b.(map[string]any)
+	if isObj1 && isObj2 {
+		d.object(path, a, b, func(string) valueDiff { return d.generic })
+		return
+	}
+	_, isArr1 := a.([]any)
+	_, isArr2 := b.([]any)
+	if isArr1 && isArr2 {
+		d.array(path, a, b, reflect.DeepEqual, d.generic)
+		return
+	}
+	d.emit("replace", path, b)
+}
+
+// ApplyOp returns root with op applied.
+func applyOp(root any, op PatchOp) (any, error) {
+	tokens, err := pointerTokens(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New(`no "value"`)
+		}
+		value, err := decodeTree(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf(`"value": %w`, err)
+		}
+		switch op.Op {
+		case "add":
+			return addAt(root, tokens, value)
+		case "replace":
+			return replaceAt(root, tokens, value)
+		default:
+			current, err := valueAt(root, tokens)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, errors.New("test failed")
+			}
+			return root, nil
+		}
+
+	case "remove":
+		root, _, err = removeAt(root, tokens)
+		return root, err
+
+	case "move", "copy":
+		from, err := pointerTokens(op.From)
+		if err != nil {
+			return nil, fmt.Errorf(`"from": %w`, err)
+		}
+		var value any
+		if op.Op == "copy" {
+			value, err = valueAt(root, from)
+			if err != nil {
+				return nil, err
+			}
+			value = deepCopy(value)
+		} else {
+			if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, errors.New("move into own child")
+			}
+			root, value, err = removeAt(root, from)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return addAt(root, tokens, value)
+
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// PointerTokens returns the reference tokens of a JSON Pointer, unescaped.
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil // whole document
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer %q does not start with a slash", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// EscapePointerToken returns name as a JSON Pointer reference token.
+func escapePointerToken(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+// ArrayIndex parses a reference token as an index in an array of size n. The
+// "-" token, and an index equal to n are permitted with end set only.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("malformed array index %q", token)
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return 0, fmt.Errorf("malformed array index %q", token)
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n || (i == n && !end) {
+		return 0, fmt.Errorf("array index %q out of bounds", token)
+	}
+	return i, nil
+}
+
+// Within applies fn to the container of the last token, and it returns node
+// with the container updated.
+func within(node any, tokens []string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", tokens[0])
+		}
+		v, err := within(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = v
+		return n, nil
+
+	case []any:
+		i, err := arrayIndex(tokens[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		v, err := within(n[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = v
+		return n, nil
+
+	default:
+		return nil, fmt.Errorf("reference token %q into a JSON value which is not an object nor an array", tokens[0])
+	}
+}
+
+func valueAt(root any, tokens []string) (any, error) {
+	var value any
+	if len(tokens) == 0 {
+		return root, nil
+	}
+	_, err := within(root, tokens, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			value = v
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			value = c[i]
+		default:
+			return nil, fmt.Errorf("reference token %q into a JSON value which is not an object nor an array", token)
+		}
+		return container, nil
+	})
+	return value, err
+}
+
+func addAt(root any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return within(root, tokens, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("reference token %q into a JSON value which is not an object nor an array", token)
+		}
+	})
+}
+
+func replaceAt(root any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	root, _, err := removeAt(root, tokens)
+	if err != nil {
+		return nil, err
+	}
+	return addAt(root, tokens, value)
+}
+
+// RemoveAt returns root without the value at tokens, and the value removed.
+func removeAt(root any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("remove of whole document")
+	}
+	var removed any
+	root, err := within(root, tokens, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			removed = v
+			delete(c, token)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = c[i]
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("reference token %q into a JSON value which is not an object nor an array", token)
+		}
+	})
+	return root, removed, err
+}
+
+// JSONEqual compares numbers by value, conform the test operation.
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeNumbers(a), normalizeNumbers(b))
+}
+
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return string(v)
+		}
+		return f
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = normalizeNumbers(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = normalizeNumbers(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = deepCopy(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
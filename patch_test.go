@@ -0,0 +1,181 @@
+package did_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleDiff() {
+	var from, to did.Document
+	err := json.Unmarshal([]byte(example9), &from)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = json.Unmarshal([]byte(`{
+		"id": "did:example:123456789abcdefghi",
+		"verificationMethod": [{
+			"id": "did:example:123456789abcdefghi#key-1",
+			"type": "Ed25519VerificationKey2020",
+			"controller": "did:example:123456789abcdefghi",
+			"publicKeyMultibase": "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+		}],
+		"authentication": ["did:example:123456789abcdefghi#key-1"],
+		"assertionMethod": ["#key-1"]
+	}`), &to)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	patch, err := did.Diff(&from, &to)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, op := range patch {
+		fmt.Println(op)
+	}
+	// Output:
+	// add /assertionMethod ["#key-1"]
+	// replace /verificationMethod/0/publicKeyMultibase "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+}
+
+func TestDiffApply(t *testing.T) {
+	tests := []struct {
+		name, from, to string
+		wantN          int // number of operations
+	}{
+		{"equal", example9, example9, 0},
+		{"equivalent notation", `{
+			"id": "did:example:123",
+			"controller": "did:example:456",
+			"verificationMethod": [{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"}],
+			"authentication": ["#key-1"],
+			"service": [{"id": "#hub", "type": "Hub", "serviceEndpoint": "https://example.com/"}]
+		}`, `{
+			"id": "did:example:123",
+			"controller": ["did:example:456"],
+			"verificationMethod": [{"id": "did:example:123#key-1", "type": "Multikey", "controller": "did:example:123"}],
+			"authentication": ["did:example:123#key-1"],
+			"service": [{"id": "did:example:123#hub", "type": "Hub", "serviceEndpoint": "https://example.com/"}]
+		}`, 0},
+		{"reorder and replace", `{
+			"id": "did:example:123",
+			"verificationMethod": [
+				{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"},
+				{"id": "#key-2", "type": "Multikey", "controller": "did:example:123"},
+				{"id": "#key-3", "type": "Multikey", "controller": "did:example:123"}
+			],
+			"keyAgreement": ["#key-3", {"id": "#key-4", "type": "Multikey", "controller": "did:example:123"}]
+		}`, `{
+			"id": "did:example:123",
+			"verificationMethod": [
+				{"id": "#key-3", "type": "JsonWebKey2020", "controller": "did:example:123"},
+				{"id": "#key-5", "type": "Multikey", "controller": "did:example:123"},
+				{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"}
+			],
+			"keyAgreement": [{"id": "#key-4", "type": "Multikey", "controller": "did:example:456"}, "#key-5"]
+		}`, 7},
+		{"new subject", `{"id": "did:example:123", "authentication": ["#key-1"]}`,
+			`{"id": "did:example:456", "authentication": ["#key-1"]}`, 3},
+		{"service endpoints", `{
+			"id": "did:example:123",
+			"service": [{"id": "#hub", "type": "Hub", "serviceEndpoint": ["https://a.example/", "https://b.example/"]}]
+		}`, `{
+			"id": "did:example:123",
+			"service": [{"id": "#hub", "type": ["Hub", "Relay"], "serviceEndpoint": "https://b.example/"}]
+		}`, 2},
+	}
+
+	for _, test := range tests {
+		var from, to did.Document
+		if err := json.Unmarshal([]byte(test.from), &from); err != nil {
+			t.Fatalf("%s: from: %s", test.name, err)
+		}
+		if err := json.Unmarshal([]byte(test.to), &to); err != nil {
+			t.Fatalf("%s: to: %s", test.name, err)
+		}
+
+		patch, err := did.Diff(&from, &to)
+		if err != nil {
+			t.Errorf("%s: diff error: %s", test.name, err)
+			continue
+		}
+		if len(patch) != test.wantN {
+			t.Errorf("%s: got %d operations %q, want %d", test.name, len(patch), patch, test.wantN)
+		}
+
+		err = from.ApplyPatch(patch)
+		if err != nil {
+			t.Errorf("%s: apply error: %s", test.name, err)
+			continue
+		}
+		// apply is semantic, with notation from the original
+		remaining, err := did.Diff(&from, &to)
+		if err != nil {
+			t.Errorf("%s: diff after apply error: %s", test.name, err)
+		} else if len(remaining) != 0 {
+			t.Errorf("%s: got operations %q after apply, want none", test.name, remaining)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{
+		"id": "did:example:123",
+		"alsoKnownAs": ["https://a.example/", "https://b.example/"],
+		"service": [{"id": "#hub", "type": "Hub", "serviceEndpoint": "https://example.com/", "a/b~c": 1}]
+	}`
+	tests := []struct {
+		patch string
+		want  string // JSON or error
+	}{
+		{`[{"op": "add", "path": "/alsoKnownAs/-", "value": "https://c.example/"}]`,
+			`{"id":"did:example:123","alsoKnownAs":["https://a.example/","https://b.example/","https://c.example/"],"service":[{"id":"#hub","type":"Hub","serviceEndpoint":"https://example.com/","a/b~c":1}]}`},
+		{`[{"op": "move", "from": "/alsoKnownAs/1", "path": "/alsoKnownAs/0"}]`,
+			`{"id":"did:example:123","alsoKnownAs":["https://b.example/","https://a.example/"],"service":[{"id":"#hub","type":"Hub","serviceEndpoint":"https://example.com/","a/b~c":1}]}`},
+		{`[{"op": "test", "path": "/service/0/a~1b~0c", "value": 1.0}, {"op": "remove", "path": "/service/0/a~1b~0c"}, {"op": "remove", "path": "/alsoKnownAs"}]`,
+			`{"id":"did:example:123","service":[{"id":"#hub","type":"Hub","serviceEndpoint":"https://example.com/"}]}`},
+		{`[{"op": "copy", "from": "/service/0/serviceEndpoint", "path": "/alsoKnownAs/1"}, {"op": "replace", "path": "/service/0/type", "value": ["Hub", "Relay"]}]`,
+			`{"id":"did:example:123","alsoKnownAs":["https://a.example/","https://example.com/","https://b.example/"],"service":[{"id":"#hub","type":["Hub","Relay"],"serviceEndpoint":"https://example.com/","a/b~c":1}]}`},
+
+		{`[{"op": "test", "path": "/id", "value": "did:example:456"}]`, "test failed"},
+		{`[{"op": "remove", "path": "/alsoKnownAs/2"}]`, "out of bounds"},
+		{`[{"op": "replace", "path": "/controller", "value": "x"}]`, `no member "controller"`},
+		{`[{"op": "replace", "path": "/id", "value": "did:Example:123"}]`, `DID document patch not applicable: JSON string content: invalid DID`},
+		{`[{"op": "move", "from": "/service", "path": "/service/0"}]`, "move into own child"},
+		{`[{"op": "merge", "path": "/id"}]`, "unknown operation"},
+	}
+
+	for _, test := range tests {
+		var d did.Document
+		if err := json.Unmarshal([]byte(doc), &d); err != nil {
+			t.Fatal(err)
+		}
+		var patch did.Patch
+		if err := json.Unmarshal([]byte(test.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+
+		err := d.ApplyPatch(patch)
+		if err != nil {
+			if !errors.Is(err, did.ErrPatch) || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("patch %s: got error %q, want %q", test.patch, err, test.want)
+			}
+			continue
+		}
+		got, err := json.Marshal(&d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("patch %s: got JSON %s\nwant %s", test.patch, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,762 @@
+package rdfc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pascaldekloe/did/internal/sorted"
+)
+
+// ErrContext signals a JSON-LD context not available from the Loader.
+var ErrContext = errors.New("JSON-LD context not available offline")
+
+// RemoteContextLimit caps the number of remote contexts in a chain.
+const remoteContextLimit = 32
+
+// ActiveContext is the state of context processing.
+type activeContext struct {
+	terms     map[string]*termDefinition
+	base      *url.URL // nil for none
+	vocab     string
+	hasVocab  bool
+	language  string // lower case
+	direction string
+	// Previous is the context to revert to, in case of a type-scoped
+	// context which does not propagate.
+	previous *activeContext
+}
+
+func (c *activeContext) copy() *activeContext {
+	terms := make(map[string]*termDefinition, len(c.terms))
+	for name, def := range c.terms {
+		terms[name] = def
+	}
+	cp := *c
+	cp.terms = terms
+	return &cp
+}
+
+func (c *activeContext) hasProtected() bool {
+	for _, def := range c.terms {
+		if def.protected {
+			return true
+		}
+	}
+	return false
+}
+
+// TermDefinition is the processed form of a term in a context.
+type termDefinition struct {
+	id        string // IRI mapping, or a keyword
+	hasID     bool   // false for a null mapping
+	reverse   bool
+	typ       string // type mapping, if any
+	language  string
+	hasLang   bool // language mapping set, possibly to null
+	direction string
+	hasDir    bool // direction mapping set, possibly to null
+	container map[string]bool
+	index     string
+	nest      string
+	prefix    bool
+	protected bool
+
+	// Context is the local context, if any.
+	context    any
+	hasContext bool
+	base       *url.URL
+}
+
+func (d *termDefinition) sameAs(o *termDefinition) bool {
+	a, b := *d, *o // copy
+	a.protected, b.protected = false, false
+	return reflect.DeepEqual(a, b)
+}
+
+// ContextProcessor applies local contexts.
+type contextProcessor struct {
+	loader Loader
+	cache  map[string]any // parsed "@context" per URL
+}
+
+// Load returns the "@context" of a remote context.
+func (p *contextProcessor) load(ctxURL string) (any, error) {
+	if ctx, ok := p.cache[ctxURL]; ok {
+		return ctx, nil
+	}
+	data, ok := p.loader[ctxURL]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrContext, ctxURL)
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("JSON-LD context %s: %w", ctxURL, err)
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("JSON-LD context %s is not a JSON object", ctxURL)
+	}
+	ctx, ok := obj["@context"]
+	if !ok {
+		return nil, fmt.Errorf("JSON-LD context %s has no @context", ctxURL)
+	}
+	if p.cache == nil {
+		p.cache = make(map[string]any)
+	}
+	p.cache[ctxURL] = ctx
+	return ctx, nil
+}
+
+// ProcessOptions are the optional arguments of the context processing
+// algorithm.
+type processOptions struct {
+	remoteContexts    []string
+	overrideProtected bool
+	noPropagate       bool
+	skipScopedCheck   bool // “validate scoped context” false
+}
+
+// Process implements the “Context Processing Algorithm”.
+func (p *contextProcessor) process(active *activeContext, local any, base *url.URL, opts processOptions) (*activeContext, error) {
+	result := active.copy()
+	if obj, ok := local.(map[string]any); ok {
+		if v, ok := obj["@propagate"]; ok {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, errors.New("JSON-LD invalid @propagate value")
+			}
+			opts.noPropagate = !b
+		}
+	}
+	if opts.noPropagate && result.previous == nil {
+		result.previous = active
+	}
+
+	locals, ok := local.([]any)
+	if !ok {
+		locals = []any{local}
+	}
+	for _, ctx := range locals {
+		switch ctx := ctx.(type) {
+		case nil:
+			if !opts.overrideProtected && result.hasProtected() {
+				return nil, errors.New("JSON-LD invalid context nullification")
+			}
+			previous := result
+			result = &activeContext{terms: make(map[string]*termDefinition), base: active.base}
+			if opts.noPropagate {
+				result.previous = previous
+			}
+
+		case string:
+			ctxURL, err := resolve(base, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("JSON-LD context reference %q: %w", ctx, err)
+			}
+			if opts.skipScopedCheck && contains(opts.remoteContexts, ctxURL) {
+				continue
+			}
+			if len(opts.remoteContexts) >= remoteContextLimit {
+				return nil, errors.New("JSON-LD context overflow")
+			}
+			loaded, err := p.load(ctxURL)
+			if err != nil {
+				return nil, err
+			}
+			u, _ := url.Parse(ctxURL)
+			result, err = p.process(result, loaded, u, processOptions{
+				remoteContexts:  append(append([]string(nil), opts.remoteContexts...), ctxURL),
+				skipScopedCheck: opts.skipScopedCheck,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+		case map[string]any:
+			var err error
+			result, err = p.processObject(result, ctx, base, opts)
+			if err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("JSON-LD invalid local context %T", ctx)
+		}
+	}
+	return result, nil
+}
+
+func (p *contextProcessor) processObject(result *activeContext, ctx map[string]any, base *url.URL, opts processOptions) (*activeContext, error) {
+	if v, ok := ctx["@version"]; ok {
+		if n, ok := v.(json.Number); !ok || n != "1.1" {
+			return nil, fmt.Errorf("JSON-LD invalid @version value %v", v)
+		}
+	}
+
+	if v, ok := ctx["@import"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("JSON-LD invalid @import value")
+		}
+		importURL, err := resolve(base, s)
+		if err != nil {
+			return nil, fmt.Errorf("JSON-LD @import %q: %w", s, err)
+		}
+		imported, err := p.load(importURL)
+		if err != nil {
+			return nil, err
+		}
+		importedObj, ok := imported.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("JSON-LD invalid remote context %s", importURL)
+		}
+		if _, ok := importedObj["@import"]; ok {
+			return nil, fmt.Errorf("JSON-LD invalid context entry @import in %s", importURL)
+		}
+		merged := make(map[string]any, len(ctx)+len(importedObj))
+		for k, v := range importedObj {
+			merged[k] = v
+		}
+		for k, v := range ctx {
+			if k != "@import" {
+				merged[k] = v
+			}
+		}
+		ctx = merged
+	}
+
+	if v, ok := ctx["@base"]; ok && len(opts.remoteContexts) == 0 {
+		switch v := v.(type) {
+		case nil:
+			result.base = nil
+		case string:
+			u, err := url.Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("JSON-LD invalid @base %q: %w", v, err)
+			}
+			if !u.IsAbs() {
+				if result.base == nil {
+					return nil, fmt.Errorf("JSON-LD invalid @base %q without base IRI", v)
+				}
+				u = result.base.ResolveReference(u)
+			}
+			result.base = u
+		default:
+			return nil, errors.New("JSON-LD invalid @base value")
+		}
+	}
+
+	if v, ok := ctx["@vocab"]; ok {
+		switch v := v.(type) {
+		case nil:
+			result.vocab, result.hasVocab = "", false
+		case string:
+			vocab, ok, err := p.expandIRI(result, v, true, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || !(isAbsIRI(vocab) || strings.HasPrefix(vocab, blankPrefix)) {
+				return nil, fmt.Errorf("JSON-LD invalid @vocab %q", v)
+			}
+			result.vocab, result.hasVocab = vocab, true
+		default:
+			return nil, errors.New("JSON-LD invalid @vocab value")
+		}
+	}
+
+	if v, ok := ctx["@language"]; ok {
+		switch v := v.(type) {
+		case nil:
+			result.language = ""
+		case string:
+			result.language = strings.ToLower(v)
+		default:
+			return nil, errors.New("JSON-LD invalid default @language")
+		}
+	}
+
+	if v, ok := ctx["@direction"]; ok {
+		switch v {
+		case nil:
+			result.direction = ""
+		case "ltr", "rtl":
+			result.direction = v.(string)
+		default:
+			return nil, errors.New("JSON-LD invalid base @direction")
+		}
+	}
+
+	if v, ok := ctx["@propagate"]; ok {
+		if _, ok := v.(bool); !ok {
+			return nil, errors.New("JSON-LD invalid @propagate value")
+		}
+	}
+
+	protected := false
+	if v, ok := ctx["@protected"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errors.New("JSON-LD invalid @protected value")
+		}
+		protected = b
+	}
+
+	scope := &termScope{local: ctx, defined: make(map[string]bool), base: base, protected: protected, opts: opts}
+	for _, term := range sorted.Keys(ctx) {
+		switch term {
+		case "@base", "@direction", "@import", "@language", "@propagate", "@protected", "@version", "@vocab":
+			continue
+		}
+		err := p.createTerm(result, scope, term)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// TermScope has the arguments of term creation from a local context.
+type termScope struct {
+	local     map[string]any
+	defined   map[string]bool // false while in progress
+	base      *url.URL
+	protected bool // @protected of the local context
+	opts      processOptions
+}
+
+// CreateTerm implements the “Create Term Definition” algorithm.
+func (p *contextProcessor) createTerm(active *activeContext, scope *termScope, term string) error {
+	local, defined := scope.local, scope.defined
+	if done, ok := defined[term]; ok {
+		if done {
+			return nil
+		}
+		return fmt.Errorf("JSON-LD cyclic IRI mapping for term %q", term)
+	}
+	if term == "" {
+		return errors.New("JSON-LD invalid term definition of empty term")
+	}
+	defined[term] = false
+
+	value := local[term]
+	if term == "@type" {
+		obj, ok := value.(map[string]any)
+		if !ok || len(obj) == 0 {
+			return errors.New("JSON-LD keyword redefinition of @type")
+		}
+		for k, v := range obj {
+			switch {
+			case k == "@container" && v == "@set":
+				continue
+			case k == "@protected":
+				continue
+			}
+			return errors.New("JSON-LD keyword redefinition of @type")
+		}
+	} else if isKeyword(term) {
+		return fmt.Errorf("JSON-LD keyword redefinition of %s", term)
+	} else if looksLikeKeyword(term) {
+		defined[term] = true
+		return nil // ignored
+	}
+
+	previous := active.terms[term]
+	delete(active.terms, term)
+
+	simpleTerm := false
+	switch v := value.(type) {
+	case nil:
+		value = map[string]any{"@id": nil}
+	case string:
+		value = map[string]any{"@id": v}
+		simpleTerm = true
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("JSON-LD invalid term definition of %q", term)
+	}
+
+	def := &termDefinition{protected: scope.protected}
+	if v, ok := obj["@protected"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("JSON-LD invalid @protected value of term %q", term)
+		}
+		def.protected = b
+	}
+
+	if v, ok := obj["@type"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("JSON-LD invalid type mapping of term %q", term)
+		}
+		typ, ok, err := p.expandIRI(active, s, false, true, scope)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !ok:
+			return fmt.Errorf("JSON-LD invalid type mapping of term %q", term)
+		case typ == "@id", typ == "@vocab", typ == "@json", typ == "@none":
+			break
+		case isAbsIRI(typ):
+			break
+		default:
+			return fmt.Errorf("JSON-LD invalid type mapping %q of term %q", typ, term)
+		}
+		def.typ = typ
+	}
+
+	if v, ok := obj["@reverse"]; ok {
+		if _, ok := obj["@id"]; ok {
+			return fmt.Errorf("JSON-LD invalid reverse property %q", term)
+		}
+		if _, ok := obj["@nest"]; ok {
+			return fmt.Errorf("JSON-LD invalid reverse property %q", term)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+		}
+		if looksLikeKeyword(s) {
+			defined[term] = true
+			return nil // ignored
+		}
+		id, ok, err := p.expandIRI(active, s, false, true, scope)
+		if err != nil {
+			return err
+		}
+		if !ok || !(isAbsIRI(id) || strings.HasPrefix(id, blankPrefix)) {
+			return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+		}
+		def.id, def.hasID = id, true
+		if v, ok := obj["@container"]; ok {
+			switch v {
+			case nil:
+				break
+			case "@set", "@index":
+				def.container = map[string]bool{v.(string): true}
+			default:
+				return fmt.Errorf("JSON-LD invalid reverse property %q", term)
+			}
+		}
+		def.reverse = true
+		active.terms[term] = def
+		defined[term] = true
+		return nil
+	}
+
+	if v, ok := obj["@id"]; ok && v != term {
+		switch v := v.(type) {
+		case nil:
+			break // null mapping
+		case string:
+			if !isKeyword(v) && looksLikeKeyword(v) {
+				defined[term] = true
+				return nil // ignored
+			}
+			id, ok, err := p.expandIRI(active, v, false, true, scope)
+			if err != nil {
+				return err
+			}
+			if !ok || !(isKeyword(id) || isAbsIRI(id) || strings.HasPrefix(id, blankPrefix)) {
+				return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+			}
+			if id == "@context" {
+				return errors.New("JSON-LD invalid keyword alias @context")
+			}
+			def.id, def.hasID = id, true
+
+			if strings.Contains(strings.Trim(term, ":"), ":") || strings.Contains(term, "/") {
+				defined[term] = true
+				expanded, ok, err := p.expandIRI(active, term, false, true, scope)
+				if err != nil {
+					return err
+				}
+				if !ok || expanded != id {
+					return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+				}
+			}
+			if !strings.ContainsAny(term, ":/") && simpleTerm &&
+				(strings.HasPrefix(id, blankPrefix) || strings.ContainsAny(id[len(id)-1:], ":/?#[]@")) {
+				def.prefix = true
+			}
+		default:
+			return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+		}
+	} else if i := strings.IndexByte(term[1:], ':'); i >= 0 {
+		prefix, suffix := term[:i+1], term[i+2:]
+		if _, ok := local[prefix]; ok {
+			err := p.createTerm(active, scope, prefix)
+			if err != nil {
+				return err
+			}
+		}
+		if prefixDef, ok := active.terms[prefix]; ok && prefixDef.hasID {
+			def.id = prefixDef.id + suffix
+		} else {
+			def.id = term
+		}
+		def.hasID = true
+	} else if strings.Contains(term, "/") {
+		id, ok, err := p.expandIRI(active, term, false, true, nil)
+		if err != nil {
+			return err
+		}
+		if !ok || !isAbsIRI(id) {
+			return fmt.Errorf("JSON-LD invalid IRI mapping of term %q", term)
+		}
+		def.id, def.hasID = id, true
+	} else if term == "@type" {
+		def.id, def.hasID = "@type", true
+	} else if active.hasVocab {
+		def.id, def.hasID = active.vocab+term, true
+	} else {
+		return fmt.Errorf("JSON-LD invalid IRI mapping of term %q without vocabulary mapping", term)
+	}
+
+	if v, ok := obj["@container"]; ok {
+		var values []any
+		switch v := v.(type) {
+		case string:
+			values = []any{v}
+		case []any:
+			values = v
+		default:
+			return fmt.Errorf("JSON-LD invalid container mapping of term %q", term)
+		}
+		def.container = make(map[string]bool, len(values))
+		for _, v := range values {
+			switch v {
+			case "@graph", "@id", "@index", "@language", "@list", "@set", "@type":
+				def.container[v.(string)] = true
+			default:
+				return fmt.Errorf("JSON-LD invalid container mapping of term %q", term)
+			}
+		}
+		if def.container["@list"] && len(def.container) != 1 {
+			return fmt.Errorf("JSON-LD invalid container mapping of term %q", term)
+		}
+		if def.container["@type"] {
+			switch def.typ {
+			case "":
+				def.typ = "@id"
+			case "@id", "@vocab":
+				break
+			default:
+				return fmt.Errorf("JSON-LD invalid type mapping of term %q", term)
+			}
+		}
+	}
+
+	if v, ok := obj["@index"]; ok {
+		s, ok := v.(string)
+		if !ok || !def.container["@index"] || isKeyword(s) {
+			return fmt.Errorf("JSON-LD invalid term definition of %q", term)
+		}
+		def.index = s
+	}
+
+	if v, ok := obj["@context"]; ok {
+		_, err := p.process(active, v, scope.base, processOptions{
+			remoteContexts:    append([]string(nil), scope.opts.remoteContexts...),
+			overrideProtected: true,
+			skipScopedCheck:   true,
+		})
+		if err != nil {
+			return fmt.Errorf("JSON-LD invalid scoped context of term %q: %w", term, err)
+		}
+		def.context, def.hasContext, def.base = v, true, scope.base
+	}
+
+	if v, ok := obj["@language"]; ok {
+		if _, ok := obj["@type"]; !ok {
+			switch v := v.(type) {
+			case nil:
+				def.language, def.hasLang = "", true
+			case string:
+				def.language, def.hasLang = strings.ToLower(v), true
+			default:
+				return fmt.Errorf("JSON-LD invalid language mapping of term %q", term)
+			}
+		}
+	}
+
+	if v, ok := obj["@direction"]; ok {
+		if _, ok := obj["@type"]; !ok {
+			switch v {
+			case nil, "ltr", "rtl":
+				def.direction, _ = v.(string)
+				def.hasDir = true
+			default:
+				return fmt.Errorf("JSON-LD invalid base direction of term %q", term)
+			}
+		}
+	}
+
+	if v, ok := obj["@nest"]; ok {
+		s, ok := v.(string)
+		if !ok || (isKeyword(s) && s != "@nest") {
+			return fmt.Errorf("JSON-LD invalid @nest value of term %q", term)
+		}
+		def.nest = s
+	}
+
+	if v, ok := obj["@prefix"]; ok {
+		b, ok := v.(bool)
+		if !ok || strings.ContainsAny(term, ":/") || isKeyword(def.id) {
+			return fmt.Errorf("JSON-LD invalid term definition of %q", term)
+		}
+		def.prefix = b
+	}
+
+	for k := range obj {
+		switch k {
+		case "@id", "@reverse", "@container", "@context", "@direction", "@index", "@language", "@nest", "@prefix", "@protected", "@type":
+			continue
+		}
+		return fmt.Errorf("JSON-LD invalid term definition of %q with %s", term, k)
+	}
+
+	if !scope.opts.overrideProtected && previous != nil && previous.protected {
+		if !def.sameAs(previous) {
+			return fmt.Errorf("JSON-LD protected term redefinition of %q", term)
+		}
+		def = previous
+	}
+
+	active.terms[term] = def
+	defined[term] = true
+	return nil
+}
+
+// ExpandIRI implements the “IRI Expansion” algorithm. The boolean return is
+// false for null. Scope is nil outside of context processing.
+func (p *contextProcessor) expandIRI(active *activeContext, value string, documentRelative, vocab bool, scope *termScope) (string, bool, error) {
+	if isKeyword(value) {
+		return value, true, nil
+	}
+	if looksLikeKeyword(value) {
+		return "", false, nil
+	}
+
+	if scope != nil {
+		if _, ok := scope.local[value]; ok && !scope.defined[value] {
+			err := p.createTerm(active, scope, value)
+			if err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	if def, ok := active.terms[value]; ok {
+		if def.hasID && isKeyword(def.id) {
+			return def.id, true, nil
+		}
+		if vocab {
+			return def.id, def.hasID, nil
+		}
+	}
+
+	if i := strings.IndexByte(value, ':'); i > 0 {
+		prefix, suffix := value[:i], value[i+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return value, true, nil
+		}
+		if scope != nil {
+			if _, ok := scope.local[prefix]; ok && !scope.defined[prefix] {
+				err := p.createTerm(active, scope, prefix)
+				if err != nil {
+					return "", false, err
+				}
+			}
+		}
+		if def, ok := active.terms[prefix]; ok && def.hasID && def.prefix {
+			return def.id + suffix, true, nil
+		}
+		if isAbsIRI(value) {
+			return value, true, nil
+		}
+	}
+
+	if vocab && active.hasVocab {
+		return active.vocab + value, true, nil
+	}
+	if documentRelative && active.base != nil {
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", false, fmt.Errorf("JSON-LD invalid IRI %q: %w", value, err)
+		}
+		return active.base.ResolveReference(u).String(), true, nil
+	}
+	return value, true, nil
+}
+
+// Resolve returns the reference as an absolute URL.
+func resolve(base *url.URL, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	if u.IsAbs() {
+		return ref, nil
+	}
+	if base == nil {
+		return "", errors.New("relative reference without base")
+	}
+	return base.ResolveReference(u).String(), nil
+}
+
+// IsAbsIRI returns whether s starts with a scheme.
+func isAbsIRI(s string) bool {
+	i := strings.IndexByte(s, ':')
+	if i < 1 {
+		return false
+	}
+	for j := 0; j < i; j++ {
+		c := s[j]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+			continue
+		case j != 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+			continue
+		}
+		return false
+	}
+	return !strings.ContainsAny(s, " <>\"{}|\\^`")
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "@base", "@container", "@context", "@default", "@direction", "@embed", "@explicit", "@graph", "@id", "@import", "@included", "@index", "@json", "@language", "@list", "@nest", "@none", "@omitDefault", "@prefix", "@preserve", "@protected", "@requireAll", "@reverse", "@set", "@type", "@value", "@version", "@vocab", "@propagate":
+		return true
+	}
+	return false
+}
+
+// LooksLikeKeyword matches the "@"1*ALPHA form reserved for keywords.
+func looksLikeKeyword(s string) bool {
+	if len(s) < 2 || s[0] != '@' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
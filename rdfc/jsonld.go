@@ -0,0 +1,1009 @@
+package rdfc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pascaldekloe/did/internal/sorted"
+	"github.com/pascaldekloe/did/jcs"
+)
+
+// ErrUndefined signals a property or a type which does not expand to an IRI.
+// Such data is silently dropped by JSON-LD, which would leave it unsigned.
+var ErrUndefined = errors.New("JSON-LD term not defined in context")
+
+// Transform returns the canonical N-Quads of a JSON-LD document. Contexts are
+// read from l exclusively. A nil Loader defaults to Bundled. Errors include
+// ErrUndefined and ErrPoisoned.
+func Transform(doc []byte, l Loader) ([]byte, error) {
+	dataset, err := ToRDF(doc, l)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := Canonicalize(dataset)
+	if err != nil {
+		return nil, err
+	}
+	return NQuads(canonical), nil
+}
+
+// ToRDF returns the RDF dataset of a JSON-LD document. Contexts are read from l
+// exclusively. A nil Loader defaults to Bundled. Any properties and types which
+// do not expand to an IRI cause an ErrUndefined, rather than to be dropped.
+func ToRDF(doc []byte, l Loader) ([]Quad, error) {
+	if l == nil {
+		l = Bundled()
+	}
+	v, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("JSON-LD document: %w", err)
+	}
+
+	e := expansion{contextProcessor: contextProcessor{loader: l}}
+	expanded, err := e.expand(&activeContext{terms: make(map[string]*termDefinition)}, "", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := expanded.(map[string]any); ok {
+		if graph, ok := obj["@graph"]; ok && len(obj) == 1 {
+			expanded = graph
+		}
+	}
+
+	var g rdfGenerator
+	for _, node := range asArray(expanded) {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := obj["@value"]; ok {
+			continue
+		}
+		if _, ok := obj["@list"]; ok {
+			continue
+		}
+		g.node(obj, Term{})
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.quads, nil
+}
+
+// DecodeJSON parses data with numbers as json.Number.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("data after top-level value")
+	}
+	return v, nil
+}
+
+// Expansion implements the “Expansion Algorithm” of JSON-LD 1.1.
+type expansion struct {
+	contextProcessor
+}
+
+// Expand returns the expanded form of element. The active property is empty
+// for null. The return is nil for null.
+func (e *expansion) expand(active *activeContext, activeProperty string, element any, base *url.URL, fromMap bool) (any, error) {
+	if element == nil {
+		return nil, nil
+	}
+
+	propertyDef := active.terms[activeProperty]
+
+	switch v := element.(type) {
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			expanded, err := e.expand(active, activeProperty, item, base, fromMap)
+			if err != nil {
+				return nil, err
+			}
+			if a, ok := expanded.([]any); ok && propertyDef != nil && propertyDef.container["@list"] {
+				expanded = map[string]any{"@list": a}
+			}
+			switch x := expanded.(type) {
+			case nil:
+				break
+			case []any:
+				result = append(result, x...)
+			default:
+				result = append(result, x)
+			}
+		}
+		return result, nil
+
+	case map[string]any:
+		return e.expandObject(active, activeProperty, propertyDef, v, base, fromMap)
+
+	default: // scalar
+		if activeProperty == "" || activeProperty == "@graph" {
+			return nil, nil // free-floating
+		}
+		if propertyDef != nil && propertyDef.hasContext {
+			var err error
+			active, err = e.process(active, propertyDef.context, propertyDef.base, processOptions{})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return e.expandValue(active, activeProperty, element)
+	}
+}
+
+// ExpandObject expands a JSON object. The definition of the active property is
+// nil for none.
+func (e *expansion) expandObject(active *activeContext, activeProperty string, propertyDef *termDefinition, element map[string]any, base *url.URL, fromMap bool) (any, error) {
+	if active.previous != nil && !fromMap {
+		revert := true
+		onlyID := len(element) == 1
+		for key := range element {
+			expanded, _, err := e.expandIRI(active, key, false, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			if expanded == "@value" || (onlyID && expanded == "@id") {
+				revert = false
+			}
+		}
+		if revert {
+			active = active.previous
+		}
+	}
+
+	var err error
+	if propertyDef != nil && propertyDef.hasContext {
+		active, err = e.process(active, propertyDef.context, propertyDef.base, processOptions{overrideProtected: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ctx, ok := element["@context"]; ok {
+		active, err = e.process(active, ctx, base, processOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	typeScoped := active
+	var inputType string
+	for _, key := range sorted.Keys(element) {
+		expanded, _, err := e.expandIRI(active, key, false, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		if expanded != "@type" {
+			continue
+		}
+		var types []string
+		for _, t := range asArray(element[key]) {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			def, ok := typeScoped.terms[t]
+			if ok && def.hasContext {
+				active, err = e.process(active, def.context, def.base, processOptions{noPropagate: true})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(types) != 0 && inputType == "" {
+			last := asArray(element[key])
+			if s, ok := last[len(last)-1].(string); ok {
+				inputType, _, err = e.expandIRI(active, s, false, true, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	result := make(map[string]any)
+	if err := e.expandEntries(active, typeScoped, activeProperty, element, result, inputType); err != nil {
+		return nil, err
+	}
+
+	if value, ok := result["@value"]; ok {
+		for key := range result {
+			switch key {
+			case "@direction", "@index", "@language", "@type", "@value":
+				continue
+			}
+			return nil, fmt.Errorf("JSON-LD invalid value object with %s", key)
+		}
+		_, hasLang := result["@language"]
+		_, hasDir := result["@direction"]
+		typ, hasType := result["@type"]
+		if hasType && (hasLang || hasDir) {
+			return nil, errors.New("JSON-LD invalid value object with both @type and @language or @direction")
+		}
+		if typ == "@json" {
+			return result, nil
+		}
+		if value == nil {
+			return nil, nil
+		}
+		switch value.(type) {
+		case string, bool, json.Number:
+			break
+		default:
+			return nil, errors.New("JSON-LD invalid value object value")
+		}
+		if _, ok := value.(string); !ok && hasLang {
+			return nil, errors.New("JSON-LD invalid language-tagged value")
+		}
+		if hasType {
+			s, ok := typ.(string)
+			if !ok || !(isAbsIRI(s) || strings.HasPrefix(s, blankPrefix)) {
+				return nil, fmt.Errorf("JSON-LD invalid typed value with @type %v", typ)
+			}
+		}
+	} else if typ, ok := result["@type"]; ok {
+		result["@type"] = asArray(typ)
+	} else if _, ok := result["@set"]; ok {
+		if err := checkListOrSet(result); err != nil {
+			return nil, err
+		}
+		return result["@set"], nil
+	} else if _, ok := result["@list"]; ok {
+		if err := checkListOrSet(result); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := result["@language"]; ok && len(result) == 1 {
+		return nil, nil
+	}
+
+	if activeProperty == "" || activeProperty == "@graph" {
+		_, hasValue := result["@value"]
+		_, hasList := result["@list"]
+		_, hasID := result["@id"]
+		if len(result) == 0 || hasValue || hasList || (hasID && len(result) == 1) {
+			return nil, nil // free-floating
+		}
+	}
+	return result, nil
+}
+
+func checkListOrSet(result map[string]any) error {
+	for key := range result {
+		switch key {
+		case "@list", "@set", "@index":
+			continue
+		}
+		return fmt.Errorf("JSON-LD invalid set or list object with %s", key)
+	}
+	if len(result) > 2 {
+		return errors.New("JSON-LD invalid set or list object")
+	}
+	return nil
+}
+
+// ExpandEntries implements step 13 and 14 of the expansion algorithm.
+func (e *expansion) expandEntries(active, typeScoped *activeContext, activeProperty string, element, result map[string]any, inputType string) error {
+	var nests []string
+	for _, key := range sorted.Keys(element) {
+		value := element[key]
+		if key == "@context" {
+			continue
+		}
+		expandedProperty, ok, err := e.expandIRI(active, key, false, true, nil)
+		if err != nil {
+			return err
+		}
+		if !ok || !(isKeyword(expandedProperty) || strings.Contains(expandedProperty, ":")) {
+			if looksLikeKeyword(key) {
+				continue // reserved for future use
+			}
+			return fmt.Errorf("%w: property %q", ErrUndefined, key)
+		}
+
+		if isKeyword(expandedProperty) {
+			if activeProperty == "@reverse" {
+				return fmt.Errorf("JSON-LD invalid reverse property map with %s", expandedProperty)
+			}
+			if _, ok := result[expandedProperty]; ok && expandedProperty != "@included" && expandedProperty != "@type" {
+				return fmt.Errorf("JSON-LD colliding keywords %s", expandedProperty)
+			}
+
+			var expandedValue any
+			switch expandedProperty {
+			case "@id":
+				s, ok := value.(string)
+				if !ok {
+					return errors.New("JSON-LD invalid @id value")
+				}
+				id, ok, err := e.expandIRI(active, s, true, false, nil)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					expandedValue = nil
+				} else {
+					expandedValue = id
+				}
+
+			case "@type":
+				var types []any
+				for _, t := range asArray(value) {
+					s, ok := t.(string)
+					if !ok {
+						return errors.New("JSON-LD invalid type value")
+					}
+					typ, ok, err := e.expandIRI(typeScoped, s, true, true, nil)
+					if err != nil {
+						return err
+					}
+					if !ok || !(isKeyword(typ) || strings.Contains(typ, ":")) {
+						return fmt.Errorf("%w: type %q", ErrUndefined, s)
+					}
+					types = append(types, typ)
+				}
+				if existing, ok := result["@type"]; ok {
+					types = append(asArray(existing), types...)
+				}
+				if _, isArray := value.([]any); !isArray && len(types) == 1 {
+					expandedValue = types[0]
+				} else {
+					expandedValue = types
+				}
+
+			case "@graph":
+				expanded, err := e.expand(active, "@graph", value, nil, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArray(expanded)
+
+			case "@included":
+				expanded, err := e.expand(active, "", value, nil, false)
+				if err != nil {
+					return err
+				}
+				included := asArray(expanded)
+				for _, item := range included {
+					if !isNodeObject(item) {
+						return errors.New("JSON-LD invalid @included value")
+					}
+				}
+				if existing, ok := result["@included"]; ok {
+					included = append(asArray(existing), included...)
+				}
+				expandedValue = included
+
+			case "@value":
+				if inputType == "@json" {
+					expandedValue = value
+					break
+				}
+				switch value.(type) {
+				case nil, string, bool, json.Number:
+					expandedValue = value
+				default:
+					return errors.New("JSON-LD invalid value object value")
+				}
+				if value == nil {
+					result["@value"] = nil
+					continue
+				}
+
+			case "@language":
+				s, ok := value.(string)
+				if !ok {
+					return errors.New("JSON-LD invalid language-tagged string")
+				}
+				expandedValue = strings.ToLower(s)
+
+			case "@direction":
+				if value != "ltr" && value != "rtl" {
+					return errors.New("JSON-LD invalid base direction")
+				}
+				expandedValue = value
+
+			case "@index":
+				if _, ok := value.(string); !ok {
+					return errors.New("JSON-LD invalid @index value")
+				}
+				expandedValue = value
+
+			case "@list":
+				if activeProperty == "" || activeProperty == "@graph" {
+					continue
+				}
+				expanded, err := e.expand(active, activeProperty, value, nil, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArray(expanded)
+
+			case "@set":
+				expandedValue, err = e.expand(active, activeProperty, value, nil, false)
+				if err != nil {
+					return err
+				}
+
+			case "@reverse":
+				if _, ok := value.(map[string]any); !ok {
+					return errors.New("JSON-LD invalid @reverse value")
+				}
+				expanded, err := e.expand(active, "@reverse", value, nil, false)
+				if err != nil {
+					return err
+				}
+				obj, _ := expanded.(map[string]any)
+				if reverse, ok := obj["@reverse"].(map[string]any); ok {
+					for property, items := range reverse {
+						result[property] = append(asArray(result[property]), asArray(items)...)
+					}
+				}
+				for property, items := range obj {
+					if property == "@reverse" {
+						continue
+					}
+					reverseMap, _ := result["@reverse"].(map[string]any)
+					if reverseMap == nil {
+						reverseMap = make(map[string]any)
+						result["@reverse"] = reverseMap
+					}
+					for _, item := range asArray(items) {
+						if isValueObject(item) || isListObject(item) {
+							return errors.New("JSON-LD invalid reverse property value")
+						}
+						reverseMap[property] = append(asArray(reverseMap[property]), item)
+					}
+				}
+				continue
+
+			case "@nest":
+				nests = append(nests, key)
+				continue
+
+			default:
+				continue // framing keywords
+			}
+
+			result[expandedProperty] = expandedValue
+			continue
+		}
+
+		def := active.terms[key]
+		var expandedValue any
+		switch {
+		case def != nil && def.typ == "@json":
+			expandedValue = map[string]any{"@value": value, "@type": "@json"}
+
+		case def != nil && def.container["@language"] && isMap(value):
+			var values []any
+			direction := active.direction
+			if def.hasDir {
+				direction = def.direction
+			}
+			languages := value.(map[string]any)
+			for _, language := range sorted.Keys(languages) {
+				for _, item := range asArray(languages[language]) {
+					if item == nil {
+						continue
+					}
+					s, ok := item.(string)
+					if !ok {
+						return errors.New("JSON-LD invalid language map value")
+					}
+					v := map[string]any{"@value": s}
+					expandedLanguage, _, err := e.expandIRI(active, language, false, true, nil)
+					if err != nil {
+						return err
+					}
+					if expandedLanguage != "@none" {
+						v["@language"] = strings.ToLower(language)
+					}
+					if direction != "" {
+						v["@direction"] = direction
+					}
+					values = append(values, v)
+				}
+			}
+			expandedValue = values
+
+		case def != nil && (def.container["@index"] || def.container["@type"] || def.container["@id"]) && isMap(value):
+			expandedValue, err = e.expandIndexMap(active, key, def, value.(map[string]any))
+			if err != nil {
+				return err
+			}
+
+		default:
+			expandedValue, err = e.expand(active, key, value, nil, false)
+			if err != nil {
+				return err
+			}
+		}
+		if expandedValue == nil {
+			continue
+		}
+
+		if def != nil && def.container["@list"] && !isListObject(expandedValue) {
+			expandedValue = map[string]any{"@list": asArray(expandedValue)}
+		}
+		if def != nil && def.container["@graph"] && !def.container["@id"] && !def.container["@index"] {
+			var graphs []any
+			for _, ev := range asArray(expandedValue) {
+				graphs = append(graphs, map[string]any{"@graph": asArray(ev)})
+			}
+			expandedValue = graphs
+		}
+
+		if def != nil && def.reverse {
+			reverseMap, _ := result["@reverse"].(map[string]any)
+			if reverseMap == nil {
+				reverseMap = make(map[string]any)
+				result["@reverse"] = reverseMap
+			}
+			for _, item := range asArray(expandedValue) {
+				if isValueObject(item) || isListObject(item) {
+					return errors.New("JSON-LD invalid reverse property value")
+				}
+				reverseMap[expandedProperty] = append(asArray(reverseMap[expandedProperty]), item)
+			}
+		} else {
+			result[expandedProperty] = append(asArray(result[expandedProperty]), asArray(expandedValue)...)
+		}
+	}
+
+	for _, key := range nests {
+		for _, nested := range asArray(element[key]) {
+			obj, ok := nested.(map[string]any)
+			if !ok {
+				return errors.New("JSON-LD invalid @nest value")
+			}
+			for k := range obj {
+				expanded, _, err := e.expandIRI(active, k, false, true, nil)
+				if err != nil {
+					return err
+				}
+				if expanded == "@value" {
+					return errors.New("JSON-LD invalid @nest value")
+				}
+			}
+			if err := e.expandEntries(active, typeScoped, activeProperty, obj, result, inputType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandIndexMap expands the value of a property with an @index, @id or @type
+// container.
+func (e *expansion) expandIndexMap(active *activeContext, key string, def *termDefinition, value map[string]any) (any, error) {
+	indexKey := "@index"
+	if def.index != "" {
+		indexKey = def.index
+	}
+
+	var values []any
+	for _, index := range sorted.Keys(value) {
+		mapContext := active
+		if (def.container["@id"] || def.container["@type"]) && active.previous != nil {
+			mapContext = active.previous
+		}
+		if indexDef, ok := mapContext.terms[index]; ok && def.container["@type"] && indexDef.hasContext {
+			var err error
+			mapContext, err = e.process(mapContext, indexDef.context, indexDef.base, processOptions{})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		expandedIndex, _, err := e.expandIRI(active, index, false, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := e.expand(mapContext, key, asArray(value[index]), nil, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range asArray(expanded) {
+			if def.container["@graph"] && !isGraphObject(item) {
+				item = map[string]any{"@graph": asArray(item)}
+			}
+			obj, ok := item.(map[string]any)
+			if !ok {
+				return nil, errors.New("JSON-LD invalid index map value")
+			}
+
+			switch {
+			case expandedIndex == "@none":
+				break
+
+			case def.container["@index"] && indexKey != "@index":
+				reExpanded, err := e.expandValue(active, indexKey, index)
+				if err != nil {
+					return nil, err
+				}
+				expandedIndexKey, _, err := e.expandIRI(active, indexKey, false, true, nil)
+				if err != nil {
+					return nil, err
+				}
+				if isValueObject(obj) {
+					return nil, errors.New("JSON-LD invalid value object in property-valued index")
+				}
+				obj[expandedIndexKey] = append([]any{reExpanded}, asArray(obj[expandedIndexKey])...)
+
+			case def.container["@index"]:
+				if _, ok := obj["@index"]; !ok {
+					obj["@index"] = index
+				}
+
+			case def.container["@id"]:
+				if _, ok := obj["@id"]; !ok {
+					id, _, err := e.expandIRI(active, index, true, false, nil)
+					if err != nil {
+						return nil, err
+					}
+					obj["@id"] = id
+				}
+
+			case def.container["@type"]:
+				obj["@type"] = append([]any{expandedIndex}, asArray(obj["@type"])...)
+			}
+			values = append(values, obj)
+		}
+	}
+	return values, nil
+}
+
+// ExpandValue implements the “Value Expansion” algorithm.
+func (e *expansion) expandValue(active *activeContext, activeProperty string, value any) (any, error) {
+	def := active.terms[activeProperty]
+	if def != nil {
+		if s, ok := value.(string); ok {
+			switch def.typ {
+			case "@id":
+				id, _, err := e.expandIRI(active, s, true, false, nil)
+				return map[string]any{"@id": id}, err
+			case "@vocab":
+				id, _, err := e.expandIRI(active, s, true, true, nil)
+				return map[string]any{"@id": id}, err
+			}
+		}
+	}
+
+	result := map[string]any{"@value": value}
+	if def != nil && def.typ != "" && def.typ != "@id" && def.typ != "@vocab" && def.typ != "@none" {
+		result["@type"] = def.typ
+	} else if _, ok := value.(string); ok {
+		language, direction := active.language, active.direction
+		if def != nil && def.hasLang {
+			language = def.language
+		}
+		if def != nil && def.hasDir {
+			direction = def.direction
+		}
+		if language != "" {
+			result["@language"] = language
+		}
+		if direction != "" {
+			result["@direction"] = direction
+		}
+	}
+	return result, nil
+}
+
+func asArray(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+func isMap(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+func isValueObject(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = obj["@value"]
+	return ok
+}
+
+func isListObject(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = obj["@list"]
+	return ok
+}
+
+func isGraphObject(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := obj["@graph"]; !ok {
+		return false
+	}
+	for key := range obj {
+		switch key {
+		case "@graph", "@id", "@index", "@context":
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isNodeObject(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	for _, key := range [...]string{"@value", "@list", "@set"} {
+		if _, ok := obj[key]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RDFGenerator implements the “Deserialize JSON-LD to RDF” algorithm, directly
+// on the expanded form.
+type rdfGenerator struct {
+	quads      []Quad
+	labels     map[string]string // blank node relabeling
+	blankCount int
+	err        error // first failure, if any
+}
+
+// NewBlank returns a blank node with a fresh label.
+func (g *rdfGenerator) newBlank() Term {
+	label := "b" + strconv.Itoa(g.blankCount)
+	g.blankCount++
+	return Term{Kind: BlankNode, Value: label}
+}
+
+// Resource returns the term of an @id value, if well-formed.
+func (g *rdfGenerator) resource(id string) (Term, bool) {
+	if strings.HasPrefix(id, blankPrefix) {
+		if g.labels == nil {
+			g.labels = make(map[string]string)
+		}
+		label, ok := g.labels[id]
+		if !ok {
+			label = g.newBlank().Value
+			g.labels[id] = label
+		}
+		return Term{Kind: BlankNode, Value: label}, true
+	}
+	if !isAbsIRI(id) {
+		return Term{}, false
+	}
+	return Term{Kind: IRI, Value: id}, true
+}
+
+func (g *rdfGenerator) emit(subject, predicate, object, graph Term) {
+	g.quads = append(g.quads, Quad{subject, predicate, object, graph})
+}
+
+// Node emits the statements of a node object in graph, and it returns the
+// subject.
+func (g *rdfGenerator) node(node map[string]any, graph Term) (Term, bool) {
+	var subject Term
+	if id, ok := node["@id"].(string); ok {
+		var wellFormed bool
+		subject, wellFormed = g.resource(id)
+		if !wellFormed {
+			// statements would be dropped from the signed data
+			if g.err == nil {
+				g.err = fmt.Errorf("%w: node @id %q is not an absolute IRI", ErrUndefined, id)
+			}
+			return Term{}, false
+		}
+	} else {
+		subject = g.newBlank()
+	}
+
+	for _, t := range asArray(node["@type"]) {
+		s, _ := t.(string)
+		if object, ok := g.resource(s); ok {
+			g.emit(subject, Term{Kind: IRI, Value: rdfType}, object, graph)
+		}
+	}
+
+	for _, property := range sorted.Keys(node) {
+		values := node[property]
+		switch property {
+		case "@id", "@type", "@index", "@context":
+			continue
+		case "@graph":
+			for _, item := range asArray(values) {
+				if obj, ok := item.(map[string]any); ok && isNodeObject(obj) {
+					g.node(obj, subject)
+				}
+			}
+			continue
+		case "@included":
+			for _, item := range asArray(values) {
+				if obj, ok := item.(map[string]any); ok {
+					g.node(obj, graph)
+				}
+			}
+			continue
+		case "@reverse":
+			reverse, _ := values.(map[string]any)
+			for _, p := range sorted.Keys(reverse) {
+				predicate, ok := g.resource(p)
+				if !ok || predicate.Kind != IRI {
+					continue
+				}
+				for _, item := range asArray(reverse[p]) {
+					obj, ok := item.(map[string]any)
+					if !ok {
+						continue
+					}
+					if s, ok := g.node(obj, graph); ok {
+						g.emit(s, predicate, subject, graph)
+					}
+				}
+			}
+			continue
+		}
+
+		predicate, ok := g.resource(property)
+		if !ok || predicate.Kind != IRI {
+			continue // no generalized RDF
+		}
+		for _, item := range asArray(values) {
+			if object, ok := g.object(item, graph); ok {
+				g.emit(subject, predicate, object, graph)
+			}
+		}
+	}
+	return subject, true
+}
+
+// Object returns the term of an expanded value, with any statements needed
+// emitted in graph.
+func (g *rdfGenerator) object(item any, graph Term) (Term, bool) {
+	obj, ok := item.(map[string]any)
+	if !ok {
+		return Term{}, false
+	}
+	if list, ok := obj["@list"]; ok {
+		return g.list(asArray(list), graph)
+	}
+	value, ok := obj["@value"]
+	if !ok {
+		return g.node(obj, graph)
+	}
+
+	datatype, _ := obj["@type"].(string)
+	if datatype != "" && datatype != "@json" && !isAbsIRI(datatype) {
+		return Term{}, false
+	}
+	language, hasLang := obj["@language"].(string)
+	if hasLang && !languageTag.MatchString(language) {
+		return Term{}, false
+	}
+
+	t := Term{Kind: Literal}
+	switch v := value.(type) {
+	case string:
+		t.Value = v
+	case bool:
+		t.Value = strconv.FormatBool(v)
+		if datatype == "" {
+			datatype = XSDBoolean
+		}
+	case json.Number:
+		if datatype == "@json" {
+			break
+		}
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return Term{}, false
+		}
+		if f != math.Trunc(f) || math.Abs(f) >= 1e21 || datatype == XSDDouble {
+			t.Value = formatDouble(f)
+			if datatype == "" {
+				datatype = XSDDouble
+			}
+		} else {
+			t.Value = strconv.FormatFloat(f, 'f', -1, 64)
+			if datatype == "" {
+				datatype = XSDInteger
+			}
+		}
+	}
+	if datatype == "@json" {
+		canonical, err := jcs.Marshal(value)
+		if err != nil {
+			return Term{}, false
+		}
+		t.Value = string(canonical)
+		datatype = RDFJSON
+	}
+
+	switch {
+	case datatype != "":
+		t.Datatype = datatype
+	case hasLang:
+		t.Datatype = LangString
+		t.Language = language
+	default:
+		t.Datatype = XSDString
+	}
+	return t, true
+}
+
+// List implements the “List to RDF Conversion” algorithm.
+func (g *rdfGenerator) list(items []any, graph Term) (Term, bool) {
+	if len(items) == 0 {
+		return Term{Kind: IRI, Value: rdfNil}, true
+	}
+	head := g.newBlank()
+	node := head
+	for i, item := range items {
+		if object, ok := g.object(item, graph); ok {
+			g.emit(node, Term{Kind: IRI, Value: rdfFirst}, object, graph)
+		}
+		rest := Term{Kind: IRI, Value: rdfNil}
+		if i+1 < len(items) {
+			rest = g.newBlank()
+		}
+		g.emit(node, Term{Kind: IRI, Value: rdfRest}, rest, graph)
+		node = rest
+	}
+	return head, true
+}
+
+var languageTag = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+
+// FormatDouble returns the canonical lexical form of xsd:double, as produced
+// by JavaScript's toExponential(15), with trailing zeros removed.
+func formatDouble(f float64) string {
+	s := strconv.FormatFloat(f, 'E', 15, 64)
+	i := strings.IndexByte(s, 'E')
+	mantissa, exponent := strings.TrimRight(s[:i], "0"), s[i+1:]
+	if strings.HasSuffix(mantissa, ".") {
+		mantissa += "0"
+	}
+	exponent = strings.TrimPrefix(exponent, "+")
+	negative := strings.HasPrefix(exponent, "-")
+	exponent = strings.TrimLeft(strings.TrimPrefix(exponent, "-"), "0")
+	if exponent == "" {
+		exponent = "0"
+	}
+	if negative {
+		exponent = "-" + exponent
+	}
+	return mantissa + "E" + exponent
+}
@@ -0,0 +1,193 @@
+package rdfc_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/did/rdfc"
+)
+
+// ExamplesV2 is not bundled, as it is for documentation purposes only.
+const examplesV2 = "https://www.w3.org/ns/credentials/examples/v2"
+
+func ExampleTransform() {
+	contexts := rdfc.Bundled()
+	contexts[examplesV2] = []byte(`{"@context": {"@vocab": "https://www.w3.org/ns/credentials/examples#"}}`)
+
+	// example from “Data Integrity EdDSA Cryptosuites v1.0”
+	canonical, err := rdfc.Transform([]byte(`{
+		"@context": [
+			"https://www.w3.org/ns/credentials/v2",
+			"https://www.w3.org/ns/credentials/examples/v2"
+		],
+		"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+		"type": ["VerifiableCredential", "AlumniCredential"],
+		"name": "Alumni Credential",
+		"description": "A minimum viable example of an Alumni Credential.",
+		"issuer": "https://vc.example/issuers/5678",
+		"validFrom": "2023-01-01T00:00:00Z",
+		"credentialSubject": {
+			"id": "did:example:abcdefgh",
+			"alumniOf": "The School of Examples"
+		}
+	}`), contexts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s", canonical)
+	// Output:
+	// <did:example:abcdefgh> <https://www.w3.org/ns/credentials/examples#alumniOf> "The School of Examples" .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/2018/credentials#VerifiableCredential> .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/ns/credentials/examples#AlumniCredential> .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <https://schema.org/description> "A minimum viable example of an Alumni Credential." .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <https://schema.org/name> "Alumni Credential" .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <https://www.w3.org/2018/credentials#credentialSubject> <did:example:abcdefgh> .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <https://www.w3.org/2018/credentials#issuer> <https://vc.example/issuers/5678> .
+	// <urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33> <https://www.w3.org/2018/credentials#validFrom> "2023-01-01T00:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct{ name, doc, want string }{
+		{"data-integrity proof configuration", `{
+			"@context": "https://www.w3.org/ns/credentials/v2",
+			"type": "DataIntegrityProof",
+			"cryptosuite": "eddsa-rdfc-2022",
+			"created": "2023-02-24T23:36:38Z",
+			"verificationMethod": "did:key:z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2#z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2",
+			"proofPurpose": "assertionMethod"
+		}`, `_:c14n0 <http://purl.org/dc/terms/created> "2023-02-24T23:36:38Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://w3id.org/security#DataIntegrityProof> .
+_:c14n0 <https://w3id.org/security#cryptosuite> "eddsa-rdfc-2022"^^<https://w3id.org/security#cryptosuiteString> .
+_:c14n0 <https://w3id.org/security#proofPurpose> <https://w3id.org/security#assertionMethod> .
+_:c14n0 <https://w3id.org/security#verificationMethod> <did:key:z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2#z6MkrJVnaZkeFzdQyMZu1cgjg7k1pZZ6pvBQ7XJPt4swbTQ2> .
+`},
+
+		{"legacy credential with proof graph", `{
+			"@context": [
+				"https://www.w3.org/2018/credentials/v1",
+				"https://w3id.org/security/suites/ed25519-2020/v1"
+			],
+			"id": "http://example.edu/credentials/3732",
+			"type": ["VerifiableCredential"],
+			"issuer": "did:example:issuer",
+			"issuanceDate": "2010-01-01T19:23:24Z",
+			"credentialSubject": {"id": "did:example:subject"},
+			"proof": {
+				"type": "Ed25519Signature2020",
+				"created": "2021-11-13T18:19:39Z",
+				"verificationMethod": "did:example:issuer#key-1",
+				"proofPurpose": "assertionMethod",
+				"proofValue": "z58DAdFfa9"
+			}
+		}`, `<http://example.edu/credentials/3732> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/2018/credentials#VerifiableCredential> .
+<http://example.edu/credentials/3732> <https://w3id.org/security#proof> _:c14n0 .
+<http://example.edu/credentials/3732> <https://www.w3.org/2018/credentials#credentialSubject> <did:example:subject> .
+<http://example.edu/credentials/3732> <https://www.w3.org/2018/credentials#issuanceDate> "2010-01-01T19:23:24Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+<http://example.edu/credentials/3732> <https://www.w3.org/2018/credentials#issuer> <did:example:issuer> .
+_:c14n1 <http://purl.org/dc/terms/created> "2021-11-13T18:19:39Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> _:c14n0 .
+_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://w3id.org/security#Ed25519Signature2020> _:c14n0 .
+_:c14n1 <https://w3id.org/security#proofPurpose> <https://w3id.org/security#assertionMethod> _:c14n0 .
+_:c14n1 <https://w3id.org/security#proofValue> "z58DAdFfa9"^^<https://w3id.org/security#multibase> _:c14n0 .
+_:c14n1 <https://w3id.org/security#verificationMethod> <did:example:issuer#key-1> _:c14n0 .
+`},
+
+		{"DID document", `{
+			"@context": [
+				"https://www.w3.org/ns/did/v1",
+				"https://w3id.org/security/multikey/v1"
+			],
+			"id": "did:example:123",
+			"verificationMethod": [{
+				"id": "did:example:123#key-1",
+				"type": "Multikey",
+				"controller": "did:example:123",
+				"publicKeyMultibase": "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+			}],
+			"authentication": ["did:example:123#key-1"]
+		}`, `<did:example:123#key-1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://w3id.org/security#Multikey> .
+<did:example:123#key-1> <https://w3id.org/security#controller> <did:example:123> .
+<did:example:123#key-1> <https://w3id.org/security#publicKeyMultibase> "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"^^<https://w3id.org/security#multibase> .
+<did:example:123> <https://w3id.org/security#authenticationMethod> <did:example:123#key-1> .
+<did:example:123> <https://w3id.org/security#verificationMethod> <did:example:123#key-1> .
+`},
+
+		{"literals and lists", `{
+			"@context": {
+				"@vocab": "http://example.com/",
+				"list": {"@container": "@list"},
+				"greeting": {"@language": "en"},
+				"data": {"@type": "@json"}
+			},
+			"@id": "http://example.com/s",
+			"list": [7, 2.5, true],
+			"greeting": "hi\n",
+			"other": {"@value": "hoi", "@language": "NL"},
+			"data": {"b": [1.0, null], "a": "x"}
+		}`, `<http://example.com/s> <http://example.com/data> "{\"a\":\"x\",\"b\":[1,null]}"^^<http://www.w3.org/1999/02/22-rdf-syntax-ns#JSON> .
+<http://example.com/s> <http://example.com/greeting> "hi\n"@en .
+<http://example.com/s> <http://example.com/list> _:c14n1 .
+<http://example.com/s> <http://example.com/other> "hoi"@nl .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "2.5E0"^^<http://www.w3.org/2001/XMLSchema#double> .
+_:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:c14n2 .
+_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "7"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:c14n1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:c14n0 .
+_:c14n2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .
+_:c14n2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+`},
+	}
+	for _, test := range tests {
+		got, err := rdfc.Transform([]byte(test.doc), nil)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      error
+	}{
+		{"undefined property", `{"@context": {"a": "http://example.com/a"}, "b": 1}`, rdfc.ErrUndefined},
+		{"undefined type", `{"@context": "https://www.w3.org/ns/did/v1", "id": "did:example:123", "type": "Unknown"}`, rdfc.ErrUndefined},
+		{"relative node", `{"@context": {"a": "http://example.com/a"}, "@id": "s", "a": 1}`, rdfc.ErrUndefined},
+		{"relative object", `{"@context": {"a": {"@id": "http://example.com/a", "@type": "@id"}}, "@id": "http://example.com/s", "a": {"@id": "o", "a": "http://example.com/x"}}`, rdfc.ErrUndefined},
+		{"remote context", `{"@context": "https://example.com/context.jsonld", "@id": "http://example.com/s"}`, rdfc.ErrContext},
+	}
+	for _, test := range tests {
+		_, err := rdfc.Transform([]byte(test.doc), nil)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestProtectedTerm(t *testing.T) {
+	_, err := rdfc.Transform([]byte(`{
+		"@context": [
+			"https://www.w3.org/ns/did/v1",
+			{"controller": "http://example.com/controller"}
+		],
+		"id": "did:example:123",
+		"controller": "did:example:456"
+	}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "protected term redefinition") {
+		t.Errorf("got error %v, want a protected term redefinition", err)
+	}
+}
+
+func TestBundled(t *testing.T) {
+	for ctxURL := range rdfc.Bundled() {
+		doc := fmt.Sprintf(`{"@context": %q, "@id": "http://example.com/s"}`, ctxURL)
+		if _, err := rdfc.ToRDF([]byte(doc), nil); err != nil {
+			t.Errorf("context %s: %s", ctxURL, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package rdfc
+
+import (
+	"embed"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/vc"
+)
+
+// Loader maps the URL of each JSON-LD context to its document. Remote contexts
+// are never fetched. Any context URL absent causes an ErrContext.
+type Loader map[string][]byte
+
+//go:embed contexts/*.jsonld
+var contexts embed.FS
+
+// BundledFiles has the context documents embedded per URL.
+var bundledFiles = map[string]string{
+	did.V1: "contexts/did-v1.jsonld",
+	vc.V1:  "contexts/credentials-v1.jsonld",
+	vc.V2:  "contexts/credentials-v2.jsonld",
+
+	"https://w3id.org/security/v1":                     "contexts/security-v1.jsonld",
+	"https://w3id.org/security/v2":                     "contexts/security-v2.jsonld",
+	"https://w3id.org/security/data-integrity/v2":      "contexts/data-integrity-v2.jsonld",
+	"https://w3id.org/security/multikey/v1":            "contexts/multikey-v1.jsonld",
+	"https://w3id.org/security/suites/ed25519-2020/v1": "contexts/ed25519-2020-v1.jsonld",
+}
+
+// Bundled returns a new Loader with the contexts embedded in this package,
+// which are did.V1, vc.V1, vc.V2, security v1 and v2, data-integrity v2,
+// multikey v1 and the ed25519-2020 suite. Entries may be added or replaced
+// freely.
+func Bundled() Loader {
+	l := make(Loader, len(bundledFiles))
+	for ctxURL, name := range bundledFiles {
+		data, err := contexts.ReadFile(name)
+		if err != nil {
+			panic(err) // embedded
+		}
+		l[ctxURL] = data
+	}
+	return l
+}
@@ -0,0 +1,523 @@
+// Package rdfc implements “RDF Dataset Canonicalization” (RDFC-1.0) for JSON-LD
+// documents. The root package omits JSON-LD by design. This package is needed
+// only for the cryptosuites which sign RDF, e.g., eddsa-rdfc-2022 and the
+// legacy Ed25519Signature2020, which it registers with package dataintegrity on
+// import. JSON-LD contexts are never fetched. Instead, a Loader provides the
+// documents, with a bundled set of common contexts. See
+// https://www.w3.org/TR/rdf-canon/ for the specification.
+package rdfc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind classifies RDF terms.
+type Kind uint8
+
+// RDF Term Kinds
+const (
+	// The zero Term is the default graph.
+	DefaultGraph Kind = iota
+	IRI
+	BlankNode
+	Literal
+)
+
+// Datatypes of literals with special treatment.
+const (
+	XSDString  = "http://www.w3.org/2001/XMLSchema#string"
+	XSDBoolean = "http://www.w3.org/2001/XMLSchema#boolean"
+	XSDInteger = "http://www.w3.org/2001/XMLSchema#integer"
+	XSDDouble  = "http://www.w3.org/2001/XMLSchema#double"
+	LangString = "http://www.w3.org/1999/02/22-rdf-syntax-ns#langString"
+	RDFJSON    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#JSON"
+)
+
+const (
+	rdfType  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+	rdfFirst = "http://www.w3.org/1999/02/22-rdf-syntax-ns#first"
+	rdfRest  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#rest"
+	rdfNil   = "http://www.w3.org/1999/02/22-rdf-syntax-ns#nil"
+
+	blankPrefix   = "_:"
+	canonicalName = "_:c14n"
+)
+
+// Term is an RDF term.
+type Term struct {
+	Kind Kind
+	// Value is the IRI, the blank node label without the "_:" prefix, or
+	// the lexical form of the literal.
+	Value string
+	// Datatype is the IRI of a literal.
+	Datatype string
+	// Language is the tag of a literal with the LangString datatype.
+	Language string
+}
+
+// AppendNQuads appends the term in canonical N-Quads notation.
+func (t *Term) appendNQuads(buf []byte) []byte {
+	switch t.Kind {
+	case IRI:
+		buf = append(buf, '<')
+		buf = append(buf, t.Value...)
+		return append(buf, '>')
+	case BlankNode:
+		buf = append(buf, blankPrefix...)
+		return append(buf, t.Value...)
+	case Literal:
+		buf = appendLiteral(buf, t.Value)
+		switch t.Datatype {
+		case LangString:
+			buf = append(buf, '@')
+			buf = append(buf, t.Language...)
+		case XSDString, "":
+			break
+		default:
+			buf = append(buf, "^^<"...)
+			buf = append(buf, t.Datatype...)
+			buf = append(buf, '>')
+		}
+	}
+	return buf
+}
+
+// AppendLiteral appends s as a quoted string, with the escapes of canonical
+// N-Triples.
+func appendLiteral(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			buf = append(buf, '\\', '"')
+		case '\\':
+			buf = append(buf, '\\', '\\')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		default:
+			if c < ' ' || c == 0x7f {
+				buf = append(buf, '\\', 'u', '0', '0', hexUpper[c>>4], hexUpper[c&15])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+	}
+	return append(buf, '"')
+}
+
+const hexUpper = "0123456789ABCDEF"
+
+// Quad is an RDF statement in a named graph, or in the default graph.
+type Quad struct {
+	Subject, Predicate, Object Term
+	Graph                      Term
+}
+
+// AppendNQuads appends the quad as a line in canonical N-Quads notation.
+func (q *Quad) AppendNQuads(buf []byte) []byte {
+	buf = q.Subject.appendNQuads(buf)
+	buf = append(buf, ' ')
+	buf = q.Predicate.appendNQuads(buf)
+	buf = append(buf, ' ')
+	buf = q.Object.appendNQuads(buf)
+	if q.Graph.Kind != DefaultGraph {
+		buf = append(buf, ' ')
+		buf = q.Graph.appendNQuads(buf)
+	}
+	return append(buf, " .\n"...)
+}
+
+// String returns the quad in canonical N-Quads notation, without the line
+// feed.
+func (q Quad) String() string {
+	s := q.AppendNQuads(nil)
+	return string(s[:len(s)-1])
+}
+
+// NQuads returns the quads in canonical N-Quads notation.
+func NQuads(quads []Quad) []byte {
+	var buf []byte
+	for i := range quads {
+		buf = quads[i].AppendNQuads(buf)
+	}
+	return buf
+}
+
+// ErrPoisoned signals a dataset which exceeds the computation budget of
+// canonicalization. Blank nodes which are hard to tell apart, like a fully
+// connected graph, take factorial time with the N-degree hashing of RDFC-1.0.
+// See section 4.4.1 of the specification, “Dataset Poisoning”.
+var ErrPoisoned = errors.New("RDF dataset exceeds canonicalization budget")
+
+// MaxNDegreeSteps limits the number of N-degree hash calls plus the number of
+// permutations they evaluate, per canonicalization.
+const maxNDegreeSteps = 100_000
+
+// Canonicalize returns the dataset with canonical blank node labels, i.e.,
+// "c14n0", "c14n1", etc. The quads are returned in the order of their N-Quads
+// notation, without any duplicates. The input is not modified. Errors other than
+// ErrPoisoned are not returned.
+func Canonicalize(dataset []Quad) ([]Quad, error) {
+	c := canonicalizer{
+		quadsPerBlank: make(map[string][]int),
+		canonical:     newIssuer(canonicalName),
+	}
+	c.quads = dedupe(dataset)
+
+	// map blank nodes to quads
+	for i := range c.quads {
+		for _, t := range c.quads[i].blankNodes() {
+			indices := c.quadsPerBlank[t.Value]
+			if len(indices) == 0 || indices[len(indices)-1] != i {
+				c.quadsPerBlank[t.Value] = append(indices, i)
+			}
+		}
+	}
+
+	// hash first degree
+	blanksPerHash := make(map[string][]string)
+	for label := range c.quadsPerBlank {
+		hash := c.hashFirstDegree(label)
+		blanksPerHash[hash] = append(blanksPerHash[hash], label)
+	}
+	hashes := make([]string, 0, len(blanksPerHash))
+	for hash := range blanksPerHash {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	// issue unique hashes first
+	var shared []string
+	for _, hash := range hashes {
+		if labels := blanksPerHash[hash]; len(labels) == 1 {
+			c.canonical.issue(labels[0])
+		} else {
+			shared = append(shared, hash)
+		}
+	}
+
+	// issue remaining in order of the N-degree hash
+	for _, hash := range shared {
+		var results []nDegreeResult
+		for _, label := range blanksPerHash[hash] {
+			if c.canonical.has(label) {
+				continue
+			}
+			issuer := newIssuer("_:b")
+			issuer.issue(label)
+			result, err := c.hashNDegree(label, issuer)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].hash < results[j].hash
+		})
+		for _, r := range results {
+			for _, label := range r.issuer.order {
+				c.canonical.issue(label)
+			}
+		}
+	}
+
+	// relabel
+	out := make([]Quad, len(c.quads))
+	lines := make([]string, len(c.quads))
+	for i, q := range c.quads {
+		for _, t := range [...]*Term{&q.Subject, &q.Object, &q.Graph} {
+			if t.Kind == BlankNode {
+				t.Value = strings.TrimPrefix(c.canonical.ids[t.Value], blankPrefix)
+			}
+		}
+		out[i] = q
+		lines[i] = string(q.AppendNQuads(nil))
+	}
+	sort.Sort(byLine{out, lines})
+	return out, nil
+}
+
+type byLine struct {
+	quads []Quad
+	lines []string
+}
+
+func (b byLine) Len() int           { return len(b.quads) }
+func (b byLine) Less(i, j int) bool { return b.lines[i] < b.lines[j] }
+func (b byLine) Swap(i, j int) {
+	b.quads[i], b.quads[j] = b.quads[j], b.quads[i]
+	b.lines[i], b.lines[j] = b.lines[j], b.lines[i]
+}
+
+// Dedupe returns the quads as a set, in their original order.
+func dedupe(quads []Quad) []Quad {
+	set := make(map[Quad]struct{}, len(quads))
+	unique := make([]Quad, 0, len(quads))
+	for _, q := range quads {
+		if _, ok := set[q]; !ok {
+			set[q] = struct{}{}
+			unique = append(unique, q)
+		}
+	}
+	return unique
+}
+
+// BlankNodes returns each blank node component, including duplicates.
+func (q *Quad) blankNodes() []*Term {
+	var terms []*Term
+	for _, t := range [...]*Term{&q.Subject, &q.Object, &q.Graph} {
+		if t.Kind == BlankNode {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// Issuer is an identifier issuer, which keeps the order of issuance.
+type idIssuer struct {
+	prefix string
+	ids    map[string]string // label to identifier
+	order  []string          // labels issued
+}
+
+func newIssuer(prefix string) *idIssuer {
+	return &idIssuer{prefix: prefix, ids: make(map[string]string)}
+}
+
+func (i *idIssuer) has(label string) bool {
+	_, ok := i.ids[label]
+	return ok
+}
+
+// Issue returns the identifier of label, with a new one issued if needed.
+func (i *idIssuer) issue(label string) string {
+	if id, ok := i.ids[label]; ok {
+		return id
+	}
+	id := i.prefix + strconv.Itoa(len(i.order))
+	i.ids[label] = id
+	i.order = append(i.order, label)
+	return id
+}
+
+func (i *idIssuer) copy() *idIssuer {
+	c := &idIssuer{
+		prefix: i.prefix,
+		ids:    make(map[string]string, len(i.ids)),
+		order:  append([]string(nil), i.order...),
+	}
+	for label, id := range i.ids {
+		c.ids[label] = id
+	}
+	return c
+}
+
+type canonicalizer struct {
+	quads         []Quad
+	quadsPerBlank map[string][]int // blank node label to quad indices
+	canonical     *idIssuer
+	firstDegree   map[string]string // cached hashes per label
+	steps         int               // N-degree work done
+}
+
+// Step accounts for a unit of N-degree work.
+func (c *canonicalizer) step() error {
+	c.steps++
+	if c.steps > maxNDegreeSteps {
+		return ErrPoisoned
+	}
+	return nil
+}
+
+// HashFirstDegree implements the “Hash First Degree Quads” algorithm.
+func (c *canonicalizer) hashFirstDegree(label string) string {
+	if hash, ok := c.firstDegree[label]; ok {
+		return hash
+	}
+
+	indices := c.quadsPerBlank[label]
+	lines := make([]string, len(indices))
+	for n, i := range indices {
+		q := c.quads[i] // copy
+		for _, t := range q.blankNodes() {
+			if t.Value == label {
+				t.Value = "a"
+			} else {
+				t.Value = "z"
+			}
+		}
+		lines[n] = string(q.AppendNQuads(nil))
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	if c.firstDegree == nil {
+		c.firstDegree = make(map[string]string)
+	}
+	c.firstDegree[label] = hash
+	return hash
+}
+
+// HashRelated implements the “Hash Related Blank Node” algorithm.
+func (c *canonicalizer) hashRelated(related string, q *Quad, issuer *idIssuer, position byte) string {
+	var id string
+	if c.canonical.has(related) {
+		id = c.canonical.ids[related]
+	} else if issuer.has(related) {
+		id = issuer.ids[related]
+	} else {
+		id = c.hashFirstDegree(related)
+	}
+
+	input := []byte{position}
+	if position != 'g' {
+		input = q.Predicate.appendNQuads(input)
+	}
+	input = append(input, id...)
+	sum := sha256.Sum256(input)
+	return hex.EncodeToString(sum[:])
+}
+
+type nDegreeResult struct {
+	hash   string
+	issuer *idIssuer
+}
+
+// HashNDegree implements the “Hash N-Degree Quads” algorithm. The only error
+// is ErrPoisoned.
+func (c *canonicalizer) hashNDegree(label string, issuer *idIssuer) (nDegreeResult, error) {
+	if err := c.step(); err != nil {
+		return nDegreeResult{}, err
+	}
+
+	relatedPerHash := make(map[string][]string)
+	for _, i := range c.quadsPerBlank[label] {
+		q := &c.quads[i]
+		for _, component := range [...]struct {
+			term     *Term
+			position byte
+		}{
+			{&q.Subject, 's'},
+			{&q.Object, 'o'},
+			{&q.Graph, 'g'},
+		} {
+			if component.term.Kind != BlankNode || component.term.Value == label {
+				continue
+			}
+			related := component.term.Value
+			hash := c.hashRelated(related, q, issuer, component.position)
+			relatedPerHash[hash] = append(relatedPerHash[hash], related)
+		}
+	}
+	hashes := make([]string, 0, len(relatedPerHash))
+	for hash := range relatedPerHash {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	h := sha256.New()
+	for _, relatedHash := range hashes {
+		h.Write([]byte(relatedHash))
+
+		var chosenPath string
+		var chosenIssuer *idIssuer
+		err := permute(relatedPerHash[relatedHash], func(permutation []string) error {
+			if err := c.step(); err != nil {
+				return err
+			}
+
+			issuerCopy := issuer.copy()
+			var path strings.Builder
+			var recursionList []string
+			for _, related := range permutation {
+				if c.canonical.has(related) {
+					path.WriteString(c.canonical.ids[related])
+				} else {
+					if !issuerCopy.has(related) {
+						recursionList = append(recursionList, related)
+					}
+					path.WriteString(issuerCopy.issue(related))
+				}
+				if chosenPath != "" && path.Len() >= len(chosenPath) && path.String() > chosenPath {
+					return nil // skip permutation
+				}
+			}
+
+			for _, related := range recursionList {
+				result, err := c.hashNDegree(related, issuerCopy)
+				if err != nil {
+					return err
+				}
+				path.WriteString(issuerCopy.issue(related))
+				path.WriteByte('<')
+				path.WriteString(result.hash)
+				path.WriteByte('>')
+				issuerCopy = result.issuer
+				if chosenPath != "" && path.Len() >= len(chosenPath) && path.String() > chosenPath {
+					return nil // skip permutation
+				}
+			}
+
+			if chosenPath == "" || path.String() < chosenPath {
+				chosenPath = path.String()
+				chosenIssuer = issuerCopy
+			}
+			return nil
+		})
+		if err != nil {
+			return nDegreeResult{}, err
+		}
+
+		h.Write([]byte(chosenPath))
+		issuer = chosenIssuer
+	}
+
+	return nDegreeResult{hex.EncodeToString(h.Sum(nil)), issuer}, nil
+}
+
+// Permute calls f with each permutation of a, in lexical order of the labels.
+// The slice passed to f is reused. Any error from f is returned immediately.
+func permute(a []string, f func([]string) error) error {
+	p := append([]string(nil), a...)
+	sort.Strings(p)
+	for {
+		if err := f(p); err != nil {
+			return err
+		}
+
+		// next permutation in lexical order
+		i := len(p) - 2
+		for i >= 0 && p[i] >= p[i+1] {
+			i--
+		}
+		if i < 0 {
+			return nil
+		}
+		j := len(p) - 1
+		for p[j] <= p[i] {
+			j--
+		}
+		p[i], p[j] = p[j], p[i]
+		for l, r := i+1, len(p)-1; l < r; l, r = l+1, r-1 {
+			p[l], p[r] = p[r], p[l]
+		}
+	}
+}
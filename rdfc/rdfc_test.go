@@ -0,0 +1,194 @@
+package rdfc_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did/rdfc"
+)
+
+// ParseQuads reads the N-Quads subset with IRIs and blank nodes only.
+func parseQuads(t testing.TB, s string) []rdfc.Quad {
+	var quads []rdfc.Quad
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), " ."))
+		if len(fields) < 3 || len(fields) > 4 {
+			t.Fatalf("malformed N-Quads line %q", line)
+		}
+		var terms [4]rdfc.Term
+		for i, f := range fields {
+			switch {
+			case strings.HasPrefix(f, "<") && strings.HasSuffix(f, ">"):
+				terms[i] = rdfc.Term{Kind: rdfc.IRI, Value: f[1 : len(f)-1]}
+			case strings.HasPrefix(f, "_:"):
+				terms[i] = rdfc.Term{Kind: rdfc.BlankNode, Value: f[2:]}
+			default:
+				t.Fatalf("unsupported N-Quads term %q", f)
+			}
+		}
+		quads = append(quads, rdfc.Quad{Subject: terms[0], Predicate: terms[1], Object: terms[2], Graph: terms[3]})
+	}
+	return quads
+}
+
+func ExampleCanonicalize() {
+	ex := func(s string) rdfc.Term { return rdfc.Term{Kind: rdfc.IRI, Value: "http://example.com/#" + s} }
+	e0 := rdfc.Term{Kind: rdfc.BlankNode, Value: "e0"}
+	e1 := rdfc.Term{Kind: rdfc.BlankNode, Value: "e1"}
+
+	// example from RDFC-1.0, section 4.4.1
+	canonical, err := rdfc.Canonicalize([]rdfc.Quad{
+		{Subject: ex("p"), Predicate: ex("q"), Object: e0},
+		{Subject: ex("p"), Predicate: ex("r"), Object: e1},
+		{Subject: e0, Predicate: ex("s"), Object: ex("u")},
+		{Subject: e1, Predicate: ex("t"), Object: ex("u")},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s", rdfc.NQuads(canonical))
+	// Output:
+	// <http://example.com/#p> <http://example.com/#q> _:c14n0 .
+	// <http://example.com/#p> <http://example.com/#r> _:c14n1 .
+	// _:c14n0 <http://example.com/#s> <http://example.com/#u> .
+	// _:c14n1 <http://example.com/#t> <http://example.com/#u> .
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct{ name, in, want string }{
+		{"shared hashes", `
+<http://example.com/#p> <http://example.com/#q> _:e0 .
+<http://example.com/#p> <http://example.com/#q> _:e1 .
+_:e0 <http://example.com/#p> _:e2 .
+_:e1 <http://example.com/#p> _:e3 .
+_:e2 <http://example.com/#r> _:e3 .
+`, `<http://example.com/#p> <http://example.com/#q> _:c14n2 .
+<http://example.com/#p> <http://example.com/#q> _:c14n3 .
+_:c14n0 <http://example.com/#r> _:c14n1 .
+_:c14n2 <http://example.com/#p> _:c14n1 .
+_:c14n3 <http://example.com/#p> _:c14n0 .
+`},
+		{"duplicates", `
+<http://example.com/#s> <http://example.com/#p> _:x .
+<http://example.com/#s> <http://example.com/#p> _:x .
+`, `<http://example.com/#s> <http://example.com/#p> _:c14n0 .
+`},
+		{"named graph", `
+_:g <http://example.com/#p> _:g _:g .
+`, `_:c14n0 <http://example.com/#p> _:c14n0 _:c14n0 .
+`},
+	}
+	for _, test := range tests {
+		canonical, err := rdfc.Canonicalize(parseQuads(t, test.in))
+		if err != nil {
+			t.Errorf("%s: got error: %s", test.name, err)
+			continue
+		}
+		got := rdfc.NQuads(canonical)
+		if string(got) != test.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+// Canonical output must not depend on the blank node labels, nor on the order
+// of the input.
+func TestCanonicalizeIsomorphic(t *testing.T) {
+	const in = `
+_:a <http://example.com/#next> _:b .
+_:b <http://example.com/#next> _:c .
+_:c <http://example.com/#next> _:a .
+_:d <http://example.com/#next> _:e .
+_:e <http://example.com/#next> _:d .
+_:a <http://example.com/#rel> _:d .
+_:c <http://example.com/#rel> _:e .
+`
+	canonical, err := rdfc.Canonicalize(parseQuads(t, in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rdfc.NQuads(canonical)
+
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 50; i++ {
+		quads := parseQuads(t, in)
+		r.Shuffle(len(quads), func(i, j int) { quads[i], quads[j] = quads[j], quads[i] })
+		rename := make(map[string]string)
+		for _, label := range r.Perm(5) {
+			rename[string(rune('a'+len(rename)))] = fmt.Sprint("n", label)
+		}
+		for i := range quads {
+			for _, term := range []*rdfc.Term{&quads[i].Subject, &quads[i].Object} {
+				term.Value = rename[term.Value]
+			}
+		}
+
+		canonical, err := rdfc.Canonicalize(quads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := rdfc.NQuads(canonical)
+		if string(got) != string(want) {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+// Clique returns a fully connected graph of n blank nodes.
+func clique(n int) []rdfc.Quad {
+	p := rdfc.Term{Kind: rdfc.IRI, Value: "http://example.com/#p"}
+	var quads []rdfc.Quad
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j {
+				quads = append(quads, rdfc.Quad{
+					Subject:   rdfc.Term{Kind: rdfc.BlankNode, Value: fmt.Sprint("n", i)},
+					Predicate: p,
+					Object:    rdfc.Term{Kind: rdfc.BlankNode, Value: fmt.Sprint("n", j)},
+				})
+			}
+		}
+	}
+	return quads
+}
+
+func TestCanonicalizePoisoned(t *testing.T) {
+	canonical, err := rdfc.Canonicalize(clique(4))
+	if err != nil {
+		t.Fatal("small clique got error:", err)
+	}
+	if len(canonical) != 12 {
+		t.Errorf("small clique got %d quads, want 12", len(canonical))
+	}
+
+	start := time.Now()
+	_, err = rdfc.Canonicalize(clique(10))
+	if !errors.Is(err, rdfc.ErrPoisoned) {
+		t.Errorf("got error %v, want rdfc.ErrPoisoned", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("took %s to fail", d)
+	}
+}
+
+func TestQuadString(t *testing.T) {
+	q := rdfc.Quad{
+		Subject:   rdfc.Term{Kind: rdfc.IRI, Value: "http://example.com/s"},
+		Predicate: rdfc.Term{Kind: rdfc.IRI, Value: "http://example.com/p"},
+		Object:    rdfc.Term{Kind: rdfc.Literal, Value: "tab\there \"quoted\"\\\x01\x7f", Datatype: rdfc.XSDString},
+	}
+	const want = `<http://example.com/s> <http://example.com/p> "tab\there \"quoted\"\\\u0001\u007F"`
+	if got := q.String(); got != want+" ." {
+		t.Errorf("got %s, want %s .", got, want)
+	}
+
+	q.Object = rdfc.Term{Kind: rdfc.Literal, Value: "hallo", Datatype: rdfc.LangString, Language: "nl"}
+	if got, want := q.String(), `<http://example.com/s> <http://example.com/p> "hallo"@nl .`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
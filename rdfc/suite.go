@@ -0,0 +1,12 @@
+package rdfc
+
+import "github.com/pascaldekloe/did/dataintegrity"
+
+// Data-integrity proofs of EdDSARDFC2022 and Ed25519Signature2020 sign RDF.
+func init() {
+	transform := func(doc []byte, contexts map[string][]byte) ([]byte, error) {
+		return Transform(doc, contexts)
+	}
+	dataintegrity.RegisterSuite(dataintegrity.EdDSARDFC2022, transform)
+	dataintegrity.RegisterSuite(dataintegrity.Ed25519Signature2020, transform)
+}
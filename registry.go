@@ -0,0 +1,84 @@
+package did
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry multiplexes resolution on the DID Method. The zero value is ready
+// for use. Multiple goroutines may invoke methods on a Registry simultaneously.
+type Registry struct {
+	mutex     sync.RWMutex
+	perMethod map[string]Resolver
+}
+
+// Register installs r for the method name. Any previous registration for the
+// name gets replaced. A nil r removes the method from the registry. Names must
+// consist of one or more letters 'a'–'z' and/or digits '0'–'9' exclusively.
+func (reg *Registry) Register(method string, r Resolve) error {
+	if r == nil {
+		return reg.RegisterResolver(method, nil)
+	}
+	return reg.RegisterResolver(method, r)
+}
+
+// RegisterResolver is like Register, yet with context support.
+func (reg *Registry) RegisterResolver(method string, r Resolver) error {
+	if !validMethodName(method) {
+		return fmt.Errorf("DID method name %q is not valid", method)
+	}
+
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if r == nil {
+		delete(reg.perMethod, method)
+		return nil
+	}
+	if reg.perMethod == nil {
+		reg.perMethod = make(map[string]Resolver)
+	}
+	reg.perMethod[method] = r
+	return nil
+}
+
+// Methods returns the names registered in alphabetical order.
+func (reg *Registry) Methods() []string {
+	reg.mutex.RLock()
+	names := make([]string, 0, len(reg.perMethod))
+	for name := range reg.perMethod {
+		names = append(names, name)
+	}
+	reg.mutex.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Resolve conforms to the Resolve signature. DIDs with a Method not registered
+// get ErrMethodNotSupported.
+func (reg *Registry) Resolve(d DID) (*Document, *Meta, error) {
+	res := reg.ResolveContext(context.Background(), d, nil)
+	if err := res.Err(); err != nil {
+		return nil, nil, err
+	}
+	return res.Document, res.DocumentMeta, nil
+}
+
+// ResolveContext implements the Resolver interface. DIDs with a Method not
+// registered get ErrMethodNotSupported.
+func (reg *Registry) ResolveContext(ctx context.Context, d DID, opts *ResolutionOptions) *ResolutionResult {
+	if !validMethodName(d.Method) || d.SpecID == "" {
+		return newErrorResult(fmt.Errorf("%w: %q", ErrInvalid, d.String()))
+	}
+
+	reg.mutex.RLock()
+	r := reg.perMethod[d.Method]
+	reg.mutex.RUnlock()
+
+	if r == nil {
+		return newErrorResult(fmt.Errorf("%w: %q", ErrMethodNotSupported, d.Method))
+	}
+	return r.ResolveContext(ctx, d, opts)
+}
@@ -0,0 +1,88 @@
+package did_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleRegistry() {
+	var reg did.Registry
+	reg.Register("example", resolveExample21)
+
+	resolve := did.Resolve(reg.Resolve)
+	doc, _, err := resolve(did.DID{Method: "example", SpecID: "123"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("got", doc.Subject)
+
+	_, _, err = resolve(did.DID{Method: "other", SpecID: "123"})
+	fmt.Println(err)
+	// Output:
+	// got did:example:123
+	// DID method not supported: "other"
+}
+
+func TestRegistryRegister(t *testing.T) {
+	var reg did.Registry
+	for _, name := range []string{"", "Example", "ex-ample", "ex:ample"} {
+		if err := reg.Register(name, resolveExample21); err == nil {
+			t.Errorf("method name %q got no error", name)
+		}
+	}
+
+	for _, name := range []string{"web", "key", "3", "example"} {
+		if err := reg.Register(name, resolveExample21); err != nil {
+			t.Errorf("method name %q got error: %s", name, err)
+		}
+	}
+	reg.Register("web", nil) // unregister
+
+	want := []string{"3", "example", "key"}
+	if got := reg.Methods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got methods %q, want %q", got, want)
+	}
+}
+
+func TestRegistryResolve(t *testing.T) {
+	var reg did.Registry
+
+	_, _, err := reg.Resolve(did.DID{Method: "example", SpecID: "123"})
+	if !errors.Is(err, did.ErrMethodNotSupported) {
+		t.Errorf("got error %v, want did.ErrMethodNotSupported", err)
+	}
+	if code := did.ErrorCode(err); code != "methodNotSupported" {
+		t.Errorf("got error code %q, want methodNotSupported", code)
+	}
+
+	_, _, err = reg.Resolve(did.DID{Method: "Example", SpecID: "123"})
+	if !errors.Is(err, did.ErrInvalid) {
+		t.Errorf("got error %v, want did.ErrInvalid", err)
+	}
+
+	// concurrent use
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			reg.Register("example", resolveExample21)
+		}()
+		go func() {
+			defer wg.Done()
+			reg.Resolve(did.DID{Method: "example", SpecID: "123"})
+		}()
+	}
+	wg.Wait()
+
+	_, _, err = reg.Resolve(did.DID{Method: "example", SpecID: "456"})
+	if !errors.Is(err, did.ErrNotFound) {
+		t.Errorf("got error %v, want did.ErrNotFound", err)
+	}
+}
@@ -0,0 +1,244 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ResolutionOptions is the “DID resolution options” input. Note that all
+// properties are optional.
+type ResolutionOptions struct {
+	// The (MIME) media type of the preferred representation.
+	Accept string
+
+	// VersionID selects a specific version of the document.
+	VersionID string
+
+	// VersionTime selects the version of the document which was valid at
+	// the given moment in time. The zero value selects the latest.
+	VersionTime time.Time
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (opts *ResolutionOptions) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 1, 128)
+	buf[0] = '{'
+
+	if opts.Accept != "" {
+		buf = append(buf, `"accept":`...)
+		buf = strconv.AppendQuote(buf, opts.Accept)
+	}
+	if opts.VersionID != "" {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `"versionId":`...)
+		buf = strconv.AppendQuote(buf, opts.VersionID)
+	}
+	if !opts.VersionTime.IsZero() {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `"versionTime":`...)
+		buf = strconv.AppendQuote(buf, formatDateTime(opts.VersionTime))
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (opts *ResolutionOptions) UnmarshalJSON(bytes []byte) error {
+	var fields struct {
+		Accept      string `json:"accept"`
+		VersionID   string `json:"versionId"`
+		VersionTime string `json:"versionTime"`
+	}
+	err := json.Unmarshal(bytes, &fields)
+	if err != nil {
+		return fmt.Errorf("DID resolution options: %w", err)
+	}
+
+	opts.Accept = fields.Accept
+	opts.VersionID = fields.VersionID
+	opts.VersionTime = time.Time{}
+	if fields.VersionTime != "" {
+		opts.VersionTime, err = time.Parse(time.RFC3339, fields.VersionTime)
+		if err != nil {
+			return fmt.Errorf("DID resolution options versionTime: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolutionMeta is the “DID resolution metadata”.
+type ResolutionMeta struct {
+	// The (MIME) media type of the representation, if any.
+	ContentType string `json:"contentType,omitempty"`
+
+	// Error has the standardised code [ErrorCode] in case of failure.
+	Error string `json:"error,omitempty"`
+}
+
+// ResolutionResult is the outcome of DID resolution.
+type ResolutionResult struct {
+	Meta ResolutionMeta `json:"didResolutionMetadata"`
+
+	// Document is nil when resolution failed.
+	Document *Document `json:"didDocument"`
+
+	// DocumentMeta is nil when resolution failed.
+	DocumentMeta *Meta `json:"didDocumentMetadata"`
+
+	err error // optional cause of Meta.Error
+}
+
+// Err returns the error which corresponds to the error code in the resolution
+// metadata, if any. The standardised codes map to their respective error value,
+// i.e., ErrInvalid, ErrNotFound, ErrMediaType and ErrMethodNotSupported.
+func (res *ResolutionResult) Err() error {
+	if res.err != nil && ErrorCode(res.err) == res.Meta.Error {
+		return res.err
+	}
+	return errorForCode(res.Meta.Error)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The document is in the
+// JSON-LD representation when the ContentType in Meta is JSONLD.
+func (res *ResolutionResult) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 512)
+
+	buf = append(buf, `{"didResolutionMetadata":`...)
+	bytes, err := json.Marshal(&res.Meta)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, bytes...)
+
+	buf = append(buf, `,"didDocument":`...)
+	if res.Document != nil && res.Meta.ContentType == JSONLD {
+		bytes, err = res.Document.MarshalJSONLD()
+	} else {
+		bytes, err = json.Marshal(res.Document)
+	}
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, bytes...)
+
+	// “If the resolution is unsuccessful, this output MUST be an empty
+	// metadata structure.”
+	buf = append(buf, `,"didDocumentMetadata":`...)
+	if res.DocumentMeta == nil {
+		buf = append(buf, '{', '}')
+	} else {
+		bytes, err = json.Marshal(res.DocumentMeta)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, bytes...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The document is read
+// in the JSON-LD representation when the ContentType in Meta is JSONLD.
+func (res *ResolutionResult) UnmarshalJSON(bytes []byte) error {
+	var fields struct {
+		Meta         ResolutionMeta  `json:"didResolutionMetadata"`
+		Document     json.RawMessage `json:"didDocument"`
+		DocumentMeta json.RawMessage `json:"didDocumentMetadata"`
+	}
+	err := json.Unmarshal(bytes, &fields)
+	if err != nil {
+		return fmt.Errorf("DID resolution result: %w", err)
+	}
+	res.Meta = fields.Meta
+
+	res.Document = nil
+	if len(fields.Document) != 0 && fields.Document[0] != 'n' {
+		res.Document = new(Document)
+		if res.Meta.ContentType == JSONLD {
+			err = res.Document.UnmarshalJSONLD([]byte(fields.Document))
+		} else {
+			err = json.Unmarshal([]byte(fields.Document), res.Document)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	res.DocumentMeta = nil
+	if res.Document != nil && len(fields.DocumentMeta) != 0 && fields.DocumentMeta[0] != 'n' {
+		res.DocumentMeta = new(Meta)
+		err = json.Unmarshal([]byte(fields.DocumentMeta), res.DocumentMeta)
+		if err != nil {
+			return fmt.Errorf("DID document metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resolution applies r with the resolution options. Errors from r are encoded
+// as a standardised code [ErrorCode] in the resolution metadata. Both the JSON
+// and the JSON-LD representation are supported, with JSON as the default. The
+// ContentType in the resolution metadata reflects the representation accepted.
+// Resolve has no means to select a version. The latest version is returned for
+// a VersionID or a VersionTime if, and only if the document metadata confirms
+// it is the version requested, i.e., with an equal VersionID, or with an Updated
+// (or Created when never updated) and a NextUpdate (if any) around VersionTime.
+// Options may be nil.
+func (r Resolve) Resolution(d DID, opts *ResolutionOptions) *ResolutionResult {
+	if opts == nil {
+		opts = new(ResolutionOptions)
+	}
+	res := new(ResolutionResult)
+
+	contentType := JSON
+	switch opts.Accept {
+	case "", JSON:
+		break
+	case JSONLD:
+		contentType = JSONLD
+	default:
+		res.Meta.Error = ErrorCode(ErrMediaType)
+		return res
+	}
+
+	doc, meta, err := r(d)
+	if err != nil {
+		res.err = err
+		res.Meta.Error = ErrorCode(err)
+		return res
+	}
+	if meta == nil {
+		meta = new(Meta)
+	}
+
+	if opts.VersionID != "" && opts.VersionID != meta.VersionID {
+		res.err = fmt.Errorf("%w: no version %q", ErrNotFound, opts.VersionID)
+		res.Meta.Error = ErrorCode(res.err)
+		return res
+	}
+	if !opts.VersionTime.IsZero() {
+		since := meta.Updated
+		if since.IsZero() {
+			// “If a DID document has not been updated, this
+			// property is omitted.”
+			since = meta.Created
+		}
+		if since.IsZero() || since.After(opts.VersionTime) ||
+			(!meta.NextUpdate.IsZero() && !opts.VersionTime.Before(meta.NextUpdate)) {
+			res.err = fmt.Errorf("%w: no version in effect at %s", ErrNotFound, formatDateTime(opts.VersionTime))
+			res.Meta.Error = ErrorCode(res.err)
+			return res
+		}
+	}
+
+	res.Meta.ContentType = contentType
+	res.Document = doc
+	res.DocumentMeta = meta
+	return res
+}
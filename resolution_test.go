@@ -0,0 +1,193 @@
+package did_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleResolutionOptions_MarshalJSON() {
+	opts := did.ResolutionOptions{
+		Accept:      did.JSON,
+		VersionTime: time.Date(2021, 5, 10, 19, 0, 0, 500e6, time.FixedZone("CEST", 2*60*60)),
+	}
+	bytes, err := json.Marshal(&opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s\n", bytes)
+	// Output:
+	// {"accept":"application/did+json","versionTime":"2021-05-10T17:00:01Z"}
+}
+
+func TestResolutionOptionsJSON(t *testing.T) {
+	const sample = `{"accept":"application/did+json","versionId":"1","versionTime":"2021-05-10T17:00:00Z"}`
+
+	var opts did.ResolutionOptions
+	err := json.Unmarshal([]byte(sample), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Accept != did.JSON || opts.VersionID != "1" || !opts.VersionTime.Equal(time.Date(2021, 5, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", opts)
+	}
+
+	bytes, err := json.Marshal(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != sample {
+		t.Errorf("got JSON %s, want %s", bytes, sample)
+	}
+}
+
+func TestResolutionResultJSON(t *testing.T) {
+	res := did.Resolve(resolveExample21).Resolution(did.DID{Method: "example", SpecID: "123"}, nil)
+	if err := res.Err(); err != nil {
+		t.Fatal("resolution error:", err)
+	}
+	if res.Meta.ContentType != did.JSON {
+		t.Errorf("got content type %q, want %q", res.Meta.ContentType, did.JSON)
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got did.ResolutionResult
+	err = json.Unmarshal(bytes, &got)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %s", bytes, err)
+	}
+	if got.Meta != res.Meta {
+		t.Errorf("got resolution metadata %+v, want %+v", got.Meta, res.Meta)
+	}
+	if got.Document == nil || got.Document.Subject != res.Document.Subject {
+		t.Errorf("got document %+v, want subject %s", got.Document, res.Document.Subject)
+	}
+	if got.DocumentMeta == nil {
+		t.Error("document metadata absent")
+	}
+}
+
+func TestResolutionJSONLD(t *testing.T) {
+	res := did.Resolve(resolveExample21).Resolution(did.DID{Method: "example", SpecID: "123"}, &did.ResolutionOptions{Accept: did.JSONLD})
+	if err := res.Err(); err != nil {
+		t.Fatal("resolution error:", err)
+	}
+	if res.Meta.ContentType != did.JSONLD {
+		t.Errorf("got content type %q, want %q", res.Meta.ContentType, did.JSONLD)
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantPrefix = `{"didResolutionMetadata":{"contentType":"application/did+ld+json"},"didDocument":{"@context":["https://www.w3.org/ns/did/v1"],"id":"did:example:123",`
+	if !strings.HasPrefix(string(bytes), wantPrefix) {
+		t.Errorf("got JSON %s\nwant prefix %s", bytes, wantPrefix)
+	}
+
+	var got did.ResolutionResult
+	err = json.Unmarshal(bytes, &got)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %s", bytes, err)
+	}
+	if got.Document == nil || got.Document.Subject != res.Document.Subject {
+		t.Errorf("got document %+v, want subject %s", got.Document, res.Document.Subject)
+	}
+}
+
+func TestResolutionErrors(t *testing.T) {
+	tests := []struct {
+		d        did.DID
+		opts     did.ResolutionOptions
+		wantErr  error
+		wantJSON string
+	}{
+		{
+			did.DID{Method: "example", SpecID: "456"},
+			did.ResolutionOptions{},
+			did.ErrNotFound,
+			`{"didResolutionMetadata":{"error":"notFound"},"didDocument":null,"didDocumentMetadata":{}}`,
+		}, {
+			did.DID{Method: "example", SpecID: "123"},
+			did.ResolutionOptions{Accept: "application/did+cbor"},
+			did.ErrMediaType,
+			`{"didResolutionMetadata":{"error":"representationNotSupported"},"didDocument":null,"didDocumentMetadata":{}}`,
+		}, {
+			did.DID{Method: "example", SpecID: "123"},
+			did.ResolutionOptions{VersionID: "1"},
+			did.ErrNotFound,
+			`{"didResolutionMetadata":{"error":"notFound"},"didDocument":null,"didDocumentMetadata":{}}`,
+		},
+	}
+
+	for _, test := range tests {
+		res := did.Resolve(resolveExample21).Resolution(test.d, &test.opts)
+		if err := res.Err(); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s with %+v got error %v, want %v", test.d, test.opts, err, test.wantErr)
+		}
+
+		bytes, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bytes) != test.wantJSON {
+			t.Errorf("%s with %+v got JSON %s, want %s", test.d, test.opts, bytes, test.wantJSON)
+		}
+
+		var decoded did.ResolutionResult
+		err = json.Unmarshal(bytes, &decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.Err(); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s with %+v got error %v after JSON round-trip, want %v", test.d, test.opts, err, test.wantErr)
+		}
+	}
+}
+
+func TestResolutionVersion(t *testing.T) {
+	updated := time.Date(2023, 8, 10, 13, 40, 6, 0, time.UTC)
+	updatedMeta := &did.Meta{
+		Created:    updated.Add(-24 * time.Hour),
+		Updated:    updated,
+		NextUpdate: updated.Add(time.Hour),
+		VersionID:  "2",
+	}
+	createdMeta := &did.Meta{Created: updated, VersionID: "1"}
+
+	tests := []struct {
+		meta    *did.Meta
+		opts    did.ResolutionOptions
+		wantErr error
+	}{
+		{updatedMeta, did.ResolutionOptions{VersionID: "2"}, nil},
+		{updatedMeta, did.ResolutionOptions{VersionID: "1"}, did.ErrNotFound},
+		{updatedMeta, did.ResolutionOptions{VersionTime: updated}, nil},
+		{updatedMeta, did.ResolutionOptions{VersionTime: updated.Add(-time.Second)}, did.ErrNotFound},
+		{updatedMeta, did.ResolutionOptions{VersionTime: updated.Add(time.Hour)}, did.ErrNotFound},
+		// never updated
+		{createdMeta, did.ResolutionOptions{VersionTime: updated}, nil},
+		{createdMeta, did.ResolutionOptions{VersionTime: updated.Add(365 * 24 * time.Hour)}, nil},
+		{createdMeta, did.ResolutionOptions{VersionTime: updated.Add(-time.Second)}, did.ErrNotFound},
+		{&did.Meta{}, did.ResolutionOptions{VersionTime: updated}, did.ErrNotFound},
+	}
+	for _, test := range tests {
+		meta := test.meta
+		r := did.Resolve(func(d did.DID) (*did.Document, *did.Meta, error) {
+			return &did.Document{Subject: d}, meta, nil
+		})
+		res := r.Resolution(did.DID{Method: "example", SpecID: "123"}, &test.opts)
+		if err := res.Err(); !errors.Is(err, test.wantErr) {
+			t.Errorf("%+v with %+v got error %v, want %v", test.opts, *test.meta, err, test.wantErr)
+		}
+	}
+}
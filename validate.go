@@ -0,0 +1,195 @@
+package did
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrDuplicateID signals an identifier which occurs more than once within the
+// verification methods, or within the services of a DID document.
+var ErrDuplicateID = errors.New("DID document identifier not unique")
+
+// ValidationError is a violation located in the JSON representation.
+type ValidationError struct {
+	// Pointer locates the violation in the JSON of the Document,
+	// conform “JavaScript Object Notation (JSON) Pointer” RFC 6901.
+	Pointer string
+
+	Err error // cause
+}
+
+// Error implements the standard error interface.
+func (e *ValidationError) Error() string {
+	return "DID document " + strconv.Quote(e.Pointer) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// Validate returns all violations found in doc, each as a *ValidationError
+// joined into one error [errors.Join], or nil when none found. Identifiers are
+// compared after resolution of relative URLs against the Subject. References
+// with the Subject must match a verification method in VerificationMethods,
+// while references to any other DID are left to their respective documents.
+//
+// Note that JSON decoding does not apply any of these checks.
+func (doc *Document) Validate() error {
+	var errs []error
+	violation := func(pointer string, err error) {
+		errs = append(errs, &ValidationError{Pointer: pointer, Err: err})
+	}
+
+	if !validDID(doc.Subject) {
+		violation("/id", errors.New("no valid DID subject"))
+	}
+	for i, d := range doc.Controllers {
+		if !validDID(d) {
+			violation("/controller/"+strconv.Itoa(i), errors.New("controller is not a valid DID"))
+		}
+	}
+
+	// Verification method identifiers are resolved once, in order of
+	// appearance, embedded ones included.
+	var methodIDs []*URL
+	var methodPointers []string
+	checkMethod := func(pointer string, m *VerificationMethod) {
+		if m == nil {
+			violation(pointer, errors.New("verification method nil"))
+			return
+		}
+		if m.Type == "" {
+			violation(pointer+"/type", errors.New("verification method has no type"))
+		}
+		if !validDID(m.Controller) {
+			violation(pointer+"/controller", errors.New("verification method controller is not a valid DID"))
+		}
+		if m.ID.IsRelative() && m.ID.RawFragment == "" && m.ID.RawPath == "" && m.ID.RawQuery == "" {
+			violation(pointer+"/id", errors.New("verification method has no identifier"))
+			return
+		}
+
+		id := doc.resolve(&m.ID)
+		for i, prior := range methodIDs {
+			if prior.Equal(id) {
+				violation(pointer+"/id", fmt.Errorf("%w: verification method %q also at %q", ErrDuplicateID, m.ID.String(), methodPointers[i]))
+				return
+			}
+		}
+		methodIDs = append(methodIDs, id)
+		methodPointers = append(methodPointers, pointer)
+	}
+
+	for i, m := range doc.VerificationMethods {
+		checkMethod("/verificationMethod/"+strconv.Itoa(i), m)
+	}
+	// top-level methods only, conform VerificationMethodRefs
+	topLevelIDs := methodIDs[:len(methodIDs):len(methodIDs)]
+
+	for _, purpose := range [...]string{Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation} {
+		r := doc.Relationship(purpose)
+		if r == nil {
+			continue
+		}
+
+		// embedded methods encode before the references
+		for i, m := range r.Methods {
+			checkMethod("/"+purpose+"/"+strconv.Itoa(i), m)
+		}
+
+	MatchRefs:
+		for i, u := range r.URIRefs {
+			pointer := "/" + purpose + "/" + strconv.Itoa(len(r.Methods)+i)
+			if u == nil {
+				violation(pointer, errors.New("verification method reference nil"))
+				continue
+			}
+			resolved := doc.resolve(u)
+			if !resolved.DID.Equal(doc.Subject) {
+				continue // external
+			}
+			for _, id := range topLevelIDs {
+				if id.Equal(resolved) {
+					continue MatchRefs
+				}
+			}
+			violation(pointer, fmt.Errorf("%w: verification method %q not in DID document", ErrNotFound, u.String()))
+		}
+	}
+
+	var serviceIDs, servicePointers []string
+	for i, srv := range doc.Services {
+		pointer := "/service/" + strconv.Itoa(i)
+		if srv == nil {
+			violation(pointer, errors.New("service nil"))
+			continue
+		}
+
+		if id := srv.ID.String(); id == "" {
+			violation(pointer+"/id", errors.New("service has no identifier"))
+		} else {
+			// normalize DID URLs for comparison
+			if u, err := ParseURL(id); err == nil {
+				id = doc.resolve(u).String()
+			}
+			for j, prior := range serviceIDs {
+				if prior == id {
+					violation(pointer+"/id", fmt.Errorf("%w: service %q also at %q", ErrDuplicateID, srv.ID.String(), servicePointers[j]))
+					break
+				}
+			}
+			serviceIDs = append(serviceIDs, id)
+			servicePointers = append(servicePointers, pointer)
+		}
+
+		if len(srv.Types) == 0 {
+			violation(pointer+"/type", errors.New("service has no type"))
+		}
+		for j, t := range srv.Types {
+			if t == "" {
+				violation(pointer+"/type/"+strconv.Itoa(j), errors.New("service type empty"))
+			}
+		}
+
+		validateEndpoint(pointer+"/serviceEndpoint", &srv.Endpoint, violation)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateEndpoint reports violations with pointers conform MarshalJSON.
+func validateEndpoint(pointer string, e *ServiceEndpoint, violation func(string, error)) {
+	n := len(e.URIRefs) + len(e.Maps)
+	if n == 0 {
+		violation(pointer, errors.New("service endpoint empty"))
+		return
+	}
+	entryPointer := func(i int) string {
+		if n == 1 {
+			return pointer // no array
+		}
+		return pointer + "/" + strconv.Itoa(i)
+	}
+
+	for i, u := range e.URIRefs {
+		switch {
+		case u == nil:
+			violation(entryPointer(i), errors.New("service endpoint URI nil"))
+		case u.Scheme == "":
+			violation(entryPointer(i), fmt.Errorf("service endpoint %q is not an absolute URI", u.String()))
+		}
+	}
+	for i, raw := range e.Maps {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &m); err != nil || m == nil {
+			violation(entryPointer(len(e.URIRefs)+i), errors.New("service endpoint map is not a JSON object"))
+		}
+	}
+}
+
+// ValidDID returns whether d is valid. Any method-specific identifier is valid
+// because String escapes when needed.
+func validDID(d DID) bool {
+	return d.SpecID != "" && validMethodName(d.Method)
+}
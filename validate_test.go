@@ -0,0 +1,135 @@
+package did_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleDocument_Validate() {
+	var doc did.Document
+	err := json.Unmarshal([]byte(`{
+		"id": "did:example:123",
+		"verificationMethod": [{
+			"id": "#key-1",
+			"type": "Multikey",
+			"controller": "did:example:123"
+		}],
+		"authentication": [{
+			"id": "did:example:123#key-1",
+			"type": "Multikey",
+			"controller": "did:example:123"
+		}, "#key-2", "did:example:456#key-1"],
+		"service": [{
+			"id": "#hub",
+			"type": "LinkedDomains",
+			"serviceEndpoint": "example.com"
+		}]
+	}`), &doc)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = doc.Validate()
+	var violations interface{ Unwrap() []error }
+	if errors.As(err, &violations) {
+		for _, err := range violations.Unwrap() {
+			fmt.Println(err)
+		}
+	}
+	// Output:
+	// DID document "/authentication/0/id": DID document identifier not unique: verification method "did:example:123#key-1" also at "/verificationMethod/0"
+	// DID document "/authentication/1": DID document not found: verification method "#key-2" not in DID document
+	// DID document "/service/0/serviceEndpoint": service endpoint "example.com" is not an absolute URI
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		pointers []string
+		is       error
+	}{
+		{"W3C example 9", example9, nil, nil},
+		{"W3C example 11", example11, nil, nil},
+		{"duplicate methods", `{
+			"id": "did:example:123",
+			"verificationMethod": [
+				{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"},
+				{"id": "did:example:123#key-1", "type": "Multikey", "controller": "did:example:123"}
+			]
+		}`, []string{"/verificationMethod/1/id"}, did.ErrDuplicateID},
+		{"duplicate embedded methods", `{
+			"id": "did:example:123",
+			"authentication": [{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"}],
+			"keyAgreement": [{"id": "#key-1", "type": "Multikey", "controller": "did:example:123"}]
+		}`, []string{"/keyAgreement/0/id"}, did.ErrDuplicateID},
+		{"duplicate services", `{
+			"id": "did:example:123",
+			"service": [
+				{"id": "did:example:123#hub", "type": "Hub", "serviceEndpoint": "https://example.com/a"},
+				{"id": "#hub", "type": "Hub", "serviceEndpoint": "https://example.com/b"}
+			]
+		}`, []string{"/service/1/id"}, did.ErrDuplicateID},
+		{"missing method reference", `{
+			"id": "did:example:123",
+			"assertionMethod": ["did:example:123#key-1", "did:example:456#key-1"]
+		}`, []string{"/assertionMethod/0"}, did.ErrNotFound},
+		{"endpoint set", `{
+			"id": "did:example:123",
+			"service": [{
+				"id": "#hub",
+				"type": "Hub",
+				"serviceEndpoint": ["https://example.com/", "relative/path", {"uri": "https://example.com/"}]
+			}]
+		}`, []string{"/service/0/serviceEndpoint/1"}, nil},
+	}
+
+	for _, test := range tests {
+		var doc did.Document
+		err := json.Unmarshal([]byte(test.doc), &doc)
+		if err != nil {
+			t.Errorf("%s: unmarshal error: %s", test.name, err)
+			continue
+		}
+
+		err = doc.Validate()
+		if test.is != nil && !errors.Is(err, test.is) {
+			t.Errorf("%s: got error %v, want a %v", test.name, err, test.is)
+		}
+		var pointers []string
+		if err != nil {
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var v *did.ValidationError
+				if !errors.As(err, &v) {
+					t.Errorf("%s: got error type %T, want a *did.ValidationError", test.name, err)
+					continue
+				}
+				pointers = append(pointers, v.Pointer)
+			}
+		}
+		if fmt.Sprint(pointers) != fmt.Sprint(test.pointers) {
+			t.Errorf("%s: got pointers %q, want %q", test.name, pointers, test.pointers)
+		}
+	}
+}
+
+func TestValidateProgrammatic(t *testing.T) {
+	doc := did.Document{
+		Controllers: did.Set{{Method: "Example", SpecID: "123"}},
+		Services:    []*did.Service{{}},
+	}
+	err := doc.Validate()
+	var pointers []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		pointers = append(pointers, err.(*did.ValidationError).Pointer)
+	}
+	want := []string{"/id", "/controller/0", "/service/0/id", "/service/0/type", "/service/0/serviceEndpoint"}
+	if fmt.Sprint(pointers) != fmt.Sprint(want) {
+		t.Errorf("got pointers %q, want %q", pointers, want)
+	}
+}
@@ -0,0 +1,611 @@
+// Package vc implements the “Verifiable Credentials Data Model v2.0”, with
+// read support for v1.1. See https://www.w3.org/TR/vc-data-model-2.0/ for the
+// specification.
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pascaldekloe/did"
+	"github.com/pascaldekloe/did/internal/sorted"
+)
+
+// Base contexts must be the first "@context" entry.
+const (
+	V2 = "https://www.w3.org/ns/credentials/v2"
+	V1 = "https://www.w3.org/2018/credentials/v1"
+)
+
+// Types of the base context.
+const (
+	CredentialType   = "VerifiableCredential"
+	PresentationType = "VerifiablePresentation"
+)
+
+// Credential is a set of claims made by an issuer. Version 1.1 credentials, as
+// in a V1 base context, have their "issuanceDate" as ValidFrom, and their
+// "expirationDate" as ValidUntil, in both directions. Any "validFrom" or
+// "validUntil" in version 1.1 remains an additional property, and vice versa.
+type Credential struct {
+	// Contexts are either a URL as JSON string or an embedded context
+	// as JSON object.
+	Contexts []json.RawMessage // "@context"
+
+	ID    string   // optional URL
+	Types []string // one or more required
+
+	// Issuer is required, except for enveloped credentials.
+	Issuer Entity
+
+	// The validity period is open ended for zero values.
+	ValidFrom  time.Time
+	ValidUntil time.Time
+
+	// One or more claims about subjects.
+	Subjects []*Subject // "credentialSubject"
+
+	Status []*Status         // "credentialStatus"
+	Proofs []json.RawMessage // "proof" as JSON objects
+
+	// A credential MAY include additional properties, such as "name",
+	// "description", "credentialSchema" or "evidence".
+	Additional map[string]json.RawMessage
+}
+
+// Version returns the data model version from the base context, which is
+// either 2 for V2, 1 for V1, or 0 for unknown.
+func (c *Credential) Version() int {
+	return version(c.Contexts)
+}
+
+// ValidityNames returns the property names of ValidFrom and ValidUntil, per
+// data model version.
+func (c *Credential) validityNames() (from, until string) {
+	if c.Version() == 1 {
+		return "issuanceDate", "expirationDate"
+	}
+	return "validFrom", "validUntil"
+}
+
+// ValidAt returns whether t is within the validity period.
+func (c *Credential) ValidAt(t time.Time) bool {
+	return (c.ValidFrom.IsZero() || !t.Before(c.ValidFrom)) &&
+		(c.ValidUntil.IsZero() || !t.After(c.ValidUntil))
+}
+
+// AdditionalString returns the value if, and only if the property is present,
+// and its value is a valid JSON string.
+func (c *Credential) AdditionalString(property string) string {
+	return additionalString(c.Additional, property)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c *Credential) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 512)
+	buf, err := appendContextsAndTypes(buf, c.Contexts, c.ID, c.Types)
+	if err != nil {
+		return nil, fmt.Errorf("verifiable credential: %w", err)
+	}
+
+	if c.Issuer.ID != "" {
+		buf = append(buf, `,"issuer":`...)
+		bytes, err := c.Issuer.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, bytes...)
+	}
+	fromName, untilName := c.validityNames()
+	if !c.ValidFrom.IsZero() {
+		buf = append(buf, ',')
+		buf = strconv.AppendQuote(buf, fromName)
+		buf = append(buf, ':')
+		buf = strconv.AppendQuote(buf, formatDateTime(c.ValidFrom))
+	}
+	if !c.ValidUntil.IsZero() {
+		buf = append(buf, ',')
+		buf = strconv.AppendQuote(buf, untilName)
+		buf = append(buf, ':')
+		buf = strconv.AppendQuote(buf, formatDateTime(c.ValidUntil))
+	}
+
+	buf, err = appendOneOrMany(buf, "credentialSubject", c.Subjects)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = appendOneOrMany(buf, "credentialStatus", c.Status)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = appendOneOrMany(buf, "proof", c.Proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, property := range sorted.Keys(c.Additional) {
+		value := c.Additional[property]
+		switch property {
+		case "@context", "id", "type", "issuer", fromName, untilName, "credentialSubject", "credentialStatus", "proof":
+			return nil, fmt.Errorf("core verifiable-credential property %q in additional set", property)
+		}
+		buf = append(buf, ',')
+		buf = strconv.AppendQuote(buf, property)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *Credential) UnmarshalJSON(bytes []byte) error {
+	// Read all properties as Additional first.
+	*c = Credential{}
+	err := json.Unmarshal(bytes, &c.Additional)
+	if err != nil {
+		return fmt.Errorf("verifiable credential: %w", err)
+	}
+	p := properties{c.Additional, "verifiable credential"}
+
+	// Second, extract the core from Additional.
+	c.Contexts, c.ID, c.Types, err = p.popContextsAndTypes()
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Additional["issuer"]; ok {
+		if err := p.popInto("issuer", &c.Issuer); err != nil {
+			return err
+		}
+	}
+
+	fromName, untilName := c.validityNames()
+	if err := p.popDateTimeInto(fromName, &c.ValidFrom); err != nil {
+		return err
+	}
+	if err := p.popDateTimeInto(untilName, &c.ValidUntil); err != nil {
+		return err
+	}
+
+	if err := popOneOrMany(p, "credentialSubject", &c.Subjects); err != nil {
+		return err
+	}
+	if err := popOneOrMany(p, "credentialStatus", &c.Status); err != nil {
+		return err
+	}
+	return popOneOrMany(p, "proof", &c.Proofs)
+}
+
+// Presentation is data derived from one or more credentials, as shared by a
+// holder.
+type Presentation struct {
+	// Contexts are either a URL as JSON string or an embedded context
+	// as JSON object.
+	Contexts []json.RawMessage // "@context"
+
+	ID    string   // optional URL
+	Types []string // one or more required
+
+	// Holder is optional.
+	Holder Entity
+
+	Credentials []*Credential     // "verifiableCredential"
+	Proofs      []json.RawMessage // "proof" as JSON objects
+
+	// A presentation MAY include additional properties.
+	Additional map[string]json.RawMessage
+}
+
+// Version returns the data model version from the base context, which is
+// either 2 for V2, 1 for V1, or 0 for unknown.
+func (p *Presentation) Version() int {
+	return version(p.Contexts)
+}
+
+// AdditionalString returns the value if, and only if the property is present,
+// and its value is a valid JSON string.
+func (p *Presentation) AdditionalString(property string) string {
+	return additionalString(p.Additional, property)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p *Presentation) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 1024)
+	buf, err := appendContextsAndTypes(buf, p.Contexts, p.ID, p.Types)
+	if err != nil {
+		return nil, fmt.Errorf("verifiable presentation: %w", err)
+	}
+
+	if p.Holder.ID != "" {
+		buf = append(buf, `,"holder":`...)
+		bytes, err := p.Holder.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, bytes...)
+	}
+
+	buf, err = appendOneOrMany(buf, "verifiableCredential", p.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = appendOneOrMany(buf, "proof", p.Proofs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, property := range sorted.Keys(p.Additional) {
+		value := p.Additional[property]
+		switch property {
+		case "@context", "id", "type", "holder", "verifiableCredential", "proof":
+			return nil, fmt.Errorf("core verifiable-presentation property %q in additional set", property)
+		}
+		buf = append(buf, ',')
+		buf = strconv.AppendQuote(buf, property)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Presentation) UnmarshalJSON(bytes []byte) error {
+	// Read all properties as Additional first.
+	*p = Presentation{}
+	err := json.Unmarshal(bytes, &p.Additional)
+	if err != nil {
+		return fmt.Errorf("verifiable presentation: %w", err)
+	}
+	props := properties{p.Additional, "verifiable presentation"}
+
+	// Second, extract the core from Additional.
+	p.Contexts, p.ID, p.Types, err = props.popContextsAndTypes()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.Additional["holder"]; ok {
+		if err := props.popInto("holder", &p.Holder); err != nil {
+			return err
+		}
+	}
+	if err := popOneOrMany(props, "verifiableCredential", &p.Credentials); err != nil {
+		return err
+	}
+	return popOneOrMany(props, "proof", &p.Proofs)
+}
+
+// Entity is either a URL, or an object with an "id" URL plus additional
+// properties, such as "name" or "description".
+type Entity struct {
+	ID string // required
+
+	// Additional is nil for the URL (string) representation.
+	Additional map[string]json.RawMessage
+}
+
+// DID returns the identifier as a DID.
+func (e *Entity) DID() (did.DID, error) {
+	return did.Parse(e.ID)
+}
+
+// AdditionalString returns the value if, and only if the property is present,
+// and its value is a valid JSON string.
+func (e *Entity) AdditionalString(property string) string {
+	return additionalString(e.Additional, property)
+}
+
+// MarshalJSON implements the json.Marshaler interface. Entities without
+// additional properties encode as a JSON string.
+func (e *Entity) MarshalJSON() ([]byte, error) {
+	if e.ID == "" {
+		return nil, errors.New("verifiable-credential entity has no id")
+	}
+	if len(e.Additional) == 0 {
+		return []byte(strconv.Quote(e.ID)), nil
+	}
+	return marshalWithID(e.ID, e.Additional, "verifiable-credential entity")
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *Entity) UnmarshalJSON(bytes []byte) error {
+	*e = Entity{}
+	if len(bytes) != 0 && bytes[0] == '"' {
+		err := json.Unmarshal(bytes, &e.ID)
+		if err != nil {
+			return fmt.Errorf("verifiable-credential entity: %w", err)
+		}
+		if e.ID == "" {
+			return errors.New("verifiable-credential entity URL empty")
+		}
+		return nil
+	}
+
+	err := json.Unmarshal(bytes, &e.Additional)
+	if err != nil {
+		return fmt.Errorf("verifiable-credential entity: %w", err)
+	}
+	p := properties{e.Additional, "verifiable-credential entity"}
+	if _, ok := e.Additional["id"]; !ok {
+		return errors.New(`verifiable-credential entity JSON has no "id"`)
+	}
+	return p.popInto("id", &e.ID)
+}
+
+// Subject has claims about an entity.
+type Subject struct {
+	ID string // optional URL
+
+	// Claims are the properties other than "id".
+	Claims map[string]json.RawMessage
+}
+
+// DID returns the identifier as a DID.
+func (s *Subject) DID() (did.DID, error) {
+	return did.Parse(s.ID)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s *Subject) MarshalJSON() ([]byte, error) {
+	return marshalWithID(s.ID, s.Claims, "verifiable-credential subject")
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Subject) UnmarshalJSON(bytes []byte) error {
+	*s = Subject{}
+	err := json.Unmarshal(bytes, &s.Claims)
+	if err != nil {
+		return fmt.Errorf("verifiable-credential subject: %w", err)
+	}
+	return properties{s.Claims, "verifiable-credential subject"}.popInto("id", &s.ID)
+}
+
+// Status locates information about the current status of a credential, such
+// as whether it is suspended or revoked.
+type Status struct {
+	ID   string // optional URL
+	Type string // required
+
+	// Additional has the properties of the status type, such as
+	// "statusPurpose" and "statusListCredential".
+	Additional map[string]json.RawMessage
+}
+
+// AdditionalString returns the value if, and only if the property is present,
+// and its value is a valid JSON string.
+func (s *Status) AdditionalString(property string) string {
+	return additionalString(s.Additional, property)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s *Status) MarshalJSON() ([]byte, error) {
+	if s.Type == "" {
+		return nil, errors.New("verifiable-credential status has no type")
+	}
+	if _, ok := s.Additional["type"]; ok {
+		return nil, errors.New(`core verifiable-credential status property "type" in additional set`)
+	}
+	m := make(map[string]json.RawMessage, len(s.Additional)+1)
+	for k, v := range s.Additional {
+		m[k] = v
+	}
+	m["type"] = json.RawMessage(strconv.Quote(s.Type))
+	return marshalWithID(s.ID, m, "verifiable-credential status")
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Status) UnmarshalJSON(bytes []byte) error {
+	*s = Status{}
+	err := json.Unmarshal(bytes, &s.Additional)
+	if err != nil {
+		return fmt.Errorf("verifiable-credential status: %w", err)
+	}
+	p := properties{s.Additional, "verifiable-credential status"}
+	if err := p.popInto("id", &s.ID); err != nil {
+		return err
+	}
+	if _, ok := s.Additional["type"]; !ok {
+		return errors.New(`verifiable-credential status JSON has no "type"`)
+	}
+	return p.popInto("type", &s.Type)
+}
+
+func version(contexts []json.RawMessage) int {
+	if len(contexts) == 0 {
+		return 0
+	}
+	var s string
+	if json.Unmarshal([]byte(contexts[0]), &s) != nil {
+		return 0
+	}
+	switch s {
+	case V2:
+		return 2
+	case V1:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func additionalString(m map[string]json.RawMessage, property string) string {
+	raw, ok := m[property]
+	if !ok {
+		return ""
+	}
+	var s string
+	err := json.Unmarshal([]byte(raw), &s)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// FormatDateTime returns t in the XML Schema dateTimeStamp notation.
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// AppendContextsAndTypes opens a JSON object with the "@context", the optional
+// "id", and the "type" properties.
+func appendContextsAndTypes(buf []byte, contexts []json.RawMessage, id string, types []string) ([]byte, error) {
+	if len(contexts) == 0 {
+		return nil, errors.New("no @context")
+	}
+	if len(types) == 0 {
+		return nil, errors.New("no type")
+	}
+
+	buf = append(buf, `{"@context":`...)
+	for i, c := range contexts {
+		if i == 0 {
+			buf = append(buf, '[')
+		} else {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, c...)
+	}
+	buf = append(buf, ']')
+
+	if id != "" {
+		buf = append(buf, `,"id":`...)
+		buf = strconv.AppendQuote(buf, id)
+	}
+
+	buf = append(buf, `,"type":`...)
+	if len(types) == 1 {
+		return strconv.AppendQuote(buf, types[0]), nil
+	}
+	for i, t := range types {
+		if i == 0 {
+			buf = append(buf, '[')
+		} else {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, t)
+	}
+	return append(buf, ']'), nil
+}
+
+// AppendOneOrMany appends the property when values is not empty, with a single
+// value as is, and multiple values as a JSON array.
+func appendOneOrMany[T any](buf []byte, property string, values []T) ([]byte, error) {
+	if len(values) == 0 {
+		return buf, nil
+	}
+	buf = append(buf, ',')
+	buf = strconv.AppendQuote(buf, property)
+	buf = append(buf, ':')
+
+	var bytes []byte
+	var err error
+	if len(values) == 1 {
+		bytes, err = json.Marshal(values[0])
+	} else {
+		bytes, err = json.Marshal(values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, bytes...), nil
+}
+
+// MarshalWithID encodes a JSON object with an optional "id" first.
+func marshalWithID(id string, additional map[string]json.RawMessage, desc string) ([]byte, error) {
+	buf := make([]byte, 1, 256)
+	buf[0] = '{'
+	if id != "" {
+		buf = append(buf, `"id":`...)
+		buf = strconv.AppendQuote(buf, id)
+	}
+	for _, property := range sorted.Keys(additional) {
+		value := additional[property]
+		if property == "id" {
+			return nil, fmt.Errorf(`core %s property "id" in additional set`, desc)
+		}
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, property)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// Properties of a JSON object with a description for errors.
+type properties struct {
+	m    map[string]json.RawMessage
+	desc string
+}
+
+// PopInto unmarshals a property, if present.
+func (p properties) popInto(name string, pointer any) error {
+	raw, ok := p.m[name]
+	if !ok {
+		return nil
+	}
+	delete(p.m, name)
+
+	err := json.Unmarshal([]byte(raw), pointer)
+	if err != nil {
+		return fmt.Errorf("%s JSON %q: %w", p.desc, name, err)
+	}
+	return nil
+}
+
+// PopDateTimeInto unmarshals a date–time property, if present.
+func (p properties) popDateTimeInto(name string, pointer *time.Time) error {
+	var s string
+	if err := p.popInto(name, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("%s JSON %q: %w", p.desc, name, err)
+	}
+	*pointer = t
+	return nil
+}
+
+func (p properties) popContextsAndTypes() (contexts []json.RawMessage, id string, types []string, err error) {
+	if _, ok := p.m["@context"]; !ok {
+		return nil, "", nil, fmt.Errorf(`%s JSON has no "@context"`, p.desc)
+	}
+	if err := popOneOrMany(p, "@context", &contexts); err != nil {
+		return nil, "", nil, err
+	}
+	if err := p.popInto("id", &id); err != nil {
+		return nil, "", nil, err
+	}
+	if _, ok := p.m["type"]; !ok {
+		return nil, "", nil, fmt.Errorf(`%s JSON has no "type"`, p.desc)
+	}
+	if err := popOneOrMany(p, "type", &types); err != nil {
+		return nil, "", nil, err
+	}
+	if len(types) == 0 {
+		return nil, "", nil, fmt.Errorf(`%s JSON "type" array empty`, p.desc)
+	}
+	return contexts, id, types, nil
+}
+
+// PopOneOrMany unmarshals a property, if present, with either a single value
+// or a JSON array of values.
+func popOneOrMany[T any](p properties, name string, pointer *[]T) error {
+	raw, ok := p.m[name]
+	if !ok {
+		return nil
+	}
+	if len(raw) != 0 && raw[0] == '[' {
+		return p.popInto(name, pointer)
+	}
+	*pointer = make([]T, 1)
+	return p.popInto(name, &(*pointer)[0])
+}
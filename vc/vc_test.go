@@ -0,0 +1,239 @@
+package vc_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/did/vc"
+)
+
+// Example 2 from the data model specification, with status added.
+const example2 = `{
+  "@context": [
+    "https://www.w3.org/ns/credentials/v2",
+    "https://www.w3.org/ns/credentials/examples/v2"
+  ],
+  "id": "http://university.example/credentials/3732",
+  "type": ["VerifiableCredential", "ExampleDegreeCredential"],
+  "issuer": "https://university.example/issuers/565049",
+  "validFrom": "2010-01-01T00:00:00Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "degree": {
+      "type": "ExampleBachelorDegree",
+      "name": "Bachelor of Science and Arts"
+    }
+  },
+  "credentialStatus": {
+    "id": "https://university.example/credentials/status/3#94567",
+    "type": "BitstringStatusListEntry",
+    "statusPurpose": "revocation",
+    "statusListIndex": "94567",
+    "statusListCredential": "https://university.example/credentials/status/3"
+  }
+}`
+
+func ExampleCredential() {
+	var c vc.Credential
+	err := json.Unmarshal([]byte(example2), &c)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println("version:", c.Version())
+	fmt.Println("issuer:", c.Issuer.ID)
+	fmt.Println("subject:", c.Subjects[0].ID)
+	fmt.Printf("degree: %s\n", c.Subjects[0].Claims["degree"])
+	fmt.Println("status purpose:", c.Status[0].AdditionalString("statusPurpose"))
+	fmt.Println("valid now:", c.ValidAt(time.Now()))
+	// Output:
+	// version: 2
+	// issuer: https://university.example/issuers/565049
+	// subject: did:example:ebfeb1f712ebc6f1c276e12ec21
+	// degree: {
+	//       "type": "ExampleBachelorDegree",
+	//       "name": "Bachelor of Science and Arts"
+	//     }
+	// status purpose: revocation
+	// valid now: true
+}
+
+func TestCredentialJSON(t *testing.T) {
+	const sample = `{"@context":["https://www.w3.org/ns/credentials/v2"],"id":"urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33","type":["VerifiableCredential","ExampleCredential"],"issuer":{"id":"did:example:2g55q912ec3476eba2l9812ecbfe","name":"Example Issuer"},"validFrom":"2023-01-01T00:00:00Z","validUntil":"2033-01-01T00:00:00.5Z","credentialSubject":[{"id":"did:example:a","name":"A"},{"name":"B"}],"proof":{"type":"DataIntegrityProof"},"name":"Example"}`
+
+	var c vc.Credential
+	if err := json.Unmarshal([]byte(sample), &c); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := c.Issuer.DID(); err != nil || d.Method != "example" {
+		t.Errorf("got issuer DID %s, %v", d, err)
+	}
+	if got := c.Issuer.AdditionalString("name"); got != "Example Issuer" {
+		t.Errorf("got issuer name %q", got)
+	}
+	if len(c.Subjects) != 2 || c.Subjects[1].ID != "" {
+		t.Errorf("got subjects %+v", c.Subjects)
+	}
+	if len(c.Proofs) != 1 {
+		t.Errorf("got %d proofs, want 1", len(c.Proofs))
+	}
+	if got := c.AdditionalString("name"); got != "Example" {
+		t.Errorf("got name %q", got)
+	}
+	if !c.ValidAt(c.ValidFrom) || !c.ValidAt(c.ValidUntil) || c.ValidAt(c.ValidUntil.Add(time.Nanosecond)) {
+		t.Error("validity period boundaries")
+	}
+
+	bytes, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != sample {
+		t.Errorf("got JSON %s\nwant %s", bytes, sample)
+	}
+}
+
+func TestCredentialJSONDeterministic(t *testing.T) {
+	const sample = `{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","issuer":{"id":"did:example:issuer","description":"D","image":"I","name":"N"},"credentialSubject":{"id":"did:example:a","age":42,"family":"F","given":"G"},"description":"D","evidence":[],"name":"N"}`
+
+	var c vc.Credential
+	if err := json.Unmarshal([]byte(sample), &c); err != nil {
+		t.Fatal(err)
+	}
+	// map iteration order is random
+	for i := 0; i < 20; i++ {
+		bytes, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bytes) != sample {
+			t.Fatalf("got JSON %s\nwant %s", bytes, sample)
+		}
+	}
+}
+
+func TestCredentialV1(t *testing.T) {
+	const sample = `{
+	  "@context": ["https://www.w3.org/2018/credentials/v1"],
+	  "type": ["VerifiableCredential"],
+	  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+	  "issuanceDate": "2010-01-01T19:23:24Z",
+	  "expirationDate": "2020-01-01T19:23:24Z",
+	  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"}
+	}`
+
+	var c vc.Credential
+	if err := json.Unmarshal([]byte(sample), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 1 {
+		t.Errorf("got version %d, want 1", c.Version())
+	}
+	if want := time.Date(2010, 1, 1, 19, 23, 24, 0, time.UTC); !c.ValidFrom.Equal(want) {
+		t.Errorf("got validFrom %s, want %s", c.ValidFrom, want)
+	}
+	if want := time.Date(2020, 1, 1, 19, 23, 24, 0, time.UTC); !c.ValidUntil.Equal(want) {
+		t.Errorf("got validUntil %s, want %s", c.ValidUntil, want)
+	}
+	if len(c.Additional) != 0 {
+		t.Errorf("got additional properties %q", c.Additional)
+	}
+
+	bytes, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"@context":["https://www.w3.org/2018/credentials/v1"],"type":"VerifiableCredential","issuer":"did:example:76e12ec712ebc6f1c221ebfeb1f","issuanceDate":"2010-01-01T19:23:24Z","expirationDate":"2020-01-01T19:23:24Z","credentialSubject":{"id":"did:example:ebfeb1f712ebc6f1c276e12ec21"}}`
+	if string(bytes) != want {
+		t.Errorf("got JSON %s\nwant %s", bytes, want)
+	}
+}
+
+func TestCredentialVersionTerms(t *testing.T) {
+	tests := []struct{ sample, want string }{
+		// validity terms of the other version remain additional
+		{`{"@context":"https://www.w3.org/2018/credentials/v1","type":"VerifiableCredential","validFrom":"2010-01-01T00:00:00Z"}`,
+			`{"@context":["https://www.w3.org/2018/credentials/v1"],"type":"VerifiableCredential","validFrom":"2010-01-01T00:00:00Z"}`},
+		{`{"@context":"https://www.w3.org/ns/credentials/v2","type":"VerifiableCredential","issuanceDate":"2010-01-01T00:00:00Z"}`,
+			`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","issuanceDate":"2010-01-01T00:00:00Z"}`},
+	}
+	for _, test := range tests {
+		var c vc.Credential
+		if err := json.Unmarshal([]byte(test.sample), &c); err != nil {
+			t.Errorf("%s got error: %s", test.sample, err)
+			continue
+		}
+		if !c.ValidFrom.IsZero() {
+			t.Errorf("%s got ValidFrom %s, want zero", test.sample, c.ValidFrom)
+		}
+		bytes, err := json.Marshal(&c)
+		if err != nil {
+			t.Errorf("%s got marshal error: %s", test.sample, err)
+			continue
+		}
+		if string(bytes) != test.want {
+			t.Errorf("%s got JSON %s\nwant %s", test.sample, bytes, test.want)
+		}
+	}
+
+	// V1 output never has version 2.0 terms
+	c := vc.Credential{
+		Contexts:  []json.RawMessage{json.RawMessage(`"` + vc.V1 + `"`)},
+		Types:     []string{vc.CredentialType},
+		ValidFrom: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	bytes, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"@context":["https://www.w3.org/2018/credentials/v1"],"type":"VerifiableCredential","issuanceDate":"2010-01-01T00:00:00Z"}`
+	if string(bytes) != want {
+		t.Errorf("got JSON %s\nwant %s", bytes, want)
+	}
+	c.Additional = map[string]json.RawMessage{"issuanceDate": json.RawMessage(`"2011-01-01T00:00:00Z"`)}
+	if _, err := json.Marshal(&c); err == nil {
+		t.Error("no error for issuanceDate in additional set of V1")
+	}
+}
+
+func TestPresentationJSON(t *testing.T) {
+	sample := `{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiablePresentation","holder":"did:example:holder","verifiableCredential":` +
+		`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","issuer":"did:example:issuer","credentialSubject":{"id":"did:example:holder"}}}`
+
+	var p vc.Presentation
+	if err := json.Unmarshal([]byte(sample), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Holder.ID != "did:example:holder" || len(p.Credentials) != 1 || p.Credentials[0].Issuer.ID != "did:example:issuer" {
+		t.Errorf("got presentation %+v", p)
+	}
+
+	bytes, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != sample {
+		t.Errorf("got JSON %s\nwant %s", bytes, sample)
+	}
+}
+
+func TestCredentialJSONErrors(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`{"type":"VerifiableCredential"}`,
+		`{"@context":["https://www.w3.org/ns/credentials/v2"]}`,
+		`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":[]}`,
+		`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","issuer":{"name":"X"}}`,
+		`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","validFrom":"yesterday"}`,
+		`{"@context":["https://www.w3.org/ns/credentials/v2"],"type":"VerifiableCredential","credentialStatus":{"id":"urn:x"}}`,
+	}
+	for _, sample := range tests {
+		var c vc.Credential
+		if err := json.Unmarshal([]byte(sample), &c); err == nil {
+			t.Errorf("%s got no error", sample)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package did
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Verification relationships by their property name, a.k.a. the purpose of a
+// verification method.
+const (
+	Authentication       = "authentication"
+	AssertionMethod      = "assertionMethod"
+	KeyAgreement         = "keyAgreement"
+	CapabilityInvocation = "capabilityInvocation"
+	CapabilityDelegation = "capabilityDelegation"
+)
+
+// Verification errors distinguish between authorization and cryptography.
+var (
+	// ErrNotAuthorized signals a verification method which is not in the
+	// verification relationship of the purpose.
+	ErrNotAuthorized = errors.New("DID verification method not authorized for purpose")
+
+	// ErrSignature signals a signature which does not match the message.
+	ErrSignature = errors.New("DID signature verification failed")
+)
+
+// Relationship returns the verification relationship of a purpose, with nil
+// for unknown purposes, and for relationships absent in doc.
+func (doc *Document) Relationship(purpose string) *VerificationRelationship {
+	switch purpose {
+	case Authentication:
+		return doc.Authentication
+	case AssertionMethod:
+		return doc.AssertionMethod
+	case KeyAgreement:
+		return doc.KeyAgreement
+	case CapabilityInvocation:
+		return doc.CapabilityInvocation
+	case CapabilityDelegation:
+		return doc.CapabilityDelegation
+	default:
+		return nil
+	}
+}
+
+// MethodFor returns the verification method identified by u if, and only if
+// it is in the verification relationship of purpose, either embedded or by
+// reference [VerificationMethodRefs]. Relative URLs resolve against the
+// Subject. Errors include ErrNotAuthorized when the method is not in the
+// relationship, and ErrNotFound when referenced methods are absent in doc.
+func (doc *Document) MethodFor(purpose string, u *URL) (*VerificationMethod, error) {
+	switch purpose {
+	case Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation:
+		break
+	default:
+		return nil, fmt.Errorf("DID verification relationship %q unknown", purpose)
+	}
+	u = doc.resolve(u)
+
+	r := doc.Relationship(purpose)
+	if r == nil {
+		return nil, fmt.Errorf("%w: DID document has no %s", ErrNotAuthorized, purpose)
+	}
+	for _, m := range r.Methods {
+		if m == nil {
+			continue
+		}
+		if doc.resolve(&m.ID).Equal(u) {
+			return m, nil
+		}
+	}
+
+	perURI, _ := doc.VerificationMethodRefs()
+	for _, ref := range r.URIRefs {
+		if !doc.resolve(ref).Equal(u) {
+			continue
+		}
+		if m, ok := perURI[ref]; ok {
+			return m, nil
+		}
+		return nil, fmt.Errorf("%w: %s reference %s not in DID document", ErrNotFound, purpose, u)
+	}
+	return nil, fmt.Errorf("%w: %s not in %s", ErrNotAuthorized, u, purpose)
+}
+
+// Verify checks the signature of message with the verification method that u
+// identifies, which must be authorized for the purpose [MethodFor]. Ed25519
+// signatures are plain. ECDSA signatures may be either in the fixed-size form
+// of RFC 7518 (r‖s) or ASN.1 DER encoded, with SHA-256 for P-256, SHA-384 for
+// P-384 and SHA-512 for P-521. RSA signatures are PKCS #1 v1.5 with SHA-256.
+// Errors include ErrSignature for mismatches, and any of the MethodFor errors.
+func (doc *Document) Verify(purpose string, u *URL, message, signature []byte) error {
+	m, err := doc.MethodFor(purpose, u)
+	if err != nil {
+		return err
+	}
+	key, err := m.PublicKey()
+	if err != nil {
+		return err
+	}
+	return verifySignature(key, message, signature)
+}
+
+// PublicKey returns the key from either the "publicKeyJwk" [JWK] or the
+// "publicKeyMultibase" [PublicKeyMultibase] property.
+func (m *VerificationMethod) PublicKey() (crypto.PublicKey, error) {
+	jwk, err := m.JWK()
+	if err != nil {
+		return nil, err
+	}
+	if jwk != nil {
+		return jwk.PublicKey()
+	}
+
+	key, err := m.PublicKeyMultibase()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("DID verification-method %s has no publicKeyJwk nor publicKeyMultibase", m.ID.String())
+	}
+	return key, nil
+}
+
+func verifySignature(key crypto.PublicKey, message, signature []byte) error {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return ErrSignature
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch key.Curve.Params().BitSize {
+		case 256:
+			sum := sha256.Sum256(message)
+			digest = sum[:]
+		case 384:
+			sum := sha512.Sum384(message)
+			digest = sum[:]
+		default:
+			sum := sha512.Sum512(message)
+			digest = sum[:]
+		}
+
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return ErrSignature
+		}
+		return nil
+
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrSignature
+		}
+		return nil
+
+	case *ecdh.PublicKey:
+		return fmt.Errorf("DID verification method with %s key can't verify signatures", key.Curve())
+	default:
+		return fmt.Errorf("DID verification method with %T key not supported", key)
+	}
+}
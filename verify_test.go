@@ -0,0 +1,150 @@
+package did_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pascaldekloe/did"
+)
+
+func ExampleDocument_Verify() {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	subject := did.DID{Method: "example", SpecID: "123"}
+	m := &did.VerificationMethod{
+		ID:         did.URL{DID: subject, RawFragment: "#key-1"},
+		Type:       did.MultikeyType,
+		Controller: subject,
+	}
+	if err := m.SetPublicKeyMultibase(public); err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc := &did.Document{
+		Subject:             subject,
+		VerificationMethods: []*did.VerificationMethod{m},
+		KeyAgreement:        &did.VerificationRelationship{URIRefs: []*did.URL{&m.ID}},
+	}
+
+	message := []byte("hello")
+	signature := ed25519.Sign(private, message)
+	err = doc.Verify(did.Authentication, &m.ID, message, signature)
+	fmt.Println("not authorized:", errors.Is(err, did.ErrNotAuthorized))
+
+	doc.Authentication = &did.VerificationRelationship{URIRefs: []*did.URL{&m.ID}}
+	err = doc.Verify(did.Authentication, &m.ID, message, signature)
+	fmt.Println("verify error:", err)
+	// Output:
+	// not authorized: true
+	// verify error: <nil>
+}
+
+func TestVerify(t *testing.T) {
+	subject := did.DID{Method: "example", SpecID: "123"}
+	newMethod := func(fragment string, key crypto.PublicKey) *did.VerificationMethod {
+		m := &did.VerificationMethod{
+			ID:         did.URL{DID: subject, RawFragment: fragment},
+			Type:       "JsonWebKey2020",
+			Controller: subject,
+		}
+		if err := m.SetJWK(must(did.NewJWK(key))); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	edKey, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edMethod := newMethod("#ed", edKey)
+	ecMethod := newMethod("#ec", &ecPrivate.PublicKey)
+	rsaMethod := newMethod("#rsa", &rsaPrivate.PublicKey)
+	doc := &did.Document{
+		Subject:             subject,
+		VerificationMethods: []*did.VerificationMethod{edMethod, rsaMethod},
+		Authentication: &did.VerificationRelationship{
+			URIRefs: []*did.URL{
+				{RawFragment: "#ed"}, // relative
+				{DID: subject, RawFragment: "#absent"},
+			},
+		},
+		AssertionMethod: &did.VerificationRelationship{
+			Methods: []*did.VerificationMethod{nil, ecMethod}, // nil is skipped
+			URIRefs: []*did.URL{&rsaMethod.ID},
+		},
+	}
+
+	message := []byte("hello")
+	ecDigest := sha512.Sum384(message)
+	r, s, err := ecdsa.Sign(rand.Reader, ecPrivate, ecDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecFixed := append(r.FillBytes(make([]byte, 48)), s.FillBytes(make([]byte, 48))...)
+	ecDER, err := ecdsa.SignASN1(rand.Reader, ecPrivate, ecDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaDigest := sha256.Sum256(message)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivate, crypto.SHA256, rsaDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edPrivate, message)
+
+	tests := []struct {
+		purpose   string
+		fragment  string
+		signature []byte
+		want      error
+	}{
+		{did.Authentication, "#ed", edSig, nil},
+		{did.Authentication, "#ed", edSig[1:], did.ErrSignature},
+		{did.AssertionMethod, "#ed", edSig, did.ErrNotAuthorized},
+		{did.KeyAgreement, "#ed", edSig, did.ErrNotAuthorized},
+		{did.Authentication, "#absent", edSig, did.ErrNotFound},
+		{did.AssertionMethod, "#ec", ecFixed, nil},
+		{did.AssertionMethod, "#ec", ecDER, nil},
+		{did.AssertionMethod, "#ec", ecFixed[1:], did.ErrSignature},
+		{did.Authentication, "#ec", ecFixed, did.ErrNotAuthorized},
+		{did.AssertionMethod, "#rsa", rsaSig, nil},
+		{did.AssertionMethod, "#rsa", edSig, did.ErrSignature},
+	}
+	for _, test := range tests {
+		u := &did.URL{RawFragment: test.fragment}
+		err := doc.Verify(test.purpose, u, message, test.signature)
+		switch {
+		case test.want == nil && err != nil:
+			t.Errorf("%s %s got error: %s", test.purpose, test.fragment, err)
+		case test.want != nil && !errors.Is(err, test.want):
+			t.Errorf("%s %s got error %v, want %v", test.purpose, test.fragment, err, test.want)
+		}
+	}
+
+	if err := doc.Verify("proofOfWork", &edMethod.ID, message, edSig); err == nil {
+		t.Error("unknown purpose got no error")
+	}
+}